@@ -0,0 +1,129 @@
+package d3
+
+import "fmt"
+
+// A Frustum is a convex volume bounded by 6 planes, typically the volume
+// visible from a camera. The normals of the planes point toward the inside of
+// the frustum.
+//
+// Planes built by NewFrustumFromMat4 are in order left, right, bottom, top,
+// near and far, though the tests performed by the methods of Frustum don't
+// depend on it.
+type Frustum [6]Plane
+
+// NewFrustum returns the frustum bounded by the given planes, whose normals
+// must point toward the inside of the frustum.
+func NewFrustum(left, right, bottom, top, near, far Plane) Frustum {
+	return Frustum{left, right, bottom, top, near, far}
+}
+
+// NewFrustumFromMat4 extracts the frustum from m, a projection or
+// view-projection matrix following the OpenGL conventions, as the ones built by
+// NewMat4Perspective or NewMat4Ortho. The planes are expressed in the
+// coordinate space m transforms from, that is the world space for a
+// view-projection matrix.
+func NewFrustumFromMat4(m Mat4) Frustum {
+	// Gribb-Hartmann: a point p is inside the clipping volume if
+	// -w <= x, y, z <= w, with (x, y, z, w) = m * p.
+	var f Frustum
+	for i := 0; i < 3; i++ {
+		f[2*i] = newPlaneABCD(
+			m.At(3, 0)+m.At(i, 0),
+			m.At(3, 1)+m.At(i, 1),
+			m.At(3, 2)+m.At(i, 2),
+			m.At(3, 3)+m.At(i, 3))
+		f[2*i+1] = newPlaneABCD(
+			m.At(3, 0)-m.At(i, 0),
+			m.At(3, 1)-m.At(i, 1),
+			m.At(3, 2)-m.At(i, 2),
+			m.At(3, 3)-m.At(i, 3))
+	}
+	return f
+}
+
+// Containment describes the position of an object relatively to a closed
+// volume.
+type Containment int
+
+const (
+	// Outside indicates that the object is entirely outside of the volume.
+	Outside Containment = iota
+
+	// Intersects indicates that the object is partially inside the volume.
+	Intersects
+
+	// Inside indicates that the object is entirely inside the volume.
+	Inside
+)
+
+func (c Containment) String() string {
+	switch c {
+	case Outside:
+		return "outside"
+	case Intersects:
+		return "intersects"
+	case Inside:
+		return "inside"
+	}
+	return fmt.Sprintf("Containment(%d)", int(c))
+}
+
+// ContainsPoint reports whether p is inside f or on its boundary.
+func (f Frustum) ContainsPoint(p Vec3) bool {
+	for i := range f {
+		if f[i].Dist(p) < 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ClassifySphere returns the position of the sphere of center c and radius r
+// relatively to f.
+//
+// The test is conservative: a sphere lying outside of f, near one of its
+// edges, may be reported as intersecting it.
+func (f Frustum) ClassifySphere(c Vec3, r float32) Containment {
+	res := Inside
+	for i := range f {
+		d := f[i].Dist(c)
+		if d < -r {
+			return Outside
+		}
+		if d < r {
+			res = Intersects
+		}
+	}
+	return res
+}
+
+// ClassifyRect returns the position of r relatively to f.
+//
+// The test is conservative: a rectangle lying outside of f, near one of its
+// edges, may be reported as intersecting it.
+func (f Frustum) ClassifyRect(r Rectangle) Containment {
+	res := Inside
+	for i := range f {
+		d, e := f[i].rectDist(r)
+		if d < -e {
+			return Outside
+		}
+		if d < e {
+			res = Intersects
+		}
+	}
+	return res
+}
+
+// IntersectsRect reports whether r is, at least partially, inside f. It's a
+// shorthand for f.ClassifyRect(r) != Outside, and is as conservative.
+func (f Frustum) IntersectsRect(r Rectangle) bool {
+	return f.ClassifyRect(r) != Outside
+}
+
+// IntersectsSphere reports whether the sphere of center c and radius r is, at
+// least partially, inside f. It's a shorthand for
+// f.ClassifySphere(c, r) != Outside, and is as conservative.
+func (f Frustum) IntersectsSphere(c Vec3, r float32) bool {
+	return f.ClassifySphere(c, r) != Outside
+}
@@ -0,0 +1,96 @@
+package d3
+
+import (
+	"math"
+	"testing"
+)
+
+func TestFrustumFromMat4(t *testing.T) {
+	// camera at (0,0,10) looking toward -Z, the frustum is a pyramid with
+	// apex at the eye, cut at z=9 and z=-90. Its half-width is 2|z-10| and its
+	// half-height |z-10|.
+	proj := NewMat4Perspective(math.Pi/2, 2, 1, 100)
+	view := NewMat4LookAt(Vec3{0, 0, 10}, Vec3{0, 0, 0}, Vec3{0, 1, 0})
+	f := NewFrustumFromMat4(proj.Mul(view))
+
+	var points = []struct {
+		p    Vec3
+		want bool
+	}{
+		{Vec3{0, 0, 0}, true},
+		{Vec3{19.9, 9.9, 0}, true},
+		{Vec3{20.1, 0, 0}, false},
+		{Vec3{0, -10.1, 0}, false},
+		{Vec3{0, 0, 9.5}, false},
+		{Vec3{0, 0, 8.9}, true},
+		{Vec3{0, 0, -89}, true},
+		{Vec3{0, 0, -91}, false},
+		{Vec3{0, 0, 20}, false},
+	}
+	for _, tt := range points {
+		if got := f.ContainsPoint(tt.p); got != tt.want {
+			t.Errorf("ContainsPoint(%v) = %t, want %t", tt.p, got, tt.want)
+		}
+	}
+
+	// same frustum, from its planes
+	g := NewFrustum(
+		NewPlane(Vec3{1, 0, -2}, Vec3{0, 0, 10}),
+		NewPlane(Vec3{-1, 0, -2}, Vec3{0, 0, 10}),
+		NewPlane(Vec3{0, 1, -1}, Vec3{0, 0, 10}),
+		NewPlane(Vec3{0, -1, -1}, Vec3{0, 0, 10}),
+		NewPlane(Vec3{0, 0, -1}, Vec3{0, 0, 9}),
+		NewPlane(Vec3{0, 0, 1}, Vec3{0, 0, -90}),
+	)
+	for i := range f {
+		if !vec3ApproxEps(f[i].Normal, g[i].Normal, 1e-5) || math.Abs(float64(f[i].D-g[i].D)) > 1e-3 {
+			t.Errorf("plane %d = %v, want %v", i, f[i], g[i])
+		}
+	}
+}
+
+func TestFrustumClassify(t *testing.T) {
+	// unit cube [-1,1]³, the orthographic clipping volume
+	f := NewFrustumFromMat4(NewMat4Identity())
+
+	var rects = []struct {
+		r    Rectangle
+		want Containment
+	}{
+		{Rect(-0.5, -0.5, -0.5, 0.5, 0.5, 0.5), Inside},
+		{Rect(-1, -1, -1, 1, 1, 1), Inside},
+		{Rect(0.5, 0.5, 0.5, 2, 2, 2), Intersects},
+		{Rect(-3, -3, -3, 3, 3, 3), Intersects},
+		{Rect(-3, -0.5, -0.5, 3, 0.5, 0.5), Intersects},
+		{Rect(1.5, 0, 0, 2, 1, 1), Outside},
+		{Rect(-1, -1, -5, 1, 1, -1.1), Outside},
+	}
+	for _, tt := range rects {
+		if got := f.ClassifyRect(tt.r); got != tt.want {
+			t.Errorf("ClassifyRect(%v) = %v, want %v", tt.r, got, tt.want)
+		}
+		if got := f.IntersectsRect(tt.r); got != (tt.want != Outside) {
+			t.Errorf("IntersectsRect(%v) = %t", tt.r, got)
+		}
+	}
+
+	var spheres = []struct {
+		c    Vec3
+		r    float32
+		want Containment
+	}{
+		{Vec3{0, 0, 0}, 0.5, Inside},
+		{Vec3{0, 0, 0}, 1.5, Intersects},
+		{Vec3{1.5, 0, 0}, 0.6, Intersects},
+		{Vec3{1.5, 0, 0}, 0.4, Outside},
+		{Vec3{0, -3, 0}, 1, Outside},
+	}
+	for _, tt := range spheres {
+		if got := f.ClassifySphere(tt.c, tt.r); got != tt.want {
+			t.Errorf("ClassifySphere(%v, %v) = %v, want %v", tt.c, tt.r, got, tt.want)
+		}
+		if got := f.IntersectsSphere(tt.c, tt.r); got != (tt.want != Outside) {
+			t.Errorf("IntersectsSphere(%v, %v) = %t", tt.c, tt.r, got)
+		}
+	}
+}
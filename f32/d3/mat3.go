@@ -0,0 +1,215 @@
+package d3
+
+import (
+	"fmt"
+
+	"github.com/arl/math32"
+)
+
+// Mat3 is a 3x3 matrix. It is made up of a slice of 9 32 bits floating points
+// numbers, stored in column-major order, as OpenGL expects them: the element
+// at row i and column j is m[j*3+i].
+//
+// Multiplying a Mat3 by a Vec3 transforms it as a column vector, so the
+// product of matrices a*b applies b first, then a.
+type Mat3 []float32
+
+// NewMat3 allocates and returns a new Mat3 where each element has its zero
+// value.
+func NewMat3() Mat3 {
+	return make(Mat3, 9)
+}
+
+// NewMat3Identity allocates and returns a new identity Mat3.
+func NewMat3Identity() Mat3 {
+	return Mat3{
+		1, 0, 0,
+		0, 1, 0,
+		0, 0, 1,
+	}
+}
+
+// NewMat3From allocates and returns a new Mat3 that is the copy of m1.
+func NewMat3From(m1 Mat3) Mat3 {
+	m := NewMat3()
+	copy(m, m1)
+	return m
+}
+
+// NewMat3Scale allocates and returns a new Mat3 that scales vectors by the
+// components of v along each axis.
+func NewMat3Scale(v Vec3) Mat3 {
+	return Mat3{
+		v[0], 0, 0,
+		0, v[1], 0,
+		0, 0, v[2],
+	}
+}
+
+// NewMat3Rotate allocates and returns a new Mat3 that rotates vectors by angle
+// radians around axis, counter-clockwise when axis points toward the
+// observer. axis doesn't need to be normalized.
+func NewMat3Rotate(axis Vec3, angle float32) Mat3 {
+	l := axis.Len()
+	x, y, z := axis[0]/l, axis[1]/l, axis[2]/l
+	s, c := math32.Sincos(angle)
+	t := 1 - c
+	return Mat3{
+		x*x*t + c, y*x*t + z*s, z*x*t - y*s,
+		x*y*t - z*s, y*y*t + c, z*y*t + x*s,
+		x*z*t + y*s, y*z*t - x*s, z*z*t + c,
+	}
+}
+
+// At returns the element of m at row i and column j.
+func (m Mat3) At(i, j int) float32 {
+	return m[j*3+i]
+}
+
+// Set sets the element of m at row i and column j to v.
+func (m Mat3) Set(i, j int, v float32) {
+	m[j*3+i] = v
+}
+
+// Mat3 functions
+
+// Mat3Mul performs a matrix multiplication. dst = a * b
+//
+//	dst  [out]  The result matrix, that can be a or b.
+//	a    [in]   The left matrix.
+//	b    [in]   The right matrix.
+func Mat3Mul(dst, a, b Mat3) {
+	var r [9]float32
+	for j := 0; j < 3; j++ {
+		for i := 0; i < 3; i++ {
+			r[j*3+i] = a[i]*b[j*3] + a[3+i]*b[j*3+1] + a[6+i]*b[j*3+2]
+		}
+	}
+	copy(dst, r[:])
+}
+
+// Mat3Transpose transposes a matrix. dst = transpose(m)
+//
+//	dst  [out]  The result matrix, that can be m.
+//	m    [in]   The matrix to transpose.
+func Mat3Transpose(dst, m Mat3) {
+	m01, m02, m12 := m[3], m[6], m[7]
+	dst[0], dst[4], dst[8] = m[0], m[4], m[8]
+	dst[3], dst[6], dst[7] = m[1], m[2], m[5]
+	dst[1], dst[2], dst[5] = m01, m02, m12
+}
+
+// Mat3Inverse inverts a matrix. dst = inverse(m)
+//
+//	dst  [out]  The result matrix, that can be m.
+//	m    [in]   The matrix to invert.
+//
+// It returns false, leaving dst unmodified, if m is not invertible.
+func Mat3Inverse(dst, m Mat3) bool {
+	// cofactors of the first column
+	c00 := m[4]*m[8] - m[7]*m[5]
+	c01 := m[7]*m[2] - m[1]*m[8]
+	c02 := m[1]*m[5] - m[4]*m[2]
+	det := m[0]*c00 + m[3]*c01 + m[6]*c02
+	if det == 0 {
+		return false
+	}
+	inv := 1 / det
+	r := [9]float32{
+		c00 * inv,
+		c01 * inv,
+		c02 * inv,
+		(m[6]*m[5] - m[3]*m[8]) * inv,
+		(m[0]*m[8] - m[6]*m[2]) * inv,
+		(m[3]*m[2] - m[0]*m[5]) * inv,
+		(m[3]*m[7] - m[6]*m[4]) * inv,
+		(m[6]*m[1] - m[0]*m[7]) * inv,
+		(m[0]*m[4] - m[3]*m[1]) * inv,
+	}
+	copy(dst, r[:])
+	return true
+}
+
+// Mat3 methods
+
+// Mul returns a new matrix that is the result of m * m1.
+//
+// It allocates a new matrix/slice.
+func (m Mat3) Mul(m1 Mat3) Mat3 {
+	dst := NewMat3()
+	Mat3Mul(dst, m, m1)
+	return dst
+}
+
+// Transpose returns a new matrix that is the transpose of m.
+//
+// It allocates a new matrix/slice.
+func (m Mat3) Transpose() Mat3 {
+	dst := NewMat3()
+	Mat3Transpose(dst, m)
+	return dst
+}
+
+// Inverse returns a new matrix that is the inverse of m, and false if m is
+// not invertible.
+//
+// It allocates a new matrix/slice.
+func (m Mat3) Inverse() (Mat3, bool) {
+	dst := NewMat3()
+	if !Mat3Inverse(dst, m) {
+		return nil, false
+	}
+	return dst, true
+}
+
+// Det returns the determinant of m.
+func (m Mat3) Det() float32 {
+	return m[0]*(m[4]*m[8]-m[7]*m[5]) -
+		m[3]*(m[1]*m[8]-m[7]*m[2]) +
+		m[6]*(m[1]*m[5]-m[4]*m[2])
+}
+
+// MulVec3 returns a new vector that is the result of m * v.
+//
+// It allocates a new vector/slice.
+func (m Mat3) MulVec3(v Vec3) Vec3 {
+	return NewVec3XYZ(
+		m[0]*v[0]+m[3]*v[1]+m[6]*v[2],
+		m[1]*v[0]+m[4]*v[1]+m[7]*v[2],
+		m[2]*v[0]+m[5]*v[1]+m[8]*v[2],
+	)
+}
+
+// Mat4 returns a new Mat4 having m as upper-left 3x3 matrix, the remaining
+// elements being those of the identity.
+//
+// It allocates a new matrix/slice.
+func (m Mat3) Mat4() Mat4 {
+	return Mat4{
+		m[0], m[1], m[2], 0,
+		m[3], m[4], m[5], 0,
+		m[6], m[7], m[8], 0,
+		0, 0, 0, 1,
+	}
+}
+
+// Approx reports wether m and m1 are approximately equal.
+//
+// Element-wise approximation uses math32.Approx()
+func (m Mat3) Approx(m1 Mat3) bool {
+	for i := range m {
+		if !math32.Approx(m[i], m1[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a string representation of m, row by row, like
+// "[1,0,0;0,1,0;0,0,1]".
+func (m Mat3) String() string {
+	return fmt.Sprintf("[%v,%v,%v;%v,%v,%v;%v,%v,%v]",
+		m[0], m[3], m[6],
+		m[1], m[4], m[7],
+		m[2], m[5], m[8])
+}
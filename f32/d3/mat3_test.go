@@ -0,0 +1,96 @@
+package d3
+
+import (
+	"math"
+	"testing"
+
+	"github.com/arl/math32"
+)
+
+func TestMat3Mul(t *testing.T) {
+	a := Mat3{1, 4, 7, 2, 5, 8, 3, 6, 9} // rows 1 2 3, 4 5 6, 7 8 9
+	b := Mat3{1, 0, 1, 0, 1, 0, 2, 0, 0} // rows 1 0 2, 0 1 0, 1 0 0
+	// rows 4 2 2, 10 5 8, 16 8 14
+	want := Mat3{4, 10, 16, 2, 5, 8, 2, 8, 14}
+
+	if got := a.Mul(b); !got.Approx(want) {
+		t.Errorf("%v * %v = %v, want %v", a, b, got, want)
+	}
+	if got := a.Mul(NewMat3Identity()); !got.Approx(a) {
+		t.Errorf("%v * I = %v, want %v", a, got, a)
+	}
+
+	// c-like api, with aliasing
+	dst := NewMat3From(a)
+	Mat3Mul(dst, dst, b)
+	if !dst.Approx(want) {
+		t.Errorf("%v * %v = %v, want %v", a, b, dst, want)
+	}
+
+	if got := a.At(0, 1); got != 2 {
+		t.Errorf("At(0, 1) = %v, want 2", got)
+	}
+	if got := a.MulVec3(Vec3{1, 0, -1}); !got.Approx(Vec3{-2, -2, -2}) {
+		t.Errorf("%v * (1,0,-1) = %v, want (-2,-2,-2)", a, got)
+	}
+}
+
+func TestMat3TransposeInverse(t *testing.T) {
+	m := Mat3{2, 0, 1, 1, 3, 0, 0, 1, 4}
+	tr := m.Transpose()
+	for i := 0; i < 3; i++ {
+		for j := 0; j < 3; j++ {
+			if tr.At(i, j) != m.At(j, i) {
+				t.Fatalf("%v.Transpose() = %v", m, tr)
+			}
+		}
+	}
+	dst := NewMat3From(m)
+	Mat3Transpose(dst, dst)
+	if !dst.Approx(tr) {
+		t.Errorf("in place transpose of %v = %v, want %v", m, dst, tr)
+	}
+
+	if got := m.Det(); got != 25 {
+		t.Errorf("%v.Det() = %v, want 25", m, got)
+	}
+	inv, ok := m.Inverse()
+	if !ok {
+		t.Fatalf("%v.Inverse() failed", m)
+	}
+	if got := m.Mul(inv); !got.Approx(NewMat3Identity()) {
+		t.Errorf("%v * %v = %v, want identity", m, inv, got)
+	}
+	if _, ok := (Mat3{1, 2, 3, 2, 4, 6, 0, 1, 0}).Inverse(); ok {
+		t.Errorf("Inverse() of a singular matrix succeeded")
+	}
+}
+
+func TestMat3Rotate(t *testing.T) {
+	var tests = []struct {
+		axis  Vec3
+		angle float32
+		v     Vec3
+		want  Vec3
+	}{
+		{Vec3{0, 0, 1}, math.Pi / 2, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{Vec3{0, 0, 2}, math.Pi / 2, Vec3{0, 1, 0}, Vec3{-1, 0, 0}},
+		{Vec3{1, 0, 0}, math.Pi / 2, Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{Vec3{0, 1, 0}, math.Pi / 2, Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{Vec3{1, 1, 1}, 2 * math.Pi / 3, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{Vec3{1, 1, 1}, 1, Vec3{2, 2, 2}, Vec3{2, 2, 2}},
+	}
+	for _, tt := range tests {
+		m := NewMat3Rotate(tt.axis, tt.angle)
+		if got := m.MulVec3(tt.v); !got.Approx(tt.want) {
+			t.Errorf("rotation of %v around %v by %v = %v, want %v", tt.v, tt.axis, tt.angle, got, tt.want)
+		}
+		if d := m.Det(); !math32.Approx(d, 1) {
+			t.Errorf("rotation determinant = %v, want 1", d)
+		}
+	}
+
+	if got := NewMat3Scale(Vec3{1, 2, 3}).MulVec3(Vec3{1, 1, 1}); !got.Approx(Vec3{1, 2, 3}) {
+		t.Errorf("scaling (1,1,1) by (1,2,3) = %v", got)
+	}
+}
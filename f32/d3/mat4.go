@@ -0,0 +1,339 @@
+package d3
+
+import (
+	"fmt"
+
+	"github.com/arl/math32"
+)
+
+// Mat4 is a 4x4 matrix, representing a transformation of the 3D space in
+// homogeneous coordinates. It is made up of a slice of 16 32 bits floating
+// points numbers, stored in column-major order, as OpenGL expects them: the
+// element at row i and column j is m[j*4+i].
+//
+// Multiplying a Mat4 by a Vec3 transforms it as a column vector, so the
+// product of matrices a*b applies b first, then a.
+type Mat4 []float32
+
+// NewMat4 allocates and returns a new Mat4 where each element has its zero
+// value.
+func NewMat4() Mat4 {
+	return make(Mat4, 16)
+}
+
+// NewMat4Identity allocates and returns a new identity Mat4.
+func NewMat4Identity() Mat4 {
+	return Mat4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		0, 0, 0, 1,
+	}
+}
+
+// NewMat4From allocates and returns a new Mat4 that is the copy of m1.
+func NewMat4From(m1 Mat4) Mat4 {
+	m := NewMat4()
+	copy(m, m1)
+	return m
+}
+
+// NewMat4Translate allocates and returns a new Mat4 that translates points by
+// v.
+func NewMat4Translate(v Vec3) Mat4 {
+	return Mat4{
+		1, 0, 0, 0,
+		0, 1, 0, 0,
+		0, 0, 1, 0,
+		v[0], v[1], v[2], 1,
+	}
+}
+
+// NewMat4Scale allocates and returns a new Mat4 that scales points by the
+// components of v along each axis.
+func NewMat4Scale(v Vec3) Mat4 {
+	return NewMat3Scale(v).Mat4()
+}
+
+// NewMat4Rotate allocates and returns a new Mat4 that rotates points by angle
+// radians around axis, going through the origin. See NewMat3Rotate.
+func NewMat4Rotate(axis Vec3, angle float32) Mat4 {
+	return NewMat3Rotate(axis, angle).Mat4()
+}
+
+// NewMat4LookAt allocates and returns a new view matrix, transforming world
+// coordinates into the coordinates of a camera located at eye and looking at
+// center, up being the upward direction. In camera coordinates, the camera
+// looks toward -Z, with Y up, as OpenGL expects it.
+func NewMat4LookAt(eye, center, up Vec3) Mat4 {
+	z := eye.Sub(center)
+	z.Normalize()
+	x := up.Cross(z)
+	x.Normalize()
+	y := z.Cross(x)
+	return Mat4{
+		x[0], y[0], z[0], 0,
+		x[1], y[1], z[1], 0,
+		x[2], y[2], z[2], 0,
+		-x.Dot(eye), -y.Dot(eye), -z.Dot(eye), 1,
+	}
+}
+
+// NewMat4Perspective allocates and returns a new perspective projection
+// matrix, for a vertical field of view of fovy radians, a width to height
+// aspect ratio and the given distances to the near and far clipping planes.
+// The visible points are mapped to the [-1, 1] cube, as OpenGL expects it.
+func NewMat4Perspective(fovy, aspect, near, far float32) Mat4 {
+	s, c := math32.Sincos(fovy / 2)
+	f := c / s
+	nf := 1 / (near - far)
+	return Mat4{
+		f / aspect, 0, 0, 0,
+		0, f, 0, 0,
+		0, 0, (far + near) * nf, -1,
+		0, 0, 2 * far * near * nf, 0,
+	}
+}
+
+// NewMat4Ortho allocates and returns a new orthographic projection matrix,
+// mapping the box delimited by the given clipping planes to the [-1, 1] cube,
+// as OpenGL expects it. near and far are distances along -Z.
+func NewMat4Ortho(left, right, bottom, top, near, far float32) Mat4 {
+	lr := 1 / (left - right)
+	bt := 1 / (bottom - top)
+	nf := 1 / (near - far)
+	return Mat4{
+		-2 * lr, 0, 0, 0,
+		0, -2 * bt, 0, 0,
+		0, 0, 2 * nf, 0,
+		(left + right) * lr, (top + bottom) * bt, (far + near) * nf, 1,
+	}
+}
+
+// At returns the element of m at row i and column j.
+func (m Mat4) At(i, j int) float32 {
+	return m[j*4+i]
+}
+
+// Set sets the element of m at row i and column j to v.
+func (m Mat4) Set(i, j int, v float32) {
+	m[j*4+i] = v
+}
+
+// Mat4 functions
+
+// Mat4Mul performs a matrix multiplication. dst = a * b
+//
+//	dst  [out]  The result matrix, that can be a or b.
+//	a    [in]   The left matrix.
+//	b    [in]   The right matrix.
+func Mat4Mul(dst, a, b Mat4) {
+	var r [16]float32
+	for j := 0; j < 4; j++ {
+		for i := 0; i < 4; i++ {
+			r[j*4+i] = a[i]*b[j*4] + a[4+i]*b[j*4+1] + a[8+i]*b[j*4+2] + a[12+i]*b[j*4+3]
+		}
+	}
+	copy(dst, r[:])
+}
+
+// Mat4Transpose transposes a matrix. dst = transpose(m)
+//
+//	dst  [out]  The result matrix, that can be m.
+//	m    [in]   The matrix to transpose.
+func Mat4Transpose(dst, m Mat4) {
+	var r [16]float32
+	for j := 0; j < 4; j++ {
+		for i := 0; i < 4; i++ {
+			r[i*4+j] = m[j*4+i]
+		}
+	}
+	copy(dst, r[:])
+}
+
+// Mat4Inverse inverts a matrix. dst = inverse(m)
+//
+//	dst  [out]  The result matrix, that can be m.
+//	m    [in]   The matrix to invert.
+//
+// It returns false, leaving dst unmodified, if m is not invertible.
+func Mat4Inverse(dst, m Mat4) bool {
+	a00, a01, a02, a03 := m[0], m[1], m[2], m[3]
+	a10, a11, a12, a13 := m[4], m[5], m[6], m[7]
+	a20, a21, a22, a23 := m[8], m[9], m[10], m[11]
+	a30, a31, a32, a33 := m[12], m[13], m[14], m[15]
+
+	// 2x2 sub-determinants of the first 2 and last 2 columns
+	b00 := a00*a11 - a01*a10
+	b01 := a00*a12 - a02*a10
+	b02 := a00*a13 - a03*a10
+	b03 := a01*a12 - a02*a11
+	b04 := a01*a13 - a03*a11
+	b05 := a02*a13 - a03*a12
+	b06 := a20*a31 - a21*a30
+	b07 := a20*a32 - a22*a30
+	b08 := a20*a33 - a23*a30
+	b09 := a21*a32 - a22*a31
+	b10 := a21*a33 - a23*a31
+	b11 := a22*a33 - a23*a32
+
+	det := b00*b11 - b01*b10 + b02*b09 + b03*b08 - b04*b07 + b05*b06
+	if det == 0 {
+		return false
+	}
+	inv := 1 / det
+	r := [16]float32{
+		(a11*b11 - a12*b10 + a13*b09) * inv,
+		(a02*b10 - a01*b11 - a03*b09) * inv,
+		(a31*b05 - a32*b04 + a33*b03) * inv,
+		(a22*b04 - a21*b05 - a23*b03) * inv,
+		(a12*b08 - a10*b11 - a13*b07) * inv,
+		(a00*b11 - a02*b08 + a03*b07) * inv,
+		(a32*b02 - a30*b05 - a33*b01) * inv,
+		(a20*b05 - a22*b02 + a23*b01) * inv,
+		(a10*b10 - a11*b08 + a13*b06) * inv,
+		(a01*b08 - a00*b10 - a03*b06) * inv,
+		(a30*b04 - a31*b02 + a33*b00) * inv,
+		(a21*b02 - a20*b04 - a23*b00) * inv,
+		(a11*b07 - a10*b09 - a12*b06) * inv,
+		(a00*b09 - a01*b07 + a02*b06) * inv,
+		(a31*b01 - a30*b03 - a32*b00) * inv,
+		(a20*b03 - a21*b01 + a22*b00) * inv,
+	}
+	copy(dst, r[:])
+	return true
+}
+
+// Mat4 methods
+
+// Mul returns a new matrix that is the result of m * m1.
+//
+// It allocates a new matrix/slice.
+func (m Mat4) Mul(m1 Mat4) Mat4 {
+	dst := NewMat4()
+	Mat4Mul(dst, m, m1)
+	return dst
+}
+
+// Transpose returns a new matrix that is the transpose of m.
+//
+// It allocates a new matrix/slice.
+func (m Mat4) Transpose() Mat4 {
+	dst := NewMat4()
+	Mat4Transpose(dst, m)
+	return dst
+}
+
+// Inverse returns a new matrix that is the inverse of m, and false if m is
+// not invertible.
+//
+// It allocates a new matrix/slice.
+func (m Mat4) Inverse() (Mat4, bool) {
+	dst := NewMat4()
+	if !Mat4Inverse(dst, m) {
+		return nil, false
+	}
+	return dst, true
+}
+
+// Det returns the determinant of m.
+func (m Mat4) Det() float32 {
+	b00 := m[0]*m[5] - m[1]*m[4]
+	b01 := m[0]*m[6] - m[2]*m[4]
+	b02 := m[0]*m[7] - m[3]*m[4]
+	b03 := m[1]*m[6] - m[2]*m[5]
+	b04 := m[1]*m[7] - m[3]*m[5]
+	b05 := m[2]*m[7] - m[3]*m[6]
+	b06 := m[8]*m[13] - m[9]*m[12]
+	b07 := m[8]*m[14] - m[10]*m[12]
+	b08 := m[8]*m[15] - m[11]*m[12]
+	b09 := m[9]*m[14] - m[10]*m[13]
+	b10 := m[9]*m[15] - m[11]*m[13]
+	b11 := m[10]*m[15] - m[11]*m[14]
+	return b00*b11 - b01*b10 + b02*b09 + b03*b08 - b04*b07 + b05*b06
+}
+
+// Mat3 returns a new Mat3 that is the upper-left 3x3 matrix of m, which
+// transforms directions.
+//
+// It allocates a new matrix/slice.
+func (m Mat4) Mat3() Mat3 {
+	return Mat3{
+		m[0], m[1], m[2],
+		m[4], m[5], m[6],
+		m[8], m[9], m[10],
+	}
+}
+
+// TransformPoint returns a new vector that is the point p transformed by m,
+// with an homogeneous coordinate of 1. The result is divided by its
+// homogeneous coordinate, as done by projections.
+//
+// It allocates a new vector/slice.
+func (m Mat4) TransformPoint(p Vec3) Vec3 {
+	x := m[0]*p[0] + m[4]*p[1] + m[8]*p[2] + m[12]
+	y := m[1]*p[0] + m[5]*p[1] + m[9]*p[2] + m[13]
+	z := m[2]*p[0] + m[6]*p[1] + m[10]*p[2] + m[14]
+	w := m[3]*p[0] + m[7]*p[1] + m[11]*p[2] + m[15]
+	if w != 1 && w != 0 {
+		x, y, z = x/w, y/w, z/w
+	}
+	return NewVec3XYZ(x, y, z)
+}
+
+// TransformDir returns a new vector that is the direction d transformed by m,
+// with an homogeneous coordinate of 0, so that it's not translated.
+//
+// It allocates a new vector/slice.
+func (m Mat4) TransformDir(d Vec3) Vec3 {
+	return NewVec3XYZ(
+		m[0]*d[0]+m[4]*d[1]+m[8]*d[2],
+		m[1]*d[0]+m[5]*d[1]+m[9]*d[2],
+		m[2]*d[0]+m[6]*d[1]+m[10]*d[2],
+	)
+}
+
+// TransformRect returns the smallest axis-aligned rectangle containing the
+// rectangle r transformed by m.
+func (m Mat4) TransformRect(r Rectangle) Rectangle {
+	res := Rectangle{
+		Min: NewVec3XYZ(math32.Inf(1), math32.Inf(1), math32.Inf(1)),
+		Max: NewVec3XYZ(math32.Inf(-1), math32.Inf(-1), math32.Inf(-1)),
+	}
+	corner := NewVec3()
+	for i := 0; i < 8; i++ {
+		for k := 0; k < 3; k++ {
+			if i&(1<<uint(k)) == 0 {
+				corner[k] = r.Min[k]
+			} else {
+				corner[k] = r.Max[k]
+			}
+		}
+		p := m.TransformPoint(corner)
+		Vec3Min(res.Min, p)
+		Vec3Max(res.Max, p)
+	}
+	return res
+}
+
+// Approx reports wether m and m1 are approximately equal.
+//
+// Element-wise approximation uses math32.Approx()
+func (m Mat4) Approx(m1 Mat4) bool {
+	for i := range m {
+		if !math32.Approx(m[i], m1[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns a string representation of m, row by row, like
+// "[1,0,0,0;0,1,0,0;0,0,1,0;0,0,0,1]".
+func (m Mat4) String() string {
+	return fmt.Sprintf("[%v,%v,%v,%v;%v,%v,%v,%v;%v,%v,%v,%v;%v,%v,%v,%v]",
+		m[0], m[4], m[8], m[12],
+		m[1], m[5], m[9], m[13],
+		m[2], m[6], m[10], m[14],
+		m[3], m[7], m[11], m[15])
+}
@@ -0,0 +1,161 @@
+package d3
+
+import (
+	"math"
+	"testing"
+
+	"github.com/arl/math32"
+)
+
+func vec3ApproxEps(a, b Vec3, eps float32) bool {
+	return math32.ApproxEpsilon(a[0], b[0], eps) &&
+		math32.ApproxEpsilon(a[1], b[1], eps) &&
+		math32.ApproxEpsilon(a[2], b[2], eps)
+}
+
+func TestMat4Mul(t *testing.T) {
+	tr := NewMat4Translate(Vec3{1, 2, 3})
+	sc := NewMat4Scale(Vec3{2, 2, 2})
+
+	// scale, then translate
+	m := tr.Mul(sc)
+	if got := m.TransformPoint(Vec3{1, 1, 1}); !got.Approx(Vec3{3, 4, 5}) {
+		t.Errorf("T*S applied to (1,1,1) = %v, want (3,4,5)", got)
+	}
+	// translate, then scale
+	m = sc.Mul(tr)
+	if got := m.TransformPoint(Vec3{1, 1, 1}); !got.Approx(Vec3{4, 6, 8}) {
+		t.Errorf("S*T applied to (1,1,1) = %v, want (4,6,8)", got)
+	}
+	if got := m.TransformDir(Vec3{1, 1, 1}); !got.Approx(Vec3{2, 2, 2}) {
+		t.Errorf("S*T applied to the direction (1,1,1) = %v, want (2,2,2)", got)
+	}
+
+	// c-like api, with aliasing
+	dst := NewMat4From(sc)
+	Mat4Mul(dst, dst, tr)
+	if !dst.Approx(m) {
+		t.Errorf("S*T = %v, want %v", dst, m)
+	}
+	if got := m.At(0, 3); got != 2 {
+		t.Errorf("At(0, 3) = %v, want 2", got)
+	}
+	if got := m.Mat3(); !got.Approx(NewMat3Scale(Vec3{2, 2, 2})) {
+		t.Errorf("Mat3() = %v, want %v", got, NewMat3Scale(Vec3{2, 2, 2}))
+	}
+}
+
+func TestMat4TransposeInverse(t *testing.T) {
+	m := NewMat4Translate(Vec3{1, -2, 3}).
+		Mul(NewMat4Rotate(Vec3{1, 2, 3}, 0.7)).
+		Mul(NewMat4Scale(Vec3{1, 2, 0.5}))
+	m.Set(3, 0, 0.25) // not affine
+
+	tr := m.Transpose()
+	for i := 0; i < 4; i++ {
+		for j := 0; j < 4; j++ {
+			if tr.At(i, j) != m.At(j, i) {
+				t.Fatalf("%v.Transpose() = %v", m, tr)
+			}
+		}
+	}
+	dst := NewMat4From(m)
+	Mat4Transpose(dst, dst)
+	if !dst.Approx(tr) {
+		t.Errorf("in place transpose of %v = %v, want %v", m, dst, tr)
+	}
+
+	inv, ok := m.Inverse()
+	if !ok {
+		t.Fatalf("%v.Inverse() failed", m)
+	}
+	if got := m.Mul(inv); !got.Approx(NewMat4Identity()) {
+		t.Errorf("%v * %v = %v, want identity", m, inv, got)
+	}
+	if got := inv.Mul(m); !got.Approx(NewMat4Identity()) {
+		t.Errorf("%v * %v = %v, want identity", inv, m, got)
+	}
+	if got, want := m.Det()*inv.Det(), float32(1); !math32.ApproxEpsilon(got, want, 1e-5) {
+		t.Errorf("det(m) * det(inverse(m)) = %v, want 1", got)
+	}
+	if got := NewMat4Scale(Vec3{1, 2, 3}).Det(); got != 6 {
+		t.Errorf("Det() of a scaling = %v, want 6", got)
+	}
+	if _, ok := NewMat4Scale(Vec3{1, 0, 1}).Inverse(); ok {
+		t.Errorf("Inverse() of a singular matrix succeeded")
+	}
+}
+
+func TestMat4LookAt(t *testing.T) {
+	eye, center, up := Vec3{1, 2, 5}, Vec3{1, 2, 0}, Vec3{0, 1, 0}
+	m := NewMat4LookAt(eye, center, up)
+	var tests = []struct {
+		p, want Vec3
+	}{
+		{eye, Vec3{0, 0, 0}},
+		{center, Vec3{0, 0, -5}},
+		{Vec3{2, 2, 0}, Vec3{1, 0, -5}},
+		{Vec3{1, 3, 5}, Vec3{0, 1, 0}},
+	}
+	for _, tt := range tests {
+		if got := m.TransformPoint(tt.p); !vec3ApproxEps(got, tt.want, 1e-5) {
+			t.Errorf("LookAt transforms %v to %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	// looking along +X
+	m = NewMat4LookAt(Vec3{0, 0, 0}, Vec3{10, 0, 0}, Vec3{0, 1, 0})
+	if got := m.TransformPoint(Vec3{3, 0, 1}); !vec3ApproxEps(got, Vec3{1, 0, -3}, 1e-5) {
+		t.Errorf("LookAt transforms (3,0,1) to %v, want (1,0,-3)", got)
+	}
+}
+
+func TestMat4Projections(t *testing.T) {
+	p := NewMat4Perspective(math.Pi/2, 2, 1, 10)
+	var tests = []struct {
+		p, want Vec3
+	}{
+		{Vec3{0, 0, -1}, Vec3{0, 0, -1}},
+		{Vec3{0, 0, -10}, Vec3{0, 0, 1}},
+		{Vec3{2, 1, -1}, Vec3{1, 1, -1}},
+		{Vec3{-20, -10, -10}, Vec3{-1, -1, 1}},
+	}
+	for _, tt := range tests {
+		if got := p.TransformPoint(tt.p); !vec3ApproxEps(got, tt.want, 1e-5) {
+			t.Errorf("perspective projection of %v = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+
+	o := NewMat4Ortho(-2, 2, -1, 1, 1, 5)
+	tests = []struct {
+		p, want Vec3
+	}{
+		{Vec3{-2, -1, -1}, Vec3{-1, -1, -1}},
+		{Vec3{2, 1, -5}, Vec3{1, 1, 1}},
+		{Vec3{0, 0, -3}, Vec3{0, 0, 0}},
+	}
+	for _, tt := range tests {
+		if got := o.TransformPoint(tt.p); !vec3ApproxEps(got, tt.want, 1e-5) {
+			t.Errorf("orthographic projection of %v = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestMat4TransformRect(t *testing.T) {
+	r := Rect(0, 0, 0, 2, 1, 1)
+	var tests = []struct {
+		m    Mat4
+		want Rectangle
+	}{
+		{NewMat4Identity(), r},
+		{NewMat4Translate(Vec3{1, 1, 1}), Rect(1, 1, 1, 3, 2, 2)},
+		{NewMat4Rotate(Vec3{0, 0, 1}, math.Pi/2), Rect(-1, 0, 0, 0, 2, 1)},
+		{NewMat4Scale(Vec3{-1, 2, 3}), Rect(-2, 0, 0, 0, 2, 3)},
+	}
+	for _, tt := range tests {
+		got := tt.m.TransformRect(r)
+		if !vec3ApproxEps(got.Min, tt.want.Min, 1e-5) || !vec3ApproxEps(got.Max, tt.want.Max, 1e-5) {
+			t.Errorf("%v.TransformRect(%v) = %v, want %v", tt.m, r, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,294 @@
+package d3
+
+import (
+	"math"
+
+	"github.com/arl/gogeo/f32/d2"
+	"github.com/arl/math32"
+)
+
+// A TriangleMesh is an indexed triangle mesh: triangles are made of 3
+// consecutive indices into the vertex attributes, so vertices can be shared
+// between triangles.
+//
+// Every vertex has a position, and optionally a normal and texture coordinates
+// (UVs). Normals and UVs, when present, have the same length as Positions.
+type TriangleMesh struct {
+	Positions []Vec3
+	Normals   []Vec3
+	UVs       []d2.Vec2
+
+	// Indices holds 3 vertex indices per triangle. The front face of a
+	// triangle is the one from where its vertices appear in counter-clockwise
+	// order.
+	Indices []uint32
+}
+
+// NewTriangleMesh returns a new mesh made of the given vertex positions and
+// triangle indices, without normals nor UVs.
+func NewTriangleMesh(positions []Vec3, indices []uint32) *TriangleMesh {
+	return &TriangleMesh{Positions: positions, Indices: indices}
+}
+
+// NumTriangles returns the number of triangles of m.
+func (m *TriangleMesh) NumTriangles() int {
+	return len(m.Indices) / 3
+}
+
+// Triangle returns the positions of the vertices of the i-th triangle of m.
+func (m *TriangleMesh) Triangle(i int) (a, b, c Vec3) {
+	return m.Positions[m.Indices[3*i]],
+		m.Positions[m.Indices[3*i+1]],
+		m.Positions[m.Indices[3*i+2]]
+}
+
+// Rectangle returns the smallest rectangle containing all the vertices of m.
+// It implements the Rectangler interface.
+func (m *TriangleMesh) Rectangle() Rectangle {
+	if len(m.Positions) == 0 {
+		return NewRect()
+	}
+	r := Rectangle{NewVec3From(m.Positions[0]), NewVec3From(m.Positions[0])}
+	for _, p := range m.Positions[1:] {
+		Vec3Min(r.Min, p)
+		Vec3Max(r.Max, p)
+	}
+	return r
+}
+
+// faceCross sets dst to the cross product of the edges of the i-th triangle,
+// whose length is twice the triangle area.
+func (m *TriangleMesh) faceCross(dst Vec3, i int, e1, e2 Vec3) {
+	a, b, c := m.Triangle(i)
+	Vec3Sub(e1, b, a)
+	Vec3Sub(e2, c, a)
+	Vec3Cross(dst, e1, e2)
+}
+
+// FaceNormal returns the unit normal of the front face of the i-th triangle
+// of m, or the null vector if the triangle is degenerate.
+//
+// It allocates a new vector/slice.
+func (m *TriangleMesh) FaceNormal(i int) Vec3 {
+	n := NewVec3()
+	m.faceCross(n, i, NewVec3(), NewVec3())
+	if n.LenSqr() > 0 {
+		n.Normalize()
+	}
+	return n
+}
+
+// FaceNormals returns the unit normals of all the triangles of m.
+func (m *TriangleMesh) FaceNormals() []Vec3 {
+	ns := make([]Vec3, m.NumTriangles())
+	for i := range ns {
+		ns[i] = m.FaceNormal(i)
+	}
+	return ns
+}
+
+// ComputeNormals computes smooth vertex normals and stores them into Normals,
+// replacing the existing ones. The normal of a vertex is the average of the
+// normals of the triangles sharing it, weighted by their area.
+func (m *TriangleMesh) ComputeNormals() {
+	ns := make([]Vec3, len(m.Positions))
+	for i := range ns {
+		ns[i] = NewVec3()
+	}
+	n, e1, e2 := NewVec3(), NewVec3(), NewVec3()
+	for i := 0; i < m.NumTriangles(); i++ {
+		m.faceCross(n, i, e1, e2)
+		for _, idx := range m.Indices[3*i : 3*i+3] {
+			Vec3Add(ns[idx], ns[idx], n)
+		}
+	}
+	for _, n := range ns {
+		if n.LenSqr() > 0 {
+			n.Normalize()
+		}
+	}
+	m.Normals = ns
+}
+
+// Area returns the total area of the triangles of m.
+func (m *TriangleMesh) Area() float32 {
+	var area float32
+	n, e1, e2 := NewVec3(), NewVec3(), NewVec3()
+	for i := 0; i < m.NumTriangles(); i++ {
+		m.faceCross(n, i, e1, e2)
+		area += n.Len() / 2
+	}
+	return area
+}
+
+// volume returns the signed volume enclosed by m and its centroid, computed
+// as the sum of the tetrahedra formed by each triangle and a reference point.
+func (m *TriangleMesh) volume() (vol float32, centroid Vec3) {
+	centroid = NewVec3()
+	if len(m.Positions) == 0 {
+		return 0, centroid
+	}
+	// the first vertex as reference point limits cancellation errors
+	o := m.Positions[0]
+	a, b, c, bc := NewVec3(), NewVec3(), NewVec3(), NewVec3()
+	for i := 0; i < m.NumTriangles(); i++ {
+		pa, pb, pc := m.Triangle(i)
+		Vec3Sub(a, pa, o)
+		Vec3Sub(b, pb, o)
+		Vec3Sub(c, pc, o)
+		Vec3Cross(bc, b, c)
+		v := a.Dot(bc) / 6
+		vol += v
+		for j := 0; j < 3; j++ {
+			centroid[j] += v * (a[j] + b[j] + c[j]) / 4
+		}
+	}
+	if vol != 0 {
+		Vec3Scale(centroid, centroid, 1/vol)
+	}
+	Vec3Add(centroid, centroid, o)
+	return vol, centroid
+}
+
+// Volume returns the volume enclosed by m.
+//
+// m must be closed, or watertight, and its triangles consistently oriented.
+// The volume is positive if their front faces point outward, negative
+// otherwise.
+func (m *TriangleMesh) Volume() float32 {
+	vol, _ := m.volume()
+	return vol
+}
+
+// Centroid returns the center of mass of the solid enclosed by m, that must be
+// closed and consistently oriented.
+//
+// If m encloses no volume, the centroid of its surface is returned instead.
+//
+// It allocates a new vector/slice.
+func (m *TriangleMesh) Centroid() Vec3 {
+	// centroid of the triangles, weighted by their area
+	var area float32
+	centroid := NewVec3()
+	n, e1, e2 := NewVec3(), NewVec3(), NewVec3()
+	for i := 0; i < m.NumTriangles(); i++ {
+		m.faceCross(n, i, e1, e2)
+		ta := n.Len() / 2
+		a, b, c := m.Triangle(i)
+		for j := 0; j < 3; j++ {
+			centroid[j] += ta * (a[j] + b[j] + c[j]) / 3
+		}
+		area += ta
+	}
+
+	// a negligible volume is that of a flat mesh, up to rounding errors
+	if vol, vc := m.volume(); math32.Abs(vol) > 1e-5*area*math32.Sqrt(area) {
+		return vc
+	}
+	if area > 0 {
+		Vec3Scale(centroid, centroid, 1/area)
+	}
+	return centroid
+}
+
+// Weld merges the vertices of m whose positions are closer than tol, along
+// every axis, and remaps the indices accordingly. With a null tolerance only
+// the vertices having exactly the same positions are merged.
+//
+// Vertices having different normals or UVs are not merged, which preserves
+// hard edges and texture seams. The attributes of a merged vertex are those of
+// its first occurrence. Triangles that become degenerate, referencing the same
+// vertex more than once, are removed.
+//
+// Weld returns the number of vertices that have been removed.
+func (m *TriangleMesh) Weld(tol float32) int {
+	type cell [3]int32
+	span := int32(1)
+	key := func(p Vec3) cell {
+		return cell{int32(math32.Floor(p[0] / tol)), int32(math32.Floor(p[1] / tol)), int32(math32.Floor(p[2] / tol))}
+	}
+	if tol <= 0 {
+		tol, span = 0, 0
+		key = func(p Vec3) cell {
+			// adding 0 turns -0 into +0, that have different bits
+			return cell{int32(math.Float32bits(p[0] + 0)), int32(math.Float32bits(p[1] + 0)), int32(math.Float32bits(p[2] + 0))}
+		}
+	}
+
+	same := func(i, j int) bool {
+		for k := 0; k < 3; k++ {
+			if math32.Abs(m.Positions[i][k]-m.Positions[j][k]) > tol {
+				return false
+			}
+		}
+		if m.Normals != nil && !vec3Equal(m.Normals[i], m.Normals[j]) {
+			return false
+		}
+		return m.UVs == nil || m.UVs[i][0] == m.UVs[j][0] && m.UVs[i][1] == m.UVs[j][1]
+	}
+
+	// kept vertices, by cell
+	grid := make(map[cell][]int)
+	remap := make([]uint32, len(m.Positions))
+	var kept []int
+	for i, p := range m.Positions {
+		k := key(p)
+		found := -1
+	search:
+		for dx := -span; dx <= span; dx++ {
+			for dy := -span; dy <= span; dy++ {
+				for dz := -span; dz <= span; dz++ {
+					for _, j := range grid[cell{k[0] + dx, k[1] + dy, k[2] + dz}] {
+						if same(i, j) {
+							found = j
+							break search
+						}
+					}
+				}
+			}
+		}
+		if found >= 0 {
+			remap[i] = remap[found]
+			continue
+		}
+		remap[i] = uint32(len(kept))
+		kept = append(kept, i)
+		grid[k] = append(grid[k], i)
+	}
+
+	removed := len(m.Positions) - len(kept)
+	pos := make([]Vec3, len(kept))
+	for i, j := range kept {
+		pos[i] = m.Positions[j]
+	}
+	m.Positions = pos
+	if m.Normals != nil {
+		ns := make([]Vec3, len(kept))
+		for i, j := range kept {
+			ns[i] = m.Normals[j]
+		}
+		m.Normals = ns
+	}
+	if m.UVs != nil {
+		uvs := make([]d2.Vec2, len(kept))
+		for i, j := range kept {
+			uvs[i] = m.UVs[j]
+		}
+		m.UVs = uvs
+	}
+
+	idx := m.Indices[:0]
+	for i := 0; i+2 < len(m.Indices); i += 3 {
+		a, b, c := remap[m.Indices[i]], remap[m.Indices[i+1]], remap[m.Indices[i+2]]
+		if a == b || b == c || c == a {
+			continue
+		}
+		idx = append(idx, a, b, c)
+	}
+	m.Indices = idx
+	return removed
+}
+
+func vec3Equal(a, b Vec3) bool {
+	return a[0] == b[0] && a[1] == b[1] && a[2] == b[2]
+}
@@ -0,0 +1,192 @@
+package d3
+
+import (
+	"math"
+	"testing"
+
+	"github.com/arl/gogeo/f32/d2"
+	"github.com/arl/math32"
+)
+
+// cube returns the mesh of the cube of given origin and size, its triangles
+// facing outward.
+func cube(o Vec3, size float32) *TriangleMesh {
+	pos := make([]Vec3, 8)
+	for i := range pos {
+		pos[i] = Vec3{
+			o[0] + float32(i&1)*size,
+			o[1] + float32(i>>1&1)*size,
+			o[2] + float32(i>>2&1)*size,
+		}
+	}
+	return NewTriangleMesh(pos, []uint32{
+		0, 2, 3, 0, 3, 1, // -Z
+		4, 5, 7, 4, 7, 6, // +Z
+		0, 1, 5, 0, 5, 4, // -Y
+		2, 6, 7, 2, 7, 3, // +Y
+		0, 4, 6, 0, 6, 2, // -X
+		1, 3, 7, 1, 7, 5, // +X
+	})
+}
+
+// soup returns a copy of m where no vertex is shared between triangles.
+func soup(m *TriangleMesh) *TriangleMesh {
+	s := &TriangleMesh{}
+	for i, idx := range m.Indices {
+		s.Positions = append(s.Positions, NewVec3From(m.Positions[idx]))
+		s.Indices = append(s.Indices, uint32(i))
+	}
+	return s
+}
+
+func TestTriangleMeshMeasures(t *testing.T) {
+	m := cube(Vec3{1, 2, 3}, 2)
+
+	var _ Rectangler = m
+	if r := m.Rectangle(); !r.Min.Approx(Vec3{1, 2, 3}) || !r.Max.Approx(Vec3{3, 4, 5}) {
+		t.Errorf("Rectangle() = %v, want (1,2,3)-(3,4,5)", r)
+	}
+	if got := m.NumTriangles(); got != 12 {
+		t.Errorf("NumTriangles() = %v, want 12", got)
+	}
+	if got := m.Area(); !math32.Approx(got, 24) {
+		t.Errorf("Area() = %v, want 24", got)
+	}
+	if got := m.Volume(); !math32.Approx(got, 8) {
+		t.Errorf("Volume() = %v, want 8", got)
+	}
+	if got := m.Centroid(); !vec3ApproxEps(got, Vec3{2, 3, 4}, 1e-5) {
+		t.Errorf("Centroid() = %v, want (2,3,4)", got)
+	}
+
+	// inward facing triangles
+	inv := &TriangleMesh{Positions: m.Positions}
+	for i := 0; i < len(m.Indices); i += 3 {
+		inv.Indices = append(inv.Indices, m.Indices[i], m.Indices[i+2], m.Indices[i+1])
+	}
+	if got := inv.Volume(); !math32.Approx(got, -8) {
+		t.Errorf("Volume() of the inverted cube = %v, want -8", got)
+	}
+	if got := inv.Centroid(); !vec3ApproxEps(got, Vec3{2, 3, 4}, 1e-5) {
+		t.Errorf("Centroid() of the inverted cube = %v, want (2,3,4)", got)
+	}
+
+	// open mesh, made of the -Z face only
+	flat := NewTriangleMesh(m.Positions, m.Indices[:6])
+	if got := flat.Area(); !math32.Approx(got, 4) {
+		t.Errorf("Area() of a flat mesh = %v, want 4", got)
+	}
+	if got := flat.Centroid(); !vec3ApproxEps(got, Vec3{2, 3, 3}, 1e-5) {
+		t.Errorf("Centroid() of a flat mesh = %v, want (2,3,3)", got)
+	}
+
+	var empty TriangleMesh
+	if empty.Area() != 0 || empty.Volume() != 0 || !empty.Rectangle().Min.Approx(Vec3{0, 0, 0}) {
+		t.Errorf("empty mesh has non null measures")
+	}
+}
+
+func TestTriangleMeshNormals(t *testing.T) {
+	m := cube(Vec3{-1, -1, -1}, 2)
+	want := []Vec3{
+		{0, 0, -1}, {0, 0, -1}, {0, 0, 1}, {0, 0, 1},
+		{0, -1, 0}, {0, -1, 0}, {0, 1, 0}, {0, 1, 0},
+		{-1, 0, 0}, {-1, 0, 0}, {1, 0, 0}, {1, 0, 0},
+	}
+	for i, n := range m.FaceNormals() {
+		if !n.Approx(want[i]) {
+			t.Errorf("FaceNormal(%d) = %v, want %v", i, n, want[i])
+		}
+	}
+
+	m.ComputeNormals()
+	if len(m.Normals) != len(m.Positions) {
+		t.Fatalf("ComputeNormals() computed %d normals, want %d", len(m.Normals), len(m.Positions))
+	}
+	for i, n := range m.Normals {
+		// the cube is centered on the origin, normals point outward
+		if !math32.Approx(n.Len(), 1) || n.Dot(m.Positions[i]) <= 0 {
+			t.Errorf("normal of vertex %v = %v", m.Positions[i], n)
+		}
+		for k := 0; k < 3; k++ {
+			if n[k]*m.Positions[i][k] < 0 {
+				t.Errorf("normal of vertex %v = %v", m.Positions[i], n)
+			}
+		}
+	}
+
+	// flat mesh
+	flat := NewTriangleMesh([]Vec3{{0, 0, 0}, {1, 0, 0}, {1, 1, 0}, {0, 1, 0}, {2, 3, 0}}, []uint32{0, 1, 2, 0, 2, 3})
+	flat.ComputeNormals()
+	for i, n := range flat.Normals[:4] {
+		if !n.Approx(Vec3{0, 0, 1}) {
+			t.Errorf("normal of vertex %d = %v, want (0,0,1)", i, n)
+		}
+	}
+	// unused vertex
+	if !flat.Normals[4].Approx(Vec3{0, 0, 0}) {
+		t.Errorf("normal of an unused vertex = %v, want (0,0,0)", flat.Normals[4])
+	}
+	// degenerate triangle
+	if n := NewTriangleMesh([]Vec3{{0, 0, 0}, {1, 1, 1}, {2, 2, 2}}, []uint32{0, 1, 2}).FaceNormal(0); !n.Approx(Vec3{0, 0, 0}) {
+		t.Errorf("FaceNormal() of a degenerate triangle = %v, want (0,0,0)", n)
+	}
+}
+
+func TestTriangleMeshWeld(t *testing.T) {
+	m := soup(cube(Vec3{1, 2, 3}, 2))
+	if got := m.Weld(0); got != 28 {
+		t.Errorf("Weld(0) removed %d vertices, want 28", got)
+	}
+	if len(m.Positions) != 8 || m.NumTriangles() != 12 {
+		t.Errorf("after Weld(0), %d vertices and %d triangles, want 8 and 12", len(m.Positions), m.NumTriangles())
+	}
+	if got := m.Volume(); !math32.Approx(got, 8) {
+		t.Errorf("Volume() after Weld(0) = %v, want 8", got)
+	}
+
+	// signed zeros
+	negz := float32(math.Copysign(0, -1))
+	m = NewTriangleMesh([]Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {negz, 0, negz}, {0, negz, 1}}, []uint32{0, 1, 2, 3, 2, 4})
+	if got := m.Weld(0); got != 1 {
+		t.Errorf("Weld(0) with signed zeros removed %d vertices, want 1", got)
+	}
+
+	// noisy positions
+	m = soup(cube(Vec3{1, 2, 3}, 2))
+	for i, p := range m.Positions {
+		d := 1e-5 * float32(i-18)
+		Vec3Add(p, p, Vec3{d, -d, d})
+	}
+	if got := NewTriangleMesh(m.Positions, append([]uint32(nil), m.Indices...)).Weld(0); got != 0 {
+		t.Errorf("Weld(0) of noisy positions removed %d vertices, want 0", got)
+	}
+	if got := m.Weld(1e-3); got != 28 {
+		t.Errorf("Weld(1e-3) removed %d vertices, want 28", got)
+	}
+	if got := m.Volume(); !math32.ApproxEpsilon(got, 8, 1e-2) {
+		t.Errorf("Volume() after Weld(1e-3) = %v, want 8", got)
+	}
+
+	// different attributes, degenerate triangles
+	m = &TriangleMesh{
+		Positions: []Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {1, 0, 0}, {0, 1, 0}, {1, 1, 0}, {1, 1, 0.01}},
+		UVs:       []d2.Vec2{{0, 0}, {1, 0}, {0, 1}, {1, 0}, {0.5, 1}, {1, 1}, {1, 1}},
+		Indices:   []uint32{0, 1, 2, 3, 5, 4, 5, 6, 4},
+	}
+	if got := m.Weld(0.1); got != 2 {
+		t.Errorf("Weld(0.1) removed %d vertices, want 2", got)
+	}
+	want := []uint32{0, 1, 2, 1, 4, 3}
+	if len(m.Indices) != len(want) {
+		t.Fatalf("indices after Weld(0.1) = %v, want %v", m.Indices, want)
+	}
+	for i := range want {
+		if m.Indices[i] != want[i] {
+			t.Fatalf("indices after Weld(0.1) = %v, want %v", m.Indices, want)
+		}
+	}
+	if len(m.UVs) != 5 || !m.UVs[3].Approx(d2.Vec2{0.5, 1}) {
+		t.Errorf("UVs after Weld(0.1) = %v", m.UVs)
+	}
+}
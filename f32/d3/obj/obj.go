@@ -0,0 +1,330 @@
+// Package obj reads and writes 3D models in the Wavefront OBJ format.
+//
+// Only the geometry is supported: vertex positions, texture coordinates,
+// normals and polygonal faces, organized into objects and groups. Other
+// statements, like materials or free-form geometry, are ignored.
+package obj
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/arl/gogeo/f32/d2"
+	"github.com/arl/gogeo/f32/d3"
+)
+
+// A Model is the geometry described by an OBJ file.
+type Model struct {
+	// Mesh holds the triangles of all the faces of the model. There's a mesh
+	// vertex for each distinct combination of position, texture coordinates
+	// and normal referenced by the faces. Mesh.UVs, respectively Mesh.Normals,
+	// is nil if no face references texture coordinates, respectively normals.
+	Mesh *d3.TriangleMesh
+
+	// Groups describes the triangles of Mesh belonging to each group.
+	Groups []Group
+}
+
+// A Group is a range of consecutive triangles sharing the same object and
+// group names.
+type Group struct {
+	// Object is the name given by the last 'o' statement, if any.
+	Object string
+
+	// Name is the name given by the last 'g' statement, if any.
+	Name string
+
+	// First is the index of the first triangle of the group and Count is the
+	// number of triangles it contains.
+	First, Count int
+}
+
+// A SyntaxError describes a malformed line of an OBJ file.
+type SyntaxError struct {
+	Line int    // line number, starting at 1
+	Msg  string // description of the error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("obj: line %d: %s", e.Line, e.Msg)
+}
+
+// vertex is a face vertex, made of 0-based indices into the positions,
+// texture coordinates and normals. -1 indicates a missing index.
+type vertex struct {
+	v, vt, vn int
+}
+
+type reader struct {
+	line int
+
+	positions []d3.Vec3
+	uvs       []d2.Vec2
+	normals   []d3.Vec3
+
+	// mesh vertex of each distinct face vertex
+	vertices map[vertex]uint32
+	order    []vertex
+	indices  []uint32
+
+	groups []Group
+	object string
+	group  string
+}
+
+// Read reads an OBJ file from r and returns the model it describes.
+//
+// Faces having more than 3 vertices are triangulated as triangle fans, which
+// is correct for convex polygons. Negative indices, referring to the end of
+// the lists of positions, texture coordinates and normals defined so far, are
+// supported.
+//
+// If a line is malformed, the returned error is a *SyntaxError.
+func Read(r io.Reader) (*Model, error) {
+	rd := &reader{vertices: make(map[vertex]uint32)}
+	sc := bufio.NewScanner(r)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		rd.line++
+		if err := rd.parseLine(sc.Text()); err != nil {
+			return nil, err
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, err
+	}
+	return rd.model(), nil
+}
+
+func (rd *reader) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Line: rd.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+func (rd *reader) parseLine(line string) error {
+	if i := strings.IndexByte(line, '#'); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+	args := fields[1:]
+	switch fields[0] {
+	case "v":
+		f, err := rd.floats(args, 3, 4)
+		if err != nil {
+			return err
+		}
+		rd.positions = append(rd.positions, d3.Vec3{f[0], f[1], f[2]})
+	case "vt":
+		f, err := rd.floats(args, 1, 3)
+		if err != nil {
+			return err
+		}
+		uv := d2.Vec2{f[0], 0}
+		if len(f) > 1 {
+			uv[1] = f[1]
+		}
+		rd.uvs = append(rd.uvs, uv)
+	case "vn":
+		f, err := rd.floats(args, 3, 3)
+		if err != nil {
+			return err
+		}
+		rd.normals = append(rd.normals, d3.Vec3{f[0], f[1], f[2]})
+	case "f":
+		return rd.parseFace(args)
+	case "o":
+		rd.object = strings.Join(args, " ")
+		rd.group = ""
+	case "g":
+		rd.group = strings.Join(args, " ")
+	}
+	return nil
+}
+
+// floats parses between min and max floating point numbers.
+func (rd *reader) floats(args []string, min, max int) ([]float32, error) {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return nil, rd.errorf("got %d numbers, want %d", len(args), min)
+		}
+		return nil, rd.errorf("got %d numbers, want %d to %d", len(args), min, max)
+	}
+	f := make([]float32, len(args))
+	for i, s := range args {
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, rd.errorf("invalid number %q", s)
+		}
+		f[i] = float32(v)
+	}
+	return f, nil
+}
+
+func (rd *reader) parseFace(args []string) error {
+	if len(args) < 3 {
+		return rd.errorf("face with %d vertices, want at least 3", len(args))
+	}
+	idx := make([]uint32, len(args))
+	for i, s := range args {
+		vtx, err := rd.parseVertex(s)
+		if err != nil {
+			return err
+		}
+		j, ok := rd.vertices[vtx]
+		if !ok {
+			j = uint32(len(rd.order))
+			rd.vertices[vtx] = j
+			rd.order = append(rd.order, vtx)
+		}
+		idx[i] = j
+	}
+
+	// append the triangles to the current group, or start a new one
+	n := len(rd.indices) / 3
+	if g := len(rd.groups) - 1; g < 0 || rd.groups[g].Object != rd.object || rd.groups[g].Name != rd.group {
+		rd.groups = append(rd.groups, Group{Object: rd.object, Name: rd.group, First: n})
+	}
+	for i := 1; i+1 < len(idx); i++ {
+		rd.indices = append(rd.indices, idx[0], idx[i], idx[i+1])
+	}
+	rd.groups[len(rd.groups)-1].Count += len(idx) - 2
+	return nil
+}
+
+// parseVertex parses a face vertex like "v", "v/vt", "v//vn" or "v/vt/vn".
+func (rd *reader) parseVertex(s string) (vertex, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) > 3 || parts[0] == "" {
+		return vertex{}, rd.errorf("invalid face vertex %q", s)
+	}
+	vtx := vertex{-1, -1, -1}
+	var err error
+	if vtx.v, err = rd.index(parts[0], len(rd.positions), "position"); err != nil {
+		return vertex{}, err
+	}
+	if len(parts) > 1 && parts[1] != "" {
+		if vtx.vt, err = rd.index(parts[1], len(rd.uvs), "texture coordinates"); err != nil {
+			return vertex{}, err
+		}
+	}
+	if len(parts) > 2 && parts[2] != "" {
+		if vtx.vn, err = rd.index(parts[2], len(rd.normals), "normal"); err != nil {
+			return vertex{}, err
+		}
+	}
+	return vtx, nil
+}
+
+// index converts the 1-based, or negative, index s into a 0-based index in a
+// list of n elements.
+func (rd *reader) index(s string, n int, what string) (int, error) {
+	i, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, rd.errorf("invalid %s index %q", what, s)
+	}
+	switch {
+	case i > 0 && i <= n:
+		return i - 1, nil
+	case i < 0 && -i <= n:
+		return n + i, nil
+	}
+	return 0, rd.errorf("%s index %d out of range [1, %d]", what, i, n)
+}
+
+func (rd *reader) model() *Model {
+	m := &d3.TriangleMesh{
+		Positions: make([]d3.Vec3, len(rd.order)),
+		Indices:   rd.indices,
+	}
+	for _, vtx := range rd.order {
+		if vtx.vt >= 0 && m.UVs == nil {
+			m.UVs = make([]d2.Vec2, len(rd.order))
+		}
+		if vtx.vn >= 0 && m.Normals == nil {
+			m.Normals = make([]d3.Vec3, len(rd.order))
+		}
+	}
+	for i, vtx := range rd.order {
+		m.Positions[i] = d3.NewVec3From(rd.positions[vtx.v])
+		if m.UVs != nil {
+			m.UVs[i] = d2.NewVec2()
+			if vtx.vt >= 0 {
+				m.UVs[i] = d2.NewVec2From(rd.uvs[vtx.vt])
+			}
+		}
+		if m.Normals != nil {
+			m.Normals[i] = d3.NewVec3()
+			if vtx.vn >= 0 {
+				m.Normals[i] = d3.NewVec3From(rd.normals[vtx.vn])
+			}
+		}
+	}
+	return &Model{Mesh: m, Groups: rd.groups}
+}
+
+// Write writes m to w in the OBJ format.
+//
+// A vertex position, and if present texture coordinates and normal, is written
+// for each vertex of m.Mesh, followed by the triangles of each group. If
+// m.Groups is empty, all the triangles are written without group.
+func Write(w io.Writer, m *Model) error {
+	bw := bufio.NewWriter(w)
+	mesh := m.Mesh
+	for _, p := range mesh.Positions {
+		fmt.Fprintf(bw, "v %s %s %s\n", ftoa(p[0]), ftoa(p[1]), ftoa(p[2]))
+	}
+	for _, uv := range mesh.UVs {
+		fmt.Fprintf(bw, "vt %s %s\n", ftoa(uv[0]), ftoa(uv[1]))
+	}
+	for _, n := range mesh.Normals {
+		fmt.Fprintf(bw, "vn %s %s %s\n", ftoa(n[0]), ftoa(n[1]), ftoa(n[2]))
+	}
+
+	groups := m.Groups
+	if len(groups) == 0 {
+		groups = []Group{{First: 0, Count: mesh.NumTriangles()}}
+	}
+	var object, group string
+	for _, g := range groups {
+		if g.Object != object {
+			fmt.Fprintf(bw, "o %s\n", g.Object)
+			object, group = g.Object, ""
+		}
+		if g.Name != group {
+			fmt.Fprintf(bw, "g %s\n", g.Name)
+			group = g.Name
+		}
+		for t := g.First; t < g.First+g.Count; t++ {
+			bw.WriteString("f")
+			for _, i := range mesh.Indices[3*t : 3*t+3] {
+				bw.WriteByte(' ')
+				bw.WriteString(faceVertex(int(i)+1, mesh.UVs != nil, mesh.Normals != nil))
+			}
+			bw.WriteByte('\n')
+		}
+	}
+	return bw.Flush()
+}
+
+// faceVertex formats the face vertex of 1-based index i.
+func faceVertex(i int, uv, normal bool) string {
+	s := strconv.Itoa(i)
+	switch {
+	case uv && normal:
+		return s + "/" + s + "/" + s
+	case uv:
+		return s + "/" + s
+	case normal:
+		return s + "//" + s
+	}
+	return s
+}
+
+func ftoa(f float32) string {
+	return strconv.FormatFloat(float64(f), 'g', -1, 32)
+}
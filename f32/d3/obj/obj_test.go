@@ -0,0 +1,176 @@
+package obj
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/arl/gogeo/f32/d2"
+	"github.com/arl/gogeo/f32/d3"
+)
+
+const quads = `# two unit squares
+mtllib squares.mtl
+v 0 0 0
+v 1 0 0
+v 1 1 0
+v 0 1 0 1.0
+vt 0 0
+vt 1 0
+vt 1 1
+vt 0 1
+vn 0 0 1
+
+o first
+usemtl red
+f 1/1/1 2/2/1 3/3/1 4/4/1
+v 0 0 1
+v 1 0 1
+v 1 1 1
+v 0 1 1
+o second
+g top
+s off
+f -4/1 -3/2 -2/3 -1/4   # negative indices
+g bottom
+f 1 3 2
+`
+
+func TestRead(t *testing.T) {
+	m, err := Read(strings.NewReader(quads))
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	mesh := m.Mesh
+	if got := mesh.NumTriangles(); got != 5 {
+		t.Fatalf("Read() returned %d triangles, want 5", got)
+	}
+	// 4 vertices with a normal, 4 without, 3 without uv nor normal
+	if len(mesh.Positions) != 11 || len(mesh.UVs) != 11 || len(mesh.Normals) != 11 {
+		t.Fatalf("Read() returned %d positions, %d uvs, %d normals, want 11",
+			len(mesh.Positions), len(mesh.UVs), len(mesh.Normals))
+	}
+
+	wantGroups := []Group{
+		{Object: "first", First: 0, Count: 2},
+		{Object: "second", Name: "top", First: 2, Count: 2},
+		{Object: "second", Name: "bottom", First: 4, Count: 1},
+	}
+	if len(m.Groups) != len(wantGroups) {
+		t.Fatalf("Read() returned groups %+v, want %+v", m.Groups, wantGroups)
+	}
+	for i, g := range m.Groups {
+		if g != wantGroups[i] {
+			t.Errorf("group %d = %+v, want %+v", i, g, wantGroups[i])
+		}
+	}
+
+	// second square, made of the last 4 positions
+	for i, want := range []d3.Vec3{{0, 0, 1}, {1, 0, 1}, {1, 1, 1}} {
+		a, b, c := mesh.Triangle(2)
+		if got := []d3.Vec3{a, b, c}[i]; !got.Approx(want) {
+			t.Errorf("vertex %d of triangle 2 = %v, want %v", i, got, want)
+		}
+	}
+	if uv := mesh.UVs[mesh.Indices[2*3+2]]; !uv.Approx(d2.Vec2{1, 1}) {
+		t.Errorf("uv of the 3rd vertex of triangle 2 = %v, want (1,1)", uv)
+	}
+	if n := mesh.Normals[mesh.Indices[0]]; !n.Approx(d3.Vec3{0, 0, 1}) {
+		t.Errorf("normal of the 1st vertex = %v, want (0,0,1)", n)
+	}
+	if n := mesh.Normals[mesh.Indices[2*3]]; !n.Approx(d3.Vec3{0, 0, 0}) {
+		t.Errorf("missing normal = %v, want (0,0,0)", n)
+	}
+	if a := mesh.Area(); a != 2.5 {
+		t.Errorf("Area() = %v, want 2.5", a)
+	}
+
+	// only positions
+	m, err = Read(strings.NewReader("v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"))
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if m.Mesh.UVs != nil || m.Mesh.Normals != nil || len(m.Groups) != 1 || m.Groups[0] != (Group{Count: 1}) {
+		t.Errorf("Read() = %+v, %+v", m.Mesh, m.Groups)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var tests = []struct {
+		obj  string
+		line int
+	}{
+		{"v 0 0\n", 1},
+		{"v 0 0 0\nv 0 0 x\n", 2},
+		{"v 0 0 0 0 0\n", 1},
+		{"vn 0 0 1 0\n", 1},
+		{"vt\n", 1},
+		{"v 0 0 0\nv 1 0 0\n\nf 1 2\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 4\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 0\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 -4\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1 2/1 3/1\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1//1 2 3\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/a 2 3\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1/1/1/1 2 3\n", 4},
+		{"v 0 0 0\nv 1 0 0\nv 0 1 0\nf /1 2 3\n", 4},
+	}
+	for _, tt := range tests {
+		_, err := Read(strings.NewReader(tt.obj))
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Read(%q) error = %v, want a *SyntaxError", tt.obj, err)
+			continue
+		}
+		if serr.Line != tt.line {
+			t.Errorf("Read(%q) error = %v, want line %d", tt.obj, err, tt.line)
+		}
+	}
+}
+
+func TestWrite(t *testing.T) {
+	m, err := Read(strings.NewReader(quads))
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, m); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	m2, err := Read(&buf)
+	if err != nil {
+		t.Fatalf("Read() of written model error: %v\n%s", err, buf.String())
+	}
+
+	if len(m2.Groups) != len(m.Groups) {
+		t.Fatalf("groups = %+v, want %+v", m2.Groups, m.Groups)
+	}
+	for i := range m.Groups {
+		if m2.Groups[i] != m.Groups[i] {
+			t.Errorf("group %d = %+v, want %+v", i, m2.Groups[i], m.Groups[i])
+		}
+	}
+	a, b := m.Mesh, m2.Mesh
+	if len(a.Positions) != len(b.Positions) || len(a.Indices) != len(b.Indices) {
+		t.Fatalf("written mesh has %d vertices and %d indices, want %d and %d",
+			len(b.Positions), len(b.Indices), len(a.Positions), len(a.Indices))
+	}
+	for i, idx := range a.Indices {
+		j := b.Indices[i]
+		if !a.Positions[idx].Approx(b.Positions[j]) || !a.Normals[idx].Approx(b.Normals[j]) || !a.UVs[idx].Approx(b.UVs[j]) {
+			t.Errorf("vertex %d = %v %v %v, want %v %v %v", i,
+				b.Positions[j], b.UVs[j], b.Normals[j], a.Positions[idx], a.UVs[idx], a.Normals[idx])
+		}
+	}
+
+	// without groups nor attributes
+	mesh := d3.NewTriangleMesh([]d3.Vec3{{0, 0, 0}, {1.5, 0, 0}, {0, 1, 0.25}}, []uint32{0, 1, 2})
+	buf.Reset()
+	if err := Write(&buf, &Model{Mesh: mesh}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+	want := "v 0 0 0\nv 1.5 0 0\nv 0 1 0.25\nf 1 2 3\n"
+	if got := buf.String(); got != want {
+		t.Errorf("Write() wrote %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,137 @@
+package d3
+
+import (
+	"fmt"
+
+	"github.com/arl/math32"
+)
+
+// A Plane is the set of points p verifying Normal.Dot(p) + D = 0.
+//
+// Normal is a unit vector pointing toward the front, or positive, side of the
+// plane and -D is the signed distance from the origin to the plane.
+type Plane struct {
+	Normal Vec3
+	D      float32
+}
+
+// NewPlane returns the plane of normal n passing through p. n doesn't need
+// to be normalized.
+func NewPlane(n, p Vec3) Plane {
+	nn := NewVec3From(n)
+	nn.Normalize()
+	return Plane{Normal: nn, D: -nn.Dot(p)}
+}
+
+// NewPlaneFromPoints returns the plane passing through a, b and c, its front
+// side being the one from where they appear in counter-clockwise order.
+//
+// It returns false if the points are collinear, in which case they don't
+// define a plane.
+func NewPlaneFromPoints(a, b, c Vec3) (Plane, bool) {
+	n := b.Sub(a).Cross(c.Sub(a))
+	if n.LenSqr() == 0 {
+		return Plane{}, false
+	}
+	return NewPlane(n, a), true
+}
+
+// newPlaneABCD returns the plane of equation ax + by + cz + d = 0, normalized.
+func newPlaneABCD(a, b, c, d float32) Plane {
+	l := math32.Sqrt(a*a + b*b + c*c)
+	return Plane{Normal: Vec3{a / l, b / l, c / l}, D: d / l}
+}
+
+// Dist returns the signed distance from pl to p, positive if p is on the front
+// side of pl.
+func (pl Plane) Dist(p Vec3) float32 {
+	return pl.Normal[0]*p[0] + pl.Normal[1]*p[1] + pl.Normal[2]*p[2] + pl.D
+}
+
+// Project returns the orthogonal projection of p on pl, that is the point of
+// pl that is the closest to p.
+//
+// It allocates a new vector/slice.
+func (pl Plane) Project(p Vec3) Vec3 {
+	return p.SAdd(pl.Normal, -pl.Dist(p))
+}
+
+// Flip returns the plane made of the same points as pl, but facing the other
+// side.
+//
+// It allocates a new vector/slice.
+func (pl Plane) Flip() Plane {
+	return Plane{Normal: pl.Normal.Scale(-1), D: -pl.D}
+}
+
+// Side describes on which side of a plane an object lies.
+type Side int
+
+const (
+	// Back indicates that the object is entirely behind the plane.
+	Back Side = iota
+
+	// OnPlane indicates that a point lies on the plane, or that a volume
+	// straddles it.
+	OnPlane
+
+	// Front indicates that the object is entirely in front of the plane.
+	Front
+)
+
+func (s Side) String() string {
+	switch s {
+	case Back:
+		return "back"
+	case OnPlane:
+		return "on plane"
+	case Front:
+		return "front"
+	}
+	return fmt.Sprintf("Side(%d)", int(s))
+}
+
+// ClassifyPoint returns the side of pl on which p lies. Points closer to pl
+// than eps are considered to lie on it.
+func (pl Plane) ClassifyPoint(p Vec3, eps float32) Side {
+	return classify(pl.Dist(p), eps)
+}
+
+// ClassifySphere returns the side of pl on which the sphere of center c and
+// radius r lies.
+func (pl Plane) ClassifySphere(c Vec3, r float32) Side {
+	return classify(pl.Dist(c), r)
+}
+
+// ClassifyRect returns the side of pl on which r lies.
+func (pl Plane) ClassifyRect(r Rectangle) Side {
+	d, e := pl.rectDist(r)
+	return classify(d, e)
+}
+
+// rectDist returns the signed distance from pl to the center of r, and the
+// projection of the half-extents of r on the normal of pl, so that r straddles
+// pl if and only if |d| <= e.
+func (pl Plane) rectDist(r Rectangle) (d, e float32) {
+	n := pl.Normal
+	cx, cy, cz := (r.Min[0]+r.Max[0])/2, (r.Min[1]+r.Max[1])/2, (r.Min[2]+r.Max[2])/2
+	ex, ey, ez := (r.Max[0]-r.Min[0])/2, (r.Max[1]-r.Min[1])/2, (r.Max[2]-r.Min[2])/2
+	d = n[0]*cx + n[1]*cy + n[2]*cz + pl.D
+	e = ex*math32.Abs(n[0]) + ey*math32.Abs(n[1]) + ez*math32.Abs(n[2])
+	return d, e
+}
+
+func classify(d, tol float32) Side {
+	switch {
+	case d > tol:
+		return Front
+	case d < -tol:
+		return Back
+	}
+	return OnPlane
+}
+
+// String returns a string representation of pl like "(n:(0,0,1),d:-2)".
+func (pl Plane) String() string {
+	return fmt.Sprintf("(n:%v,d:%v)", pl.Normal, pl.D)
+}
@@ -0,0 +1,84 @@
+package d3
+
+import (
+	"testing"
+
+	"github.com/arl/math32"
+)
+
+func TestPlaneDist(t *testing.T) {
+	pl := NewPlane(Vec3{0, 0, 2}, Vec3{5, -1, 3})
+	if !pl.Normal.Approx(Vec3{0, 0, 1}) || pl.D != -3 {
+		t.Fatalf("NewPlane() = %v, want (n:(0,0,1),d:-3)", pl)
+	}
+
+	pl2, ok := NewPlaneFromPoints(Vec3{0, 0, 3}, Vec3{1, 0, 3}, Vec3{0, 1, 3})
+	if !ok || !pl2.Normal.Approx(pl.Normal) || !math32.Approx(pl2.D, pl.D) {
+		t.Errorf("NewPlaneFromPoints() = %v, %t, want %v, true", pl2, ok, pl)
+	}
+	if _, ok := NewPlaneFromPoints(Vec3{0, 0, 0}, Vec3{1, 1, 1}, Vec3{3, 3, 3}); ok {
+		t.Errorf("NewPlaneFromPoints() with collinear points succeeded")
+	}
+
+	var tests = []struct {
+		p    Vec3
+		dist float32
+		side Side
+	}{
+		{Vec3{0, 0, 3}, 0, OnPlane},
+		{Vec3{7, 8, 3.0000001}, 0, OnPlane},
+		{Vec3{1, 2, 5}, 2, Front},
+		{Vec3{-1, 2, -1}, -4, Back},
+	}
+	for _, tt := range tests {
+		if got := pl.Dist(tt.p); !math32.ApproxEpsilon(got, tt.dist, 1e-6) {
+			t.Errorf("%v.Dist(%v) = %v, want %v", pl, tt.p, got, tt.dist)
+		}
+		if got := pl.ClassifyPoint(tt.p, 1e-5); got != tt.side {
+			t.Errorf("%v.ClassifyPoint(%v) = %v, want %v", pl, tt.p, got, tt.side)
+		}
+		if got := pl.Flip().Dist(tt.p); !math32.ApproxEpsilon(got, -tt.dist, 1e-6) {
+			t.Errorf("flipped %v.Dist(%v) = %v, want %v", pl, tt.p, got, -tt.dist)
+		}
+		if got := pl.Project(tt.p); !vec3ApproxEps(got, Vec3{tt.p[0], tt.p[1], 3}, 1e-6) {
+			t.Errorf("%v.Project(%v) = %v", pl, tt.p, got)
+		}
+	}
+}
+
+func TestPlaneClassify(t *testing.T) {
+	// oblique plane x + y = 2
+	pl := NewPlane(Vec3{1, 1, 0}, Vec3{1, 1, 0})
+
+	var rects = []struct {
+		r    Rectangle
+		want Side
+	}{
+		{Rect(0, 0, 0, 0.9, 0.9, 5), Back},
+		{Rect(0, 0, 0, 1.1, 1.1, 5), OnPlane},
+		{Rect(1.1, 1.1, -3, 4, 4, 5), Front},
+		{Rect(-5, 3, 0, -4, 4, 1), Back},
+		{Rect(-5, 6.9, 0, -4, 8, 1), OnPlane},
+	}
+	for _, tt := range rects {
+		if got := pl.ClassifyRect(tt.r); got != tt.want {
+			t.Errorf("%v.ClassifyRect(%v) = %v, want %v", pl, tt.r, got, tt.want)
+		}
+	}
+
+	var spheres = []struct {
+		c    Vec3
+		r    float32
+		want Side
+	}{
+		{Vec3{0, 0, 0}, 1, Back},
+		{Vec3{0, 0, 0}, 1.5, OnPlane},
+		{Vec3{3, 3, 9}, 2.8, Front},
+		{Vec3{3, 3, 9}, 2.9, OnPlane},
+	}
+	for _, tt := range spheres {
+		if got := pl.ClassifySphere(tt.c, tt.r); got != tt.want {
+			t.Errorf("%v.ClassifySphere(%v, %v) = %v, want %v", pl, tt.c, tt.r, got, tt.want)
+		}
+	}
+}
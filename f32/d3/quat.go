@@ -0,0 +1,336 @@
+package d3
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/arl/math32"
+)
+
+// Quat is a quaternion, used to represent rotations in 3D space. It is made up
+// of a slice of 4 32 bits floating points numbers: the X, Y and Z components
+// of its vector part, followed by its scalar part W.
+//
+// A rotation of angle a around the unit axis u is represented by the unit
+// quaternion (u*sin(a/2), cos(a/2)). Unless stated otherwise, the methods
+// rotating vectors expect unit quaternions.
+type Quat []float32
+
+// NewQuat allocates and returns a new Quat where each component has its zero
+// value.
+func NewQuat() Quat {
+	return make(Quat, 4)
+}
+
+// NewQuatIdentity allocates and returns a new identity Quat, that represents
+// no rotation.
+func NewQuatIdentity() Quat {
+	return Quat{0, 0, 0, 1}
+}
+
+// NewQuatXYZW allocates and returns Quat{x, y, z, w}.
+func NewQuatXYZW(x, y, z, w float32) Quat {
+	return Quat{x, y, z, w}
+}
+
+// NewQuatFrom allocates and returns a new Quat that is the copy of q1.
+func NewQuatFrom(q1 Quat) Quat {
+	return Quat{q1[0], q1[1], q1[2], q1[3]}
+}
+
+// NewQuatAxisAngle allocates and returns a new Quat representing the rotation
+// by angle radians around axis, counter-clockwise when axis points toward the
+// observer. axis doesn't need to be normalized.
+func NewQuatAxisAngle(axis Vec3, angle float32) Quat {
+	s, c := math32.Sincos(angle / 2)
+	s /= axis.Len()
+	return Quat{axis[0] * s, axis[1] * s, axis[2] * s, c}
+}
+
+// NewQuatEuler allocates and returns a new Quat representing the rotation by
+// x radians around the X axis, followed by the rotation by y radians around
+// the Y axis, followed by the rotation by z radians around the Z axis.
+func NewQuatEuler(x, y, z float32) Quat {
+	sx, cx := math32.Sincos(x / 2)
+	sy, cy := math32.Sincos(y / 2)
+	sz, cz := math32.Sincos(z / 2)
+	// product of the rotations around Z, Y and X
+	return Quat{
+		sx*cy*cz - cx*sy*sz,
+		cx*sy*cz + sx*cy*sz,
+		cx*cy*sz - sx*sy*cz,
+		cx*cy*cz + sx*sy*sz,
+	}
+}
+
+// NewQuatRotationBetween allocates and returns a new Quat representing the
+// shortest rotation transforming the direction of from into the direction
+// of to. from and to don't need to be normalized.
+func NewQuatRotationBetween(from, to Vec3) Quat {
+	a, b := NewVec3From(from), NewVec3From(to)
+	a.Normalize()
+	b.Normalize()
+	d := a.Dot(b)
+	switch {
+	case d >= 1-1e-6:
+		return NewQuatIdentity()
+	case d <= -1+1e-6:
+		// opposite directions, rotate by 180° around any perpendicular axis
+		axis := Vec3{1, 0, 0}.Cross(a)
+		if axis.LenSqr() < 1e-6 {
+			axis = Vec3{0, 1, 0}.Cross(a)
+		}
+		return NewQuatAxisAngle(axis, math.Pi)
+	}
+	c := a.Cross(b)
+	q := Quat{c[0], c[1], c[2], 1 + d}
+	q.Normalize()
+	return q
+}
+
+// NewQuatFromMat3 allocates and returns a new Quat representing the same
+// rotation as m, that must be a rotation matrix.
+func NewQuatFromMat3(m Mat3) Quat {
+	m00, m11, m22 := m.At(0, 0), m.At(1, 1), m.At(2, 2)
+	var q Quat
+	// choose the computation dividing by the largest number
+	switch tr := m00 + m11 + m22; {
+	case tr > 0:
+		s := 2 * math32.Sqrt(tr+1)
+		q = Quat{(m.At(2, 1) - m.At(1, 2)) / s, (m.At(0, 2) - m.At(2, 0)) / s, (m.At(1, 0) - m.At(0, 1)) / s, s / 4}
+	case m00 > m11 && m00 > m22:
+		s := 2 * math32.Sqrt(1+m00-m11-m22)
+		q = Quat{s / 4, (m.At(0, 1) + m.At(1, 0)) / s, (m.At(0, 2) + m.At(2, 0)) / s, (m.At(2, 1) - m.At(1, 2)) / s}
+	case m11 > m22:
+		s := 2 * math32.Sqrt(1+m11-m00-m22)
+		q = Quat{(m.At(0, 1) + m.At(1, 0)) / s, s / 4, (m.At(1, 2) + m.At(2, 1)) / s, (m.At(0, 2) - m.At(2, 0)) / s}
+	default:
+		s := 2 * math32.Sqrt(1+m22-m00-m11)
+		q = Quat{(m.At(0, 2) + m.At(2, 0)) / s, (m.At(1, 2) + m.At(2, 1)) / s, s / 4, (m.At(1, 0) - m.At(0, 1)) / s}
+	}
+	q.Normalize()
+	return q
+}
+
+// NewQuatFromMat4 allocates and returns a new Quat representing the same
+// rotation as the upper-left 3x3 matrix of m, that must be a rotation matrix.
+func NewQuatFromMat4(m Mat4) Quat {
+	return NewQuatFromMat3(m.Mat3())
+}
+
+// component access
+
+// X returns the X component of q.
+func (q Quat) X() float32 {
+	return q[0]
+}
+
+// Y returns the Y component of q.
+func (q Quat) Y() float32 {
+	return q[1]
+}
+
+// Z returns the Z component of q.
+func (q Quat) Z() float32 {
+	return q[2]
+}
+
+// W returns the W component, or scalar part, of q.
+func (q Quat) W() float32 {
+	return q[3]
+}
+
+// Quat functions
+
+// QuatMul performs a quaternion multiplication. dst = q1 * q2
+//
+//	dst  [out]  The result quaternion, that can be q1 or q2.
+//	q1   [in]   The left quaternion.
+//	q2   [in]   The right quaternion.
+//
+// The result represents the rotation q2 followed by the rotation q1.
+func QuatMul(dst, q1, q2 Quat) {
+	x := q1[3]*q2[0] + q1[0]*q2[3] + q1[1]*q2[2] - q1[2]*q2[1]
+	y := q1[3]*q2[1] - q1[0]*q2[2] + q1[1]*q2[3] + q1[2]*q2[0]
+	z := q1[3]*q2[2] + q1[0]*q2[1] - q1[1]*q2[0] + q1[2]*q2[3]
+	w := q1[3]*q2[3] - q1[0]*q2[0] - q1[1]*q2[1] - q1[2]*q2[2]
+	dst[0], dst[1], dst[2], dst[3] = x, y, z, w
+}
+
+// Quat methods
+
+// Mul returns a new quaternion that is the result of q * q1, that represents
+// the rotation q1 followed by the rotation q.
+//
+// It allocates a new quaternion/slice.
+func (q Quat) Mul(q1 Quat) Quat {
+	dst := NewQuat()
+	QuatMul(dst, q, q1)
+	return dst
+}
+
+// Dot derives the dot product of two quaternions. q . q1
+func (q Quat) Dot(q1 Quat) float32 {
+	return q[0]*q1[0] + q[1]*q1[1] + q[2]*q1[2] + q[3]*q1[3]
+}
+
+// Len derives the length, or norm, of q.
+func (q Quat) Len() float32 {
+	return math32.Sqrt(q.Dot(q))
+}
+
+// Normalize normalizes the quaternion, so that it represents a rotation.
+func (q Quat) Normalize() {
+	d := 1 / q.Len()
+	q[0] *= d
+	q[1] *= d
+	q[2] *= d
+	q[3] *= d
+}
+
+// Conjugate returns a new quaternion that is the conjugate of q. For a unit
+// quaternion, it's also its inverse, that represents the opposite rotation.
+//
+// It allocates a new quaternion/slice.
+func (q Quat) Conjugate() Quat {
+	return Quat{-q[0], -q[1], -q[2], q[3]}
+}
+
+// Inverse returns a new quaternion that is the inverse of q, which doesn't
+// need to be a unit quaternion.
+//
+// It allocates a new quaternion/slice.
+func (q Quat) Inverse() Quat {
+	d := q.Dot(q)
+	return Quat{-q[0] / d, -q[1] / d, -q[2] / d, q[3] / d}
+}
+
+// AxisAngle returns the unit axis and the angle, in [0, 2π], of the rotation
+// represented by q. The axis is arbitrary if the angle is null.
+func (q Quat) AxisAngle() (axis Vec3, angle float32) {
+	u := NewQuatFrom(q)
+	u.Normalize()
+	angle = 2 * math32.Acos(math32.Max(-1, math32.Min(1, u[3])))
+	s := math32.Sqrt(1 - u[3]*u[3])
+	if s < 1e-6 {
+		return Vec3{1, 0, 0}, angle
+	}
+	return Vec3{u[0] / s, u[1] / s, u[2] / s}, angle
+}
+
+// Euler returns the angles of the rotations around the X, Y and Z axis which,
+// applied in that order, are equivalent to q, so that NewQuatEuler(q.Euler())
+// represents the same rotation as q. y is in [-π/2, π/2], x and z in [-π, π].
+func (q Quat) Euler() (x, y, z float32) {
+	m := q.Mat3()
+	// m = Rz(z) * Ry(y) * Rx(x)
+	sy := -m.At(2, 0)
+	if sy >= 1-1e-6 || sy <= -1+1e-6 {
+		// gimbal lock, only x-z or x+z can be known, x is chosen null
+		y = math32.Copysign(math.Pi/2, sy)
+		z = math32.Atan2(-m.At(0, 1), m.At(1, 1))
+		return 0, y, z
+	}
+	x = math32.Atan2(m.At(2, 1), m.At(2, 2))
+	y = math32.Asin(sy)
+	z = math32.Atan2(m.At(1, 0), m.At(0, 0))
+	return x, y, z
+}
+
+// Rotate returns a new vector that is v rotated by q.
+//
+// It allocates a new vector/slice.
+func (q Quat) Rotate(v Vec3) Vec3 {
+	// v + 2w(u x v) + 2u x (u x v), with u the vector part of q
+	u := Vec3{q[0], q[1], q[2]}
+	uv := u.Cross(v)
+	uuv := u.Cross(uv)
+	return NewVec3XYZ(
+		v[0]+2*(q[3]*uv[0]+uuv[0]),
+		v[1]+2*(q[3]*uv[1]+uuv[1]),
+		v[2]+2*(q[3]*uv[2]+uuv[2]),
+	)
+}
+
+// Nlerp returns a new quaternion that is the normalized linear interpolation
+// between q and q1, along the shortest path, for t in [0, 1]. It's faster
+// than Slerp, but doesn't interpolate at constant angular speed.
+//
+// It allocates a new quaternion/slice.
+func (q Quat) Nlerp(q1 Quat, t float32) Quat {
+	s := float32(1)
+	if q.Dot(q1) < 0 {
+		s = -1
+	}
+	r := Quat{
+		q[0] + (s*q1[0]-q[0])*t,
+		q[1] + (s*q1[1]-q[1])*t,
+		q[2] + (s*q1[2]-q[2])*t,
+		q[3] + (s*q1[3]-q[3])*t,
+	}
+	r.Normalize()
+	return r
+}
+
+// Slerp returns a new quaternion that is the spherical linear interpolation
+// between the unit quaternions q and q1, along the shortest path, for t in
+// [0, 1]. It interpolates at constant angular speed.
+//
+// It allocates a new quaternion/slice.
+func (q Quat) Slerp(q1 Quat, t float32) Quat {
+	d := q.Dot(q1)
+	s := float32(1)
+	if d < 0 {
+		d, s = -d, -1
+	}
+	if d > 1-1e-5 {
+		// nearly identical rotations, sin(θ) vanishes
+		return q.Nlerp(q1, t)
+	}
+	theta := math32.Acos(d)
+	sin := math32.Sin(theta)
+	s0 := math32.Sin((1-t)*theta) / sin
+	s1 := s * math32.Sin(t*theta) / sin
+	return Quat{
+		s0*q[0] + s1*q1[0],
+		s0*q[1] + s1*q1[1],
+		s0*q[2] + s1*q1[2],
+		s0*q[3] + s1*q1[3],
+	}
+}
+
+// Mat3 returns a new rotation matrix representing the same rotation as the
+// unit quaternion q.
+//
+// It allocates a new matrix/slice.
+func (q Quat) Mat3() Mat3 {
+	x, y, z, w := q[0], q[1], q[2], q[3]
+	return Mat3{
+		1 - 2*(y*y+z*z), 2 * (x*y + w*z), 2 * (x*z - w*y),
+		2 * (x*y - w*z), 1 - 2*(x*x+z*z), 2 * (y*z + w*x),
+		2 * (x*z + w*y), 2 * (y*z - w*x), 1 - 2*(x*x+y*y),
+	}
+}
+
+// Mat4 returns a new rotation matrix representing the same rotation as the
+// unit quaternion q.
+//
+// It allocates a new matrix/slice.
+func (q Quat) Mat4() Mat4 {
+	return q.Mat3().Mat4()
+}
+
+// Approx reports wether q and q1 are approximately equal.
+//
+// Element-wise approximation uses math32.Approx()
+func (q Quat) Approx(q1 Quat) bool {
+	return math32.Approx(q[0], q1[0]) &&
+		math32.Approx(q[1], q1[1]) &&
+		math32.Approx(q[2], q1[2]) &&
+		math32.Approx(q[3], q1[3])
+}
+
+// String returns a string representation of q like "(x,y,z,w)".
+func (q Quat) String() string {
+	return fmt.Sprintf("(%f,%f,%f,%f)", q[0], q[1], q[2], q[3])
+}
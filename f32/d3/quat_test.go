@@ -0,0 +1,208 @@
+package d3
+
+import (
+	"math"
+	"testing"
+
+	"github.com/arl/math32"
+)
+
+func quatApproxEps(a, b Quat, eps float32) bool {
+	// q and -q represent the same rotation
+	if a.Dot(b) < 0 {
+		b = Quat{-b[0], -b[1], -b[2], -b[3]}
+	}
+	return math32.ApproxEpsilon(a[0], b[0], eps) &&
+		math32.ApproxEpsilon(a[1], b[1], eps) &&
+		math32.ApproxEpsilon(a[2], b[2], eps) &&
+		math32.ApproxEpsilon(a[3], b[3], eps)
+}
+
+func TestQuatRotate(t *testing.T) {
+	var tests = []struct {
+		axis  Vec3
+		angle float32
+		v     Vec3
+		want  Vec3
+	}{
+		{Vec3{0, 0, 1}, math.Pi / 2, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{Vec3{0, 0, 2}, math.Pi / 2, Vec3{0, 1, 0}, Vec3{-1, 0, 0}},
+		{Vec3{1, 0, 0}, math.Pi / 2, Vec3{0, 1, 0}, Vec3{0, 0, 1}},
+		{Vec3{0, 1, 0}, math.Pi / 2, Vec3{0, 0, 1}, Vec3{1, 0, 0}},
+		{Vec3{1, 1, 1}, 2 * math.Pi / 3, Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{Vec3{1, 1, 1}, 1, Vec3{2, 2, 2}, Vec3{2, 2, 2}},
+	}
+	for _, tt := range tests {
+		q := NewQuatAxisAngle(tt.axis, tt.angle)
+		if got := q.Rotate(tt.v); !vec3ApproxEps(got, tt.want, 1e-6) {
+			t.Errorf("rotation of %v around %v by %v = %v, want %v", tt.v, tt.axis, tt.angle, got, tt.want)
+		}
+		if got := q.Len(); !math32.Approx(got, 1) {
+			t.Errorf("%v.Len() = %v, want 1", q, got)
+		}
+		// same rotation as the matrix
+		if got, want := q.Mat3(), NewMat3Rotate(tt.axis, tt.angle); !got.Approx(want) {
+			t.Errorf("%v.Mat3() = %v, want %v", q, got, want)
+		}
+		if got := q.Mat4().TransformDir(tt.v); !vec3ApproxEps(got, tt.want, 1e-6) {
+			t.Errorf("%v.Mat4() transforms %v to %v, want %v", q, tt.v, got, tt.want)
+		}
+		// and back
+		if got := NewQuatFromMat3(q.Mat3()); !quatApproxEps(got, q, 1e-6) {
+			t.Errorf("NewQuatFromMat3(%v) = %v, want %v", q.Mat3(), got, q)
+		}
+		if got := NewQuatFromMat4(q.Mat4()); !quatApproxEps(got, q, 1e-6) {
+			t.Errorf("NewQuatFromMat4(%v) = %v, want %v", q.Mat4(), got, q)
+		}
+		// the conjugate reverts the rotation
+		if got := q.Conjugate().Rotate(tt.want); !vec3ApproxEps(got, tt.v, 1e-6) {
+			t.Errorf("inverse rotation of %v = %v, want %v", tt.want, got, tt.v)
+		}
+	}
+}
+
+func TestQuatFromMat3(t *testing.T) {
+	// rotations by π around each axis exercise every branch
+	for _, axis := range []Vec3{{1, 0, 0}, {0, 1, 0}, {0, 0, 1}, {1, 1, 0}, {0, 0, 0.5}} {
+		m := NewMat3Rotate(axis, math.Pi)
+		q := NewQuatFromMat3(m)
+		if got := q.Mat3(); !got.Approx(m) {
+			t.Errorf("NewQuatFromMat3(%v).Mat3() = %v", m, got)
+		}
+	}
+	if got := NewQuatFromMat3(NewMat3Identity()); !got.Approx(NewQuatIdentity()) {
+		t.Errorf("NewQuatFromMat3(identity) = %v, want identity", got)
+	}
+}
+
+func TestQuatAxisAngle(t *testing.T) {
+	q := NewQuatAxisAngle(Vec3{0, 3, 4}, 1.2)
+	axis, angle := q.AxisAngle()
+	if !vec3ApproxEps(axis, Vec3{0, 0.6, 0.8}, 1e-6) || !math32.ApproxEpsilon(angle, 1.2, 1e-6) {
+		t.Errorf("%v.AxisAngle() = %v, %v, want (0,0.6,0.8), 1.2", q, axis, angle)
+	}
+	if _, angle := NewQuatIdentity().AxisAngle(); angle != 0 {
+		t.Errorf("angle of the identity = %v, want 0", angle)
+	}
+}
+
+func TestQuatMulInverse(t *testing.T) {
+	qx := NewQuatAxisAngle(Vec3{1, 0, 0}, math.Pi/2)
+	qz := NewQuatAxisAngle(Vec3{0, 0, 1}, math.Pi/2)
+
+	// rotate around X, then around Z
+	q := qz.Mul(qx)
+	if got := q.Rotate(Vec3{0, 1, 0}); !vec3ApproxEps(got, Vec3{0, 0, 1}, 1e-6) {
+		t.Errorf("qz*qx rotates (0,1,0) to %v, want (0,0,1)", got)
+	}
+	if got := q.Rotate(Vec3{0, 0, 1}); !vec3ApproxEps(got, Vec3{1, 0, 0}, 1e-6) {
+		t.Errorf("qz*qx rotates (0,0,1) to %v, want (1,0,0)", got)
+	}
+	if got, want := q.Mat3(), qz.Mat3().Mul(qx.Mat3()); !got.Approx(want) {
+		t.Errorf("(qz*qx).Mat3() = %v, want %v", got, want)
+	}
+
+	// c-like api, with aliasing
+	dst := NewQuatFrom(qz)
+	QuatMul(dst, dst, qx)
+	if !dst.Approx(q) {
+		t.Errorf("qz*qx = %v, want %v", dst, q)
+	}
+
+	// non-unit quaternion
+	n := Quat{1, 2, 3, 4}
+	if got := n.Mul(n.Inverse()); !got.Approx(NewQuatIdentity()) {
+		t.Errorf("%v * %v = %v, want identity", n, n.Inverse(), got)
+	}
+	n.Normalize()
+	if got := n.Len(); !math32.Approx(got, 1) {
+		t.Errorf("Len() after Normalize() = %v, want 1", got)
+	}
+	if !n.Inverse().Approx(n.Conjugate()) {
+		t.Errorf("inverse %v != conjugate %v of a unit quaternion", n.Inverse(), n.Conjugate())
+	}
+}
+
+func TestQuatEuler(t *testing.T) {
+	var tests = []struct {
+		x, y, z float32
+	}{
+		{0, 0, 0},
+		{0.3, 0, 0},
+		{0, -0.4, 0},
+		{0, 0, 2.5},
+		{0.1, 0.2, 0.3},
+		{-2, 1.2, -0.7},
+		{0.5, math.Pi / 2, 0},
+	}
+	for _, tt := range tests {
+		q := NewQuatEuler(tt.x, tt.y, tt.z)
+		want := NewQuatAxisAngle(Vec3{0, 0, 1}, tt.z).
+			Mul(NewQuatAxisAngle(Vec3{0, 1, 0}, tt.y)).
+			Mul(NewQuatAxisAngle(Vec3{1, 0, 0}, tt.x))
+		if !quatApproxEps(q, want, 1e-6) {
+			t.Errorf("NewQuatEuler(%v, %v, %v) = %v, want %v", tt.x, tt.y, tt.z, q, want)
+		}
+
+		x, y, z := q.Euler()
+		if got := NewQuatEuler(x, y, z); !quatApproxEps(got, q, 1e-3) {
+			t.Errorf("%v.Euler() = %v, %v, %v, doesn't represent the same rotation", q, x, y, z)
+		}
+	}
+	if x, y, z := NewQuatEuler(0.1, 0.2, 0.3).Euler(); !math32.ApproxEpsilon(x, 0.1, 1e-5) ||
+		!math32.ApproxEpsilon(y, 0.2, 1e-5) || !math32.ApproxEpsilon(z, 0.3, 1e-5) {
+		t.Errorf("Euler() = %v, %v, %v, want 0.1, 0.2, 0.3", x, y, z)
+	}
+}
+
+func TestQuatRotationBetween(t *testing.T) {
+	var tests = []struct {
+		from, to Vec3
+	}{
+		{Vec3{1, 0, 0}, Vec3{0, 1, 0}},
+		{Vec3{1, 2, 3}, Vec3{-3, 0.5, 2}},
+		{Vec3{0, 0, 2}, Vec3{0, 0, 5}},
+		{Vec3{1, 0, 0}, Vec3{-1, 0, 0}},
+		{Vec3{0, 1, 1}, Vec3{0, -2, -2}},
+	}
+	for _, tt := range tests {
+		q := NewQuatRotationBetween(tt.from, tt.to)
+		got := q.Rotate(tt.from)
+		want := tt.to.Scale(tt.from.Len() / tt.to.Len())
+		if !vec3ApproxEps(got, want, 1e-5) {
+			t.Errorf("NewQuatRotationBetween(%v, %v) rotates %v to %v, want %v", tt.from, tt.to, tt.from, got, want)
+		}
+		if l := q.Len(); !math32.Approx(l, 1) {
+			t.Errorf("NewQuatRotationBetween(%v, %v).Len() = %v, want 1", tt.from, tt.to, l)
+		}
+	}
+}
+
+func TestQuatInterpolation(t *testing.T) {
+	axis := Vec3{1, 2, 2}
+	q0 := NewQuatAxisAngle(axis, 0.2)
+	q1 := NewQuatAxisAngle(axis, 1.4)
+
+	for _, tt := range []float32{0, 0.25, 0.5, 1} {
+		want := NewQuatAxisAngle(axis, 0.2+1.2*tt)
+		if got := q0.Slerp(q1, tt); !quatApproxEps(got, want, 1e-6) {
+			t.Errorf("Slerp(%v) = %v, want %v", tt, got, want)
+		}
+		// same axis, constant speed only at the middle and the ends
+		if tt == 0.25 {
+			continue
+		}
+		if got := q0.Nlerp(q1, tt); !quatApproxEps(got, want, 1e-6) {
+			t.Errorf("Nlerp(%v) = %v, want %v", tt, got, want)
+		}
+	}
+
+	// shortest path, -q1 is the same rotation as q1
+	neg := Quat{-q1[0], -q1[1], -q1[2], -q1[3]}
+	if got, want := q0.Slerp(neg, 0.5), NewQuatAxisAngle(axis, 0.8); !quatApproxEps(got, want, 1e-6) {
+		t.Errorf("Slerp along the shortest path = %v, want %v", got, want)
+	}
+	if got := q0.Slerp(q0, 0.3); !quatApproxEps(got, q0, 1e-6) {
+		t.Errorf("Slerp of identical quaternions = %v, want %v", got, q0)
+	}
+}
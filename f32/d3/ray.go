@@ -0,0 +1,270 @@
+package d3
+
+import (
+	"fmt"
+
+	"github.com/arl/math32"
+)
+
+// A Ray is a line with an origin that extends infinitely in one direction.
+type Ray struct {
+	o    Vec3 // origin
+	v    Vec3 // direction vector
+	invv Vec3 // inverse of the direction vector
+}
+
+// NewRay creates a new Ray having o as origin and v as direction of the line.
+// v doesn't need to be normalized, the ray parameters are then expressed in
+// units of v.
+func NewRay(o, v Vec3) Ray {
+	return Ray{
+		o:    NewVec3From(o),
+		v:    NewVec3From(v),
+		invv: Vec3{1 / v[0], 1 / v[1], 1 / v[2]},
+	}
+}
+
+// Origin returns the origin point of the ray.
+func (r Ray) Origin() Vec3 {
+	return r.o
+}
+
+// Direction returns the direction vector of the ray.
+func (r Ray) Direction() Vec3 {
+	return r.v
+}
+
+// At returns the point of the ray located at parameter t, that is o + t*v.
+//
+// It allocates a new vector/slice.
+func (r Ray) At(t float32) Vec3 {
+	return r.o.SAdd(r.v, t)
+}
+
+// IntersectRect indicates wether the ray intersects with the rectangle b.
+func (r Ray) IntersectRect(b Rectangle) bool {
+	_, _, ok := r.ClipRect(b)
+	return ok
+}
+
+// ClipRect computes the parameters at which the ray enters and exits the
+// rectangle b, using the slab method. The boundaries of b are considered as
+// part of it.
+//
+// Parameters are expressed in units of the ray direction vector, so the
+// entry point is r.At(tmin). tmin is negative if the origin of the ray is
+// inside b. ok is false if the ray does not intersect b, in which case tmin
+// and tmax are meaningless.
+func (r Ray) ClipRect(b Rectangle) (tmin, tmax float32, ok bool) {
+	tmin, tmax, _, _, ok = r.clipRect(b)
+	return
+}
+
+// clipRect performs the slab test of r against b and also returns the axis
+// (0 for X, 1 for Y, 2 for Z) that determined tmin and tmax.
+func (r Ray) clipRect(b Rectangle) (tmin, tmax float32, amin, amax int, ok bool) {
+	tmin, tmax = math32.Inf(-1), math32.Inf(1)
+	amin, amax = -1, -1
+
+	for axis := 0; axis < 3; axis++ {
+		o, lo, hi := r.o[axis], b.Min[axis], b.Max[axis]
+		if r.v[axis] == 0 {
+			// parallel to the slab, the origin must be in it
+			if o < lo || o > hi {
+				return 0, 0, -1, -1, false
+			}
+			continue
+		}
+		t1 := (lo - o) * r.invv[axis]
+		t2 := (hi - o) * r.invv[axis]
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tmin {
+			tmin, amin = t1, axis
+		}
+		if t2 < tmax {
+			tmax, amax = t2, axis
+		}
+	}
+	ok = tmax >= math32.Max(tmin, 0)
+	return
+}
+
+// A RayHit describes the point where a ray hits a shape.
+type RayHit struct {
+	// T is the parameter of the hit point along the ray, expressed in units
+	// of the ray direction vector. If the direction vector is normalized, T
+	// is the distance from the ray origin to the hit point.
+	T float32
+
+	// Point is the hit point.
+	Point Vec3
+
+	// Normal is the unit normal of the shape surface at the hit point. It
+	// always faces the ray origin, that is Normal.Dot(ray.Direction()) <= 0.
+	Normal Vec3
+}
+
+// HitRect computes the first point where the ray hits the boundary of the
+// rectangle b, along with the normal of the hit face.
+//
+// If the ray origin is inside b, the hit point is where the ray exits b and
+// the normal is the one of the exit face, oriented toward the inside of b.
+// ok is false if the ray does not intersect b or if its direction is the null
+// vector.
+func (r Ray) HitRect(b Rectangle) (hit RayHit, ok bool) {
+	tmin, tmax, amin, amax, ok := r.clipRect(b)
+	if !ok || r.v.LenSqr() == 0 {
+		return RayHit{}, false
+	}
+	t, axis := tmin, amin
+	if tmin < 0 {
+		t, axis = tmax, amax
+	}
+	hit.T = t
+	hit.Point = r.At(t)
+	hit.Normal = NewVec3()
+	hit.Normal[axis] = -math32.Copysign(1, r.v[axis])
+	return hit, true
+}
+
+// triangleEps is the tolerance under which a ray is considered parallel to a
+// triangle.
+const triangleEps = 1e-7
+
+// IntersectTriangle computes the intersection between the ray and the
+// triangle (a, b, c), using the Möller–Trumbore algorithm. Both faces of the
+// triangle are considered.
+//
+// t is the parameter of the intersection point along the ray, u and v are its
+// barycentric coordinates in the triangle, so that it's located at
+// (1-u-v)*a + u*b + v*c. ok is false if the ray does not intersect the
+// triangle or is parallel to it.
+func (r Ray) IntersectTriangle(a, b, c Vec3) (t, u, v float32, ok bool) {
+	e1, e2, p, q, s := NewVec3(), NewVec3(), NewVec3(), NewVec3(), NewVec3()
+	Vec3Sub(e1, b, a)
+	Vec3Sub(e2, c, a)
+	Vec3Cross(p, r.v, e2)
+	det := e1.Dot(p)
+	if math32.Abs(det) <= triangleEps*e1.Len()*e2.Len()*r.v.Len() {
+		return 0, 0, 0, false
+	}
+	inv := 1 / det
+	Vec3Sub(s, r.o, a)
+	u = s.Dot(p) * inv
+	if u < 0 || u > 1 {
+		return 0, 0, 0, false
+	}
+	Vec3Cross(q, s, e1)
+	v = r.v.Dot(q) * inv
+	if v < 0 || u+v > 1 {
+		return 0, 0, 0, false
+	}
+	t = e2.Dot(q) * inv
+	if t < 0 {
+		return 0, 0, 0, false
+	}
+	return t, u, v, true
+}
+
+// HitTriangle computes the point where the ray hits the triangle (a, b, c).
+//
+// The normal is perpendicular to the triangle, on the side of the ray origin.
+// ok is false if the ray does not intersect the triangle.
+func (r Ray) HitTriangle(a, b, c Vec3) (hit RayHit, ok bool) {
+	t, _, _, ok := r.IntersectTriangle(a, b, c)
+	if !ok {
+		return RayHit{}, false
+	}
+	hit.T = t
+	hit.Point = r.At(t)
+	hit.Normal = b.Sub(a).Cross(c.Sub(a))
+	hit.Normal.Normalize()
+	if hit.Normal.Dot(r.v) > 0 {
+		Vec3Scale(hit.Normal, hit.Normal, -1)
+	}
+	return hit, true
+}
+
+// IntersectPlane computes the parameter t at which the ray intersects the
+// plane pl. ok is false if the ray is parallel to pl or points away from it.
+func (r Ray) IntersectPlane(pl Plane) (t float32, ok bool) {
+	denom := pl.Normal.Dot(r.v)
+	if denom == 0 {
+		return 0, false
+	}
+	t = -pl.Dist(r.o) / denom
+	if t < 0 {
+		return 0, false
+	}
+	return t, true
+}
+
+// HitPlane computes the point where the ray hits the plane pl.
+//
+// The normal is the one of pl, or its opposite, so that it faces the ray
+// origin. ok is false if the ray doesn't intersect pl.
+func (r Ray) HitPlane(pl Plane) (hit RayHit, ok bool) {
+	t, ok := r.IntersectPlane(pl)
+	if !ok {
+		return RayHit{}, false
+	}
+	hit.T = t
+	hit.Point = r.At(t)
+	hit.Normal = NewVec3From(pl.Normal)
+	if hit.Normal.Dot(r.v) > 0 {
+		Vec3Scale(hit.Normal, hit.Normal, -1)
+	}
+	return hit, true
+}
+
+// IntersectSphere computes the parameters at which the ray enters and exits
+// the sphere of center c and radius rad. t1 is negative if the origin of the
+// ray is inside the sphere. ok is false if the ray does not intersect the
+// sphere.
+func (r Ray) IntersectSphere(c Vec3, rad float32) (t1, t2 float32, ok bool) {
+	oc := r.o.Sub(c)
+	a := r.v.Dot(r.v)
+	b := oc.Dot(r.v)
+	cc := oc.Dot(oc) - rad*rad
+	disc := b*b - a*cc
+	if a == 0 || disc < 0 {
+		return 0, 0, false
+	}
+	sq := math32.Sqrt(disc)
+	t1, t2 = (-b-sq)/a, (-b+sq)/a
+	if t2 < 0 {
+		return 0, 0, false
+	}
+	return t1, t2, true
+}
+
+// HitSphere computes the first point where the ray hits the sphere of center
+// c and radius rad.
+//
+// If the ray origin is inside the sphere, the hit point is where the ray exits
+// it and the normal is oriented toward c. ok is false if the ray does not
+// intersect the sphere.
+func (r Ray) HitSphere(c Vec3, rad float32) (hit RayHit, ok bool) {
+	t1, t2, ok := r.IntersectSphere(c, rad)
+	if !ok {
+		return RayHit{}, false
+	}
+	hit.T = t1
+	if t1 < 0 {
+		hit.T = t2
+	}
+	hit.Point = r.At(hit.T)
+	hit.Normal = hit.Point.Sub(c)
+	hit.Normal.Normalize()
+	if t1 < 0 {
+		Vec3Scale(hit.Normal, hit.Normal, -1)
+	}
+	return hit, true
+}
+
+// String returns a string representation of r like with (o:Vec3,v:Vec3).
+func (r Ray) String() string {
+	return fmt.Sprintf("(o:%v,v:%v)", r.o, r.v)
+}
@@ -0,0 +1,137 @@
+package d3
+
+import (
+	"testing"
+
+	"github.com/arl/math32"
+)
+
+func TestRayClipRect(t *testing.T) {
+	b := Rect(1, 1, 1, 3, 2, 2)
+	var tests = []struct {
+		r          Ray
+		ok         bool
+		tmin, tmax float32
+		hit        RayHit
+	}{
+		{NewRay(Vec3{0, 1.5, 1.5}, Vec3{1, 0, 0}), true, 1, 3, RayHit{1, Vec3{1, 1.5, 1.5}, Vec3{-1, 0, 0}}},
+		{NewRay(Vec3{4, 1.5, 1.5}, Vec3{-2, 0, 0}), true, 0.5, 1.5, RayHit{0.5, Vec3{3, 1.5, 1.5}, Vec3{1, 0, 0}}},
+		{NewRay(Vec3{2, 0, 1.5}, Vec3{0, 1, 0}), true, 1, 2, RayHit{1, Vec3{2, 1, 1.5}, Vec3{0, -1, 0}}},
+		{NewRay(Vec3{2, 1.5, 5}, Vec3{0, 0, -1}), true, 3, 4, RayHit{3, Vec3{2, 1.5, 2}, Vec3{0, 0, 1}}},
+		{NewRay(Vec3{0, 0, 0}, Vec3{1, 1, 1}), true, 1, 2, RayHit{1, Vec3{1, 1, 1}, Vec3{-1, 0, 0}}},
+		// origin inside: exit point, inward normal
+		{NewRay(Vec3{2, 1.5, 1.5}, Vec3{0, 0, 1}), true, -0.5, 0.5, RayHit{0.5, Vec3{2, 1.5, 2}, Vec3{0, 0, -1}}},
+		// misses
+		{NewRay(Vec3{0, 0, 0}, Vec3{1, 0, 0}), false, 0, 0, RayHit{}},
+		{NewRay(Vec3{4, 1.5, 1.5}, Vec3{1, 0, 0}), false, 0, 0, RayHit{}},
+		{NewRay(Vec3{0, 1.5, 0}, Vec3{1, 0, 0.2}), false, 0, 0, RayHit{}},
+		{NewRay(Vec3{0, 0, 0}, Vec3{1, 3, 1}), false, 0, 0, RayHit{}},
+	}
+	for _, tt := range tests {
+		tmin, tmax, ok := tt.r.ClipRect(b)
+		if ok != tt.ok || ok && (!math32.Approx(tmin, tt.tmin) || !math32.Approx(tmax, tt.tmax)) {
+			t.Errorf("%v.ClipRect(%v) = %v, %v, %t, want %v, %v, %t", tt.r, b, tmin, tmax, ok, tt.tmin, tt.tmax, tt.ok)
+		}
+		if got := tt.r.IntersectRect(b); got != tt.ok {
+			t.Errorf("%v.IntersectRect(%v) = %t, want %t", tt.r, b, got, tt.ok)
+		}
+		hit, ok := tt.r.HitRect(b)
+		if ok != tt.ok {
+			t.Errorf("%v.HitRect(%v) ok = %t, want %t", tt.r, b, ok, tt.ok)
+			continue
+		}
+		if ok && (!math32.Approx(hit.T, tt.hit.T) || !hit.Point.Approx(tt.hit.Point) || !hit.Normal.Approx(tt.hit.Normal)) {
+			t.Errorf("%v.HitRect(%v) = %+v, want %+v", tt.r, b, hit, tt.hit)
+		}
+	}
+}
+
+func TestRayIntersectTriangle(t *testing.T) {
+	a, b, c := Vec3{0, 0, 0}, Vec3{4, 0, 0}, Vec3{0, 4, 0}
+	var tests = []struct {
+		r       Ray
+		ok      bool
+		t, u, v float32
+	}{
+		{NewRay(Vec3{1, 1, 5}, Vec3{0, 0, -1}), true, 5, 0.25, 0.25},
+		{NewRay(Vec3{1, 1, -2}, Vec3{0, 0, 2}), true, 1, 0.25, 0.25},
+		{NewRay(Vec3{0, 0, 1}, Vec3{0, 0, -1}), true, 1, 0, 0},
+		{NewRay(Vec3{2, 2, 1}, Vec3{0, 0, -1}), true, 1, 0.5, 0.5},
+		{NewRay(Vec3{0, 0, 3}, Vec3{3, 1, -3}), true, 1, 0.75, 0.25},
+		// misses
+		{NewRay(Vec3{3, 3, 1}, Vec3{0, 0, -1}), false, 0, 0, 0},
+		{NewRay(Vec3{-1, 1, 1}, Vec3{0, 0, -1}), false, 0, 0, 0},
+		{NewRay(Vec3{1, 1, 1}, Vec3{0, 0, 1}), false, 0, 0, 0},
+		{NewRay(Vec3{-1, 1, 0}, Vec3{1, 0, 0}), false, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		tt2, u, v, ok := tt.r.IntersectTriangle(a, b, c)
+		if ok != tt.ok || ok && (!math32.Approx(tt2, tt.t) || !math32.Approx(u, tt.u) || !math32.Approx(v, tt.v)) {
+			t.Errorf("%v.IntersectTriangle() = %v, %v, %v, %t, want %v, %v, %v, %t",
+				tt.r, tt2, u, v, ok, tt.t, tt.u, tt.v, tt.ok)
+		}
+		hit, ok := tt.r.HitTriangle(a, b, c)
+		if ok != tt.ok {
+			t.Errorf("%v.HitTriangle() ok = %t, want %t", tt.r, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		want := a.Scale(1 - u - v).Add(b.Scale(u)).Add(c.Scale(v))
+		if !vec3ApproxEps(hit.Point, want, 1e-6) {
+			t.Errorf("%v.HitTriangle() point = %v, want %v", tt.r, hit.Point, want)
+		}
+		if hit.Normal.Dot(tt.r.Direction()) > 0 || !math32.Approx(math32.Abs(hit.Normal[2]), 1) {
+			t.Errorf("%v.HitTriangle() normal = %v", tt.r, hit.Normal)
+		}
+	}
+
+	// degenerate triangle
+	if _, _, _, ok := NewRay(Vec3{1, 0, 1}, Vec3{0, 0, -1}).IntersectTriangle(a, b, b); ok {
+		t.Errorf("IntersectTriangle() with a degenerate triangle succeeded")
+	}
+}
+
+func TestRayPlaneSphere(t *testing.T) {
+	pl := NewPlane(Vec3{0, 1, 0}, Vec3{0, 2, 0})
+	r := NewRay(Vec3{1, 5, 1}, Vec3{0, -2, 0})
+	hit, ok := r.HitPlane(pl)
+	if !ok || !math32.Approx(hit.T, 1.5) || !hit.Point.Approx(Vec3{1, 2, 1}) || !hit.Normal.Approx(Vec3{0, 1, 0}) {
+		t.Errorf("%v.HitPlane(%v) = %+v, %t", r, pl, hit, ok)
+	}
+	r = NewRay(Vec3{1, -1, 1}, Vec3{1, 1, 0})
+	hit, ok = r.HitPlane(pl)
+	if !ok || !math32.Approx(hit.T, 3) || !hit.Point.Approx(Vec3{4, 2, 1}) || !hit.Normal.Approx(Vec3{0, -1, 0}) {
+		t.Errorf("%v.HitPlane(%v) = %+v, %t", r, pl, hit, ok)
+	}
+	if _, ok := NewRay(Vec3{1, 5, 1}, Vec3{0, 1, 0}).IntersectPlane(pl); ok {
+		t.Errorf("IntersectPlane() with a ray pointing away succeeded")
+	}
+	if _, ok := NewRay(Vec3{1, 5, 1}, Vec3{1, 0, 1}).IntersectPlane(pl); ok {
+		t.Errorf("IntersectPlane() with a parallel ray succeeded")
+	}
+
+	c := Vec3{0, 0, 5}
+	var tests = []struct {
+		r      Ray
+		ok     bool
+		t      float32
+		normal Vec3
+	}{
+		{NewRay(Vec3{0, 0, 0}, Vec3{0, 0, 1}), true, 3, Vec3{0, 0, -1}},
+		{NewRay(Vec3{0, 0, 10}, Vec3{0, 0, -2}), true, 1.5, Vec3{0, 0, 1}},
+		{NewRay(Vec3{2, 0, 0}, Vec3{0, 0, 1}), true, 5, Vec3{1, 0, 0}},
+		// origin inside: exit point, inward normal
+		{NewRay(Vec3{0, 0, 5}, Vec3{0, 1, 0}), true, 2, Vec3{0, -1, 0}},
+		// misses
+		{NewRay(Vec3{3, 0, 0}, Vec3{0, 0, 1}), false, 0, nil},
+		{NewRay(Vec3{0, 0, 0}, Vec3{0, 0, -1}), false, 0, nil},
+	}
+	for _, tt := range tests {
+		hit, ok := tt.r.HitSphere(c, 2)
+		if ok != tt.ok || ok && (!math32.Approx(hit.T, tt.t) || !hit.Normal.Approx(tt.normal)) {
+			t.Errorf("%v.HitSphere() = %+v, %t, want T=%v, normal %v, %t", tt.r, hit, ok, tt.t, tt.normal, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,355 @@
+// Package stl reads and writes triangulated surfaces in the STL format, in
+// both its ASCII and binary variants.
+package stl
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/arl/gogeo/f32/d3"
+)
+
+// Format is the variant of the STL format.
+type Format int
+
+const (
+	// Binary is the binary STL format, with little-endian numbers.
+	Binary Format = iota
+
+	// ASCII is the textual STL format.
+	ASCII
+)
+
+func (f Format) String() string {
+	switch f {
+	case Binary:
+		return "binary"
+	case ASCII:
+		return "ascii"
+	}
+	return fmt.Sprintf("Format(%d)", int(f))
+}
+
+// A Facet is a triangle of an STL solid.
+type Facet struct {
+	// Normal is the unit normal of the facet. Some files have null normals,
+	// that should then be computed from the vertices.
+	Normal d3.Vec3
+
+	// Vertices are listed in counter-clockwise order, seen from the outside
+	// of the solid.
+	Vertices [3]d3.Vec3
+
+	// Attr is the attribute byte count of the binary format, that some
+	// software uses to store colors. It's 0 for the ASCII format.
+	Attr uint16
+}
+
+// A Solid is the content of an STL file.
+type Solid struct {
+	// Name is the name of an ASCII solid, or the header of a binary file
+	// stripped of its trailing NUL bytes and spaces.
+	Name   string
+	Facets []Facet
+}
+
+// ErrTruncated is returned when reading a binary STL file whose size doesn't
+// match its number of facets.
+var ErrTruncated = errors.New("stl: truncated binary file")
+
+// A SyntaxError describes a malformed line of an ASCII STL file.
+type SyntaxError struct {
+	Line int    // line number, starting at 1
+	Msg  string // description of the error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("stl: line %d: %s", e.Line, e.Msg)
+}
+
+const (
+	headerSize = 80
+	facetSize  = 50
+)
+
+// Read reads an STL file from r, detecting whether it's in the ASCII or in the
+// binary format, and returns the solid it contains along with its format.
+//
+// A file is considered binary if its size matches the number of facets of its
+// header, even if it starts with "solid" like ASCII files do.
+func Read(r io.Reader) (*Solid, Format, error) {
+	buf, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, Binary, err
+	}
+	if isBinary(buf) || !bytes.HasPrefix(bytes.TrimLeft(buf, " \t\r\n"), []byte("solid")) {
+		s, err := readBinary(buf)
+		return s, Binary, err
+	}
+	s, err := readASCII(buf)
+	return s, ASCII, err
+}
+
+func isBinary(buf []byte) bool {
+	if len(buf) < headerSize+4 {
+		return false
+	}
+	n := binary.LittleEndian.Uint32(buf[headerSize:])
+	return uint64(len(buf)) == headerSize+4+uint64(n)*facetSize
+}
+
+func readBinary(buf []byte) (*Solid, error) {
+	if len(buf) < headerSize+4 {
+		return nil, ErrTruncated
+	}
+	n := binary.LittleEndian.Uint32(buf[headerSize:])
+	data := buf[headerSize+4:]
+	if uint64(len(data)) < uint64(n)*facetSize {
+		return nil, ErrTruncated
+	}
+	s := &Solid{
+		Name:   strings.TrimRight(string(buf[:headerSize]), "\x00 "),
+		Facets: make([]Facet, n),
+	}
+	vec := func(b []byte) d3.Vec3 {
+		return d3.Vec3{
+			math.Float32frombits(binary.LittleEndian.Uint32(b)),
+			math.Float32frombits(binary.LittleEndian.Uint32(b[4:])),
+			math.Float32frombits(binary.LittleEndian.Uint32(b[8:])),
+		}
+	}
+	for i := range s.Facets {
+		b := data[i*facetSize:]
+		f := &s.Facets[i]
+		f.Normal = vec(b)
+		for j := range f.Vertices {
+			f.Vertices[j] = vec(b[12+12*j:])
+		}
+		f.Attr = binary.LittleEndian.Uint16(b[48:])
+	}
+	return s, nil
+}
+
+// asciiReader reads the lines of an ASCII STL file.
+type asciiReader struct {
+	sc   *bufio.Scanner
+	line int
+}
+
+func (rd *asciiReader) errorf(format string, args ...interface{}) error {
+	return &SyntaxError{Line: rd.line, Msg: fmt.Sprintf(format, args...)}
+}
+
+// next returns the fields of the next non-empty line, which must start with
+// the given keywords.
+func (rd *asciiReader) next(keywords ...string) ([]string, error) {
+	for rd.sc.Scan() {
+		rd.line++
+		fields := strings.Fields(rd.sc.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) < len(keywords) {
+			return nil, rd.errorf("got %q, want %q", strings.Join(fields, " "), strings.Join(keywords, " "))
+		}
+		for i, kw := range keywords {
+			if fields[i] != kw {
+				return nil, rd.errorf("got %q, want %q", strings.Join(fields, " "), strings.Join(keywords, " "))
+			}
+		}
+		return fields[len(keywords):], nil
+	}
+	if err := rd.sc.Err(); err != nil {
+		return nil, err
+	}
+	return nil, rd.errorf("unexpected end of file, want %q", strings.Join(keywords, " "))
+}
+
+// vec reads a line made of the given keywords followed by 3 numbers.
+func (rd *asciiReader) vec(keywords ...string) (d3.Vec3, error) {
+	args, err := rd.next(keywords...)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != 3 {
+		return nil, rd.errorf("got %d numbers, want 3", len(args))
+	}
+	v := d3.NewVec3()
+	for i, s := range args {
+		f, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return nil, rd.errorf("invalid number %q", s)
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+func readASCII(buf []byte) (*Solid, error) {
+	rd := &asciiReader{sc: bufio.NewScanner(bytes.NewReader(buf))}
+	args, err := rd.next("solid")
+	if err != nil {
+		return nil, err
+	}
+	s := &Solid{Name: strings.Join(args, " ")}
+	for {
+		args, err := rd.next()
+		if err != nil {
+			return nil, err
+		}
+		if args[0] == "endsolid" {
+			return s, nil
+		}
+		if args[0] != "facet" {
+			return nil, rd.errorf("got %q, want \"facet\" or \"endsolid\"", args[0])
+		}
+		f, err := rd.facet(args[1:])
+		if err != nil {
+			return nil, err
+		}
+		s.Facets = append(s.Facets, f)
+	}
+}
+
+// facet reads a facet, args being the fields following "facet" on its first
+// line.
+func (rd *asciiReader) facet(args []string) (Facet, error) {
+	var f Facet
+	if len(args) != 4 || args[0] != "normal" {
+		return f, rd.errorf("got %q, want \"facet normal\" and 3 numbers", "facet "+strings.Join(args, " "))
+	}
+	f.Normal = d3.NewVec3()
+	for i, s := range args[1:] {
+		v, err := strconv.ParseFloat(s, 32)
+		if err != nil {
+			return f, rd.errorf("invalid number %q", s)
+		}
+		f.Normal[i] = float32(v)
+	}
+	if _, err := rd.next("outer", "loop"); err != nil {
+		return f, err
+	}
+	for i := range f.Vertices {
+		v, err := rd.vec("vertex")
+		if err != nil {
+			return f, err
+		}
+		f.Vertices[i] = v
+	}
+	if _, err := rd.next("endloop"); err != nil {
+		return f, err
+	}
+	if _, err := rd.next("endfacet"); err != nil {
+		return f, err
+	}
+	return f, nil
+}
+
+// Write writes s to w, in the given format. A facet without a normal is
+// written with a null normal.
+//
+// In the binary format, the name of s is written in the header, truncated to
+// 80 bytes.
+func Write(w io.Writer, s *Solid, format Format) error {
+	bw := bufio.NewWriter(w)
+	if format == ASCII {
+		writeASCII(bw, s)
+	} else {
+		writeBinary(bw, s)
+	}
+	return bw.Flush()
+}
+
+// normal returns the normal of f, or the null vector if f has none.
+func (f *Facet) normal() d3.Vec3 {
+	if len(f.Normal) < 3 {
+		return d3.Vec3{0, 0, 0}
+	}
+	return f.Normal
+}
+
+func writeASCII(w *bufio.Writer, s *Solid) {
+	vec := func(v d3.Vec3) string {
+		return ftoa(v[0]) + " " + ftoa(v[1]) + " " + ftoa(v[2])
+	}
+	fmt.Fprintf(w, "solid %s\n", s.Name)
+	for i := range s.Facets {
+		f := &s.Facets[i]
+		fmt.Fprintf(w, "  facet normal %s\n", vec(f.normal()))
+		w.WriteString("    outer loop\n")
+		for _, v := range f.Vertices {
+			fmt.Fprintf(w, "      vertex %s\n", vec(v))
+		}
+		w.WriteString("    endloop\n")
+		w.WriteString("  endfacet\n")
+	}
+	fmt.Fprintf(w, "endsolid %s\n", s.Name)
+}
+
+func writeBinary(w *bufio.Writer, s *Solid) {
+	var header [headerSize + 4]byte
+	copy(header[:headerSize], s.Name)
+	binary.LittleEndian.PutUint32(header[headerSize:], uint32(len(s.Facets)))
+	w.Write(header[:])
+
+	var b [facetSize]byte
+	put := func(off int, v d3.Vec3) {
+		for i := 0; i < 3; i++ {
+			binary.LittleEndian.PutUint32(b[off+4*i:], math.Float32bits(v[i]))
+		}
+	}
+	for i := range s.Facets {
+		f := &s.Facets[i]
+		put(0, f.normal())
+		for j, v := range f.Vertices {
+			put(12+12*j, v)
+		}
+		binary.LittleEndian.PutUint16(b[48:], f.Attr)
+		w.Write(b[:])
+	}
+}
+
+func ftoa(f float32) string {
+	return strconv.FormatFloat(float64(f), 'e', -1, 32)
+}
+
+// NewSolid returns a solid made of the triangles of m, their normals being
+// computed from their vertices.
+func NewSolid(name string, m *d3.TriangleMesh) *Solid {
+	s := &Solid{Name: name, Facets: make([]Facet, m.NumTriangles())}
+	for i := range s.Facets {
+		a, b, c := m.Triangle(i)
+		s.Facets[i] = Facet{
+			Normal:   m.FaceNormal(i),
+			Vertices: [3]d3.Vec3{d3.NewVec3From(a), d3.NewVec3From(b), d3.NewVec3From(c)},
+		}
+	}
+	return s
+}
+
+// Mesh returns an indexed mesh made of the facets of s, whose vertices closer
+// than tol are welded, as d3.TriangleMesh.Weld does. Facet normals are not
+// kept, they can be recomputed with TriangleMesh.FaceNormal, or smoothed with
+// TriangleMesh.ComputeNormals.
+func (s *Solid) Mesh(tol float32) *d3.TriangleMesh {
+	m := &d3.TriangleMesh{
+		Positions: make([]d3.Vec3, 0, 3*len(s.Facets)),
+		Indices:   make([]uint32, 0, 3*len(s.Facets)),
+	}
+	for _, f := range s.Facets {
+		for _, v := range f.Vertices {
+			m.Indices = append(m.Indices, uint32(len(m.Positions)))
+			m.Positions = append(m.Positions, d3.NewVec3From(v))
+		}
+	}
+	m.Weld(tol)
+	return m
+}
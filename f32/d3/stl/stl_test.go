@@ -0,0 +1,170 @@
+package stl
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/arl/gogeo/f32/d3"
+	"github.com/arl/math32"
+)
+
+// tetrahedron returns the mesh of a tetrahedron with outward facing triangles.
+func tetrahedron() *d3.TriangleMesh {
+	return d3.NewTriangleMesh(
+		[]d3.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}, {0, 0, 1}},
+		[]uint32{0, 2, 1, 0, 1, 3, 0, 3, 2, 1, 2, 3},
+	)
+}
+
+const ascii = `solid my part
+  facet normal 0 0 -1
+    outer loop
+      vertex 0 0 0
+      vertex 0 1 0
+      vertex 1 0 0
+    endloop
+  endfacet
+
+  facet normal 0.0e0 -1.0e0 0.0e0
+    outer loop
+      vertex 0 0 0
+      vertex 1 0 0
+      vertex 0 0 1
+    endloop
+  endfacet
+endsolid my part
+`
+
+func TestReadASCII(t *testing.T) {
+	s, format, err := Read(strings.NewReader(ascii))
+	if err != nil {
+		t.Fatalf("Read() error: %v", err)
+	}
+	if format != ASCII {
+		t.Errorf("Read() format = %v, want %v", format, ASCII)
+	}
+	if s.Name != "my part" || len(s.Facets) != 2 {
+		t.Fatalf("Read() = %q with %d facets, want \"my part\" with 2 facets", s.Name, len(s.Facets))
+	}
+	f := s.Facets[1]
+	if !f.Normal.Approx(d3.Vec3{0, -1, 0}) || !f.Vertices[1].Approx(d3.Vec3{1, 0, 0}) || !f.Vertices[2].Approx(d3.Vec3{0, 0, 1}) {
+		t.Errorf("facet 1 = %+v", f)
+	}
+}
+
+func TestReadErrors(t *testing.T) {
+	var tests = []struct {
+		stl  string
+		line int
+	}{
+		{"solid\nfacet normal 0 0 1\n", 2},
+		{"solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 0 0\n", 5},
+		{"solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 1 x 0\n", 6},
+		{"solid\nfacet normal 0 0\n", 2},
+		{"solid\n\nfacet\n", 3},
+		{"solid\nfacet normal 0 0 1\nloop\n", 3},
+		{"solid\nfacet normal 0 0 1\nouter loop\nvertex 0 0 0\nvertex 1 0 0\nvertex 1 1 0\nendloop\nendloop\n", 8},
+		{"solid\nvertex 0 0 0\n", 2},
+	}
+	for _, tt := range tests {
+		_, _, err := Read(strings.NewReader(tt.stl))
+		serr, ok := err.(*SyntaxError)
+		if !ok {
+			t.Errorf("Read(%q) error = %v, want a *SyntaxError", tt.stl, err)
+			continue
+		}
+		if serr.Line != tt.line {
+			t.Errorf("Read(%q) error = %v, want line %d", tt.stl, err, tt.line)
+		}
+	}
+
+	// binary
+	for _, tt := range []string{"", "not an stl file", strings.Repeat("x", 80) + "\x02\x00\x00\x00" + strings.Repeat("x", 60)} {
+		if _, _, err := Read(strings.NewReader(tt)); err != ErrTruncated {
+			t.Errorf("Read(%q) error = %v, want %v", tt, err, ErrTruncated)
+		}
+	}
+}
+
+func TestWriteRead(t *testing.T) {
+	s := NewSolid("solid tetrahedron", tetrahedron())
+	s.Facets[2].Attr = 0x7c00
+	for _, format := range []Format{Binary, ASCII} {
+		var buf bytes.Buffer
+		if err := Write(&buf, s, format); err != nil {
+			t.Fatalf("Write(%v) error: %v", format, err)
+		}
+		if format == Binary && buf.Len() != 84+4*50 {
+			t.Errorf("binary file size = %d, want %d", buf.Len(), 84+4*50)
+		}
+
+		// binary headers starting with "solid" are not mistaken for ascii
+		got, gotFormat, err := Read(&buf)
+		if err != nil {
+			t.Fatalf("Read() of %v file error: %v", format, err)
+		}
+		if gotFormat != format {
+			t.Errorf("Read() format = %v, want %v", gotFormat, format)
+		}
+		if got.Name != s.Name || len(got.Facets) != len(s.Facets) {
+			t.Fatalf("Read() of %v file = %q with %d facets, want %q with %d facets",
+				format, got.Name, len(got.Facets), s.Name, len(s.Facets))
+		}
+		for i, f := range s.Facets {
+			g := got.Facets[i]
+			if !g.Normal.Approx(f.Normal) {
+				t.Errorf("%v facet %d normal = %v, want %v", format, i, g.Normal, f.Normal)
+			}
+			for j := range f.Vertices {
+				if !g.Vertices[j].Approx(f.Vertices[j]) {
+					t.Errorf("%v facet %d vertex %d = %v, want %v", format, i, j, g.Vertices[j], f.Vertices[j])
+				}
+			}
+			if format == Binary && g.Attr != f.Attr {
+				t.Errorf("facet %d attribute = %#x, want %#x", i, g.Attr, f.Attr)
+			}
+		}
+	}
+
+	if n := s.Facets[3].Normal; !n.Approx(d3.Vec3{1 / math32.Sqrt(3), 1 / math32.Sqrt(3), 1 / math32.Sqrt(3)}) {
+		t.Errorf("NewSolid() facet normal = %v", n)
+	}
+}
+
+func TestWriteNoNormal(t *testing.T) {
+	s := &Solid{Facets: []Facet{{Vertices: [3]d3.Vec3{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}}}}}
+	for _, format := range []Format{Binary, ASCII} {
+		var buf bytes.Buffer
+		if err := Write(&buf, s, format); err != nil {
+			t.Fatalf("Write(%v) error: %v", format, err)
+		}
+		got, _, err := Read(&buf)
+		if err != nil {
+			t.Fatalf("Read() of %v file error: %v", format, err)
+		}
+		if len(got.Facets) != 1 || !got.Facets[0].Normal.Approx(d3.Vec3{0, 0, 0}) {
+			t.Errorf("Read() of %v file = %+v, want a facet with a null normal", format, got.Facets)
+		}
+	}
+}
+
+func TestMesh(t *testing.T) {
+	s := NewSolid("", tetrahedron())
+	m := s.Mesh(0)
+	if len(m.Positions) != 4 || m.NumTriangles() != 4 {
+		t.Fatalf("Mesh() has %d vertices and %d triangles, want 4 and 4", len(m.Positions), m.NumTriangles())
+	}
+	if v := m.Volume(); !math32.Approx(v, 1.0/6) {
+		t.Errorf("Mesh().Volume() = %v, want 1/6", v)
+	}
+
+	// almost coincident vertices
+	s.Facets[1].Vertices[2][0] += 1e-4
+	if m := s.Mesh(0); len(m.Positions) != 5 {
+		t.Errorf("Mesh(0) has %d vertices, want 5", len(m.Positions))
+	}
+	if m := s.Mesh(1e-3); len(m.Positions) != 4 {
+		t.Errorf("Mesh(1e-3) has %d vertices, want 4", len(m.Positions))
+	}
+}
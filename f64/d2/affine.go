@@ -0,0 +1,200 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"fmt"
+	"math"
+)
+
+// An Affine is a 2D affine transformation, represented by the first 2 rows of
+// a 3x3 matrix in row-major order, the third one being implicitly 0 0 1:
+//
+//	| a[0] a[1] a[2] |
+//	| a[3] a[4] a[5] |
+//	|  0    0    1   |
+//
+// It maps the point (x, y) to (a[0]*x + a[1]*y + a[2], a[3]*x + a[4]*y + a[5]).
+type Affine [6]float64
+
+// IdentityAffine is the transformation that leaves points unchanged.
+var IdentityAffine = Affine{1, 0, 0, 0, 1, 0}
+
+// Translation returns the transformation that translates points by v.
+func Translation(v Vec) Affine {
+	return Affine{1, 0, v.X, 0, 1, v.Y}
+}
+
+// Rotation returns the transformation that rotates points by angle radians
+// around the origin, counter-clockwise in a Y-up frame.
+func Rotation(angle float64) Affine {
+	sin, cos := math.Sincos(angle)
+	return Affine{cos, -sin, 0, sin, cos, 0}
+}
+
+// RotationAround returns the transformation that rotates points by angle
+// radians around c.
+func RotationAround(angle float64, c Vec) Affine {
+	return Translation(c).Mul(Rotation(angle)).Mul(Translation(c.Mul(-1)))
+}
+
+// Scaling returns the transformation that scales points by sx along X and by
+// sy along Y, from the origin.
+func Scaling(sx, sy float64) Affine {
+	return Affine{sx, 0, 0, 0, sy, 0}
+}
+
+// Shearing returns the transformation that shears points by kx along X and by
+// ky along Y, mapping (x, y) to (x + kx*y, ky*x + y).
+func Shearing(kx, ky float64) Affine {
+	return Affine{1, kx, 0, ky, 1, 0}
+}
+
+// NewAffine returns the transformation that scales points, then shears them
+// along X, then rotates them, then translates them. It's the inverse of
+// Decompose.
+func NewAffine(translation Vec, angle float64, scale Vec, shear float64) Affine {
+	return Translation(translation).
+		Mul(Rotation(angle)).
+		Mul(Shearing(shear, 0)).
+		Mul(Scaling(scale.X, scale.Y))
+}
+
+// Mul returns the matrix product of a and b, that is the transformation
+// applying b, then a.
+func (a Affine) Mul(b Affine) Affine {
+	return Affine{
+		a[0]*b[0] + a[1]*b[3],
+		a[0]*b[1] + a[1]*b[4],
+		a[0]*b[2] + a[1]*b[5] + a[2],
+		a[3]*b[0] + a[4]*b[3],
+		a[3]*b[1] + a[4]*b[4],
+		a[3]*b[2] + a[4]*b[5] + a[5],
+	}
+}
+
+// Translate returns the transformation applying a, then translating by v.
+func (a Affine) Translate(v Vec) Affine {
+	return Translation(v).Mul(a)
+}
+
+// Rotate returns the transformation applying a, then rotating by angle
+// radians around the origin.
+func (a Affine) Rotate(angle float64) Affine {
+	return Rotation(angle).Mul(a)
+}
+
+// Scale returns the transformation applying a, then scaling by sx along X
+// and by sy along Y.
+func (a Affine) Scale(sx, sy float64) Affine {
+	return Scaling(sx, sy).Mul(a)
+}
+
+// Shear returns the transformation applying a, then shearing by kx along X
+// and by ky along Y.
+func (a Affine) Shear(kx, ky float64) Affine {
+	return Shearing(kx, ky).Mul(a)
+}
+
+// Det returns the determinant of a, which is the factor by which a multiplies
+// areas. It is negative if a reverses orientations.
+func (a Affine) Det() float64 {
+	return a[0]*a[4] - a[1]*a[3]
+}
+
+// Invert returns the inverse of a, and false if a can't be inverted because
+// its determinant is null.
+func (a Affine) Invert() (Affine, bool) {
+	det := a.Det()
+	if det == 0 {
+		return Affine{}, false
+	}
+	inv := Affine{
+		a[4] / det,
+		-a[1] / det,
+		0,
+		-a[3] / det,
+		a[0] / det,
+		0,
+	}
+	inv[2] = -(inv[0]*a[2] + inv[1]*a[5])
+	inv[5] = -(inv[3]*a[2] + inv[4]*a[5])
+	return inv, true
+}
+
+// Decompose decomposes a into a scaling, followed by a shearing along X, a
+// rotation and a translation, so that
+//
+//	NewAffine(a.Decompose()) == a
+//
+// up to rounding errors. The scale along X is never negative, a reflection
+// being expressed by a negative scale along Y.
+func (a Affine) Decompose() (translation Vec, angle float64, scale Vec, shear float64) {
+	translation = Vec{a[2], a[5]}
+	scale.X = math.Hypot(a[0], a[3])
+	if scale.X == 0 {
+		// the X axis collapses, the Y axis gives the rotation
+		scale.Y = math.Hypot(a[1], a[4])
+		angle = math.Atan2(-a[1], a[4])
+		return
+	}
+	angle = math.Atan2(a[3], a[0])
+	scale.Y = a.Det() / scale.X
+	if scale.Y != 0 {
+		shear = (a[0]*a[1] + a[3]*a[4]) / (scale.X * scale.Y)
+	}
+	return
+}
+
+// Apply returns the point v transformed by a.
+func (a Affine) Apply(v Vec) Vec {
+	return Vec{
+		a[0]*v.X + a[1]*v.Y + a[2],
+		a[3]*v.X + a[4]*v.Y + a[5],
+	}
+}
+
+// ApplyVector returns the vector v transformed by a, ignoring the
+// translation, as is done for directions and displacements.
+func (a Affine) ApplyVector(v Vec) Vec {
+	return Vec{
+		a[0]*v.X + a[1]*v.Y,
+		a[3]*v.X + a[4]*v.Y,
+	}
+}
+
+// ApplyPath returns a copy of p, with its points transformed by a.
+func (a Affine) ApplyPath(p Path) Path {
+	if p == nil {
+		return nil
+	}
+	res := make(Path, len(p))
+	for i, v := range p {
+		res[i] = a.Apply(v)
+	}
+	return res
+}
+
+// ApplyPathInPlace transforms the points of p by a.
+func (a Affine) ApplyPathInPlace(p Path) {
+	for i, v := range p {
+		p[i] = a.Apply(v)
+	}
+}
+
+// ApplyRect returns the smallest rectangle containing r transformed by a.
+func (a Affine) ApplyRect(r Rectangle) Rectangle {
+	return a.ApplyPath(Path{
+		r.Min,
+		{r.Max.X, r.Min.Y},
+		r.Max,
+		{r.Min.X, r.Max.Y},
+	}).Rectangle()
+}
+
+// String returns a string representation of a like "[1,0,0;0,1,0]".
+func (a Affine) String() string {
+	return fmt.Sprintf("[%v,%v,%v;%v,%v,%v]", a[0], a[1], a[2], a[3], a[4], a[5])
+}
@@ -0,0 +1,154 @@
+package d2
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func affineApprox(a, b Affine) bool {
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-9 {
+			return false
+		}
+	}
+	return true
+}
+
+func TestAffineApply(t *testing.T) {
+	var tests = []struct {
+		name string
+		a    Affine
+		v    Vec
+		want Vec
+	}{
+		{"identity", IdentityAffine, Vec{1, 2}, Vec{1, 2}},
+		{"translation", Translation(Vec{3, -1}), Vec{1, 2}, Vec{4, 1}},
+		{"rotation", Rotation(math.Pi / 2), Vec{1, 2}, Vec{-2, 1}},
+		{"rotation around", RotationAround(math.Pi, Vec{1, 1}), Vec{2, 3}, Vec{0, -1}},
+		{"scaling", Scaling(2, -3), Vec{1, 2}, Vec{2, -6}},
+		{"shearing", Shearing(1, 0.5), Vec{1, 2}, Vec{3, 2.5}},
+		{"chained", IdentityAffine.Scale(2, 2).Rotate(math.Pi / 2).Translate(Vec{1, 0}), Vec{1, 0}, Vec{1, 2}},
+		{"composed", Translation(Vec{1, 0}).Mul(Rotation(math.Pi / 2)).Mul(Scaling(2, 2)), Vec{1, 0}, Vec{1, 2}},
+		{"shear method", IdentityAffine.Translate(Vec{0, 1}).Shear(2, 0), Vec{0, 0}, Vec{2, 1}},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Apply(tt.v); !got.ApproxEpsilon(tt.want, 1e-12) {
+			t.Errorf("%s: %v.Apply(%v) = %v, want %v", tt.name, tt.a, tt.v, got, tt.want)
+		}
+	}
+
+	a := Translation(Vec{5, 5}).Mul(Rotation(math.Pi / 2))
+	if got := a.ApplyVector(Vec{1, 0}); !got.ApproxEpsilon(Vec{0, 1}, 1e-12) {
+		t.Errorf("ApplyVector((1,0)) = %v, want (0,1)", got)
+	}
+}
+
+func TestAffineInvert(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		var a Affine
+		for j := range a {
+			a[j] = rng.Float64()*4 - 2
+		}
+		inv, ok := a.Invert()
+		if !ok {
+			t.Fatalf("%v.Invert() failed", a)
+		}
+		if got := a.Mul(inv); !affineApprox(got, IdentityAffine) {
+			t.Errorf("%v * %v = %v, want identity", a, inv, got)
+		}
+		if got := inv.Mul(a); !affineApprox(got, IdentityAffine) {
+			t.Errorf("%v * %v = %v, want identity", inv, a, got)
+		}
+		if got, want := inv.Det(), 1/a.Det(); math.Abs(got-want) > 1e-9*math.Abs(want) {
+			t.Errorf("%v.Det() = %v, want %v", inv, got, want)
+		}
+	}
+	if _, ok := Scaling(1, 0).Invert(); ok {
+		t.Errorf("Invert() of a singular matrix succeeded")
+	}
+}
+
+func TestAffineDecompose(t *testing.T) {
+	var tests = []struct {
+		tr    Vec
+		angle float64
+		scale Vec
+		shear float64
+	}{
+		{Vec{0, 0}, 0, Vec{1, 1}, 0},
+		{Vec{1, 2}, 0.5, Vec{2, 3}, 0},
+		{Vec{-1, 2}, -2, Vec{0.5, -3}, 0.7},
+		{Vec{3, 4}, 1, Vec{2, 0}, 0},
+		{Vec{3, 4}, 1, Vec{0, 2}, 0},
+	}
+	for _, tt := range tests {
+		a := NewAffine(tt.tr, tt.angle, tt.scale, tt.shear)
+		tr, angle, scale, shear := a.Decompose()
+		if !tr.ApproxEpsilon(tt.tr, 1e-12) || math.Abs(angle-tt.angle) > 1e-9 ||
+			!scale.ApproxEpsilon(tt.scale, 1e-9) || math.Abs(shear-tt.shear) > 1e-9 {
+			t.Errorf("%v.Decompose() = %v, %v, %v, %v, want %v, %v, %v, %v",
+				a, tr, angle, scale, shear, tt.tr, tt.angle, tt.scale, tt.shear)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		var a Affine
+		for j := range a {
+			a[j] = rng.Float64()*4 - 2
+		}
+		if got := NewAffine(a.Decompose()); !affineApprox(got, a) {
+			t.Errorf("NewAffine(%v.Decompose()) = %v", a, got)
+		}
+	}
+}
+
+func TestAffineApplyPath(t *testing.T) {
+	p := Path{{0, 0}, {1, 0}, {1, 1}}
+	a := Translation(Vec{1, 1})
+	want := Path{{1, 1}, {2, 1}, {2, 2}}
+
+	got := a.ApplyPath(p)
+	if p[0] != (Vec{0, 0}) {
+		t.Errorf("ApplyPath modified its argument: %v", p)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("ApplyPath(%v) = %v, want %v", p, got, want)
+			break
+		}
+	}
+	if got := a.ApplyPath(nil); got != nil {
+		t.Errorf("ApplyPath(nil) = %v, want nil", got)
+	}
+
+	a.ApplyPathInPlace(p)
+	for i := range want {
+		if p[i] != want[i] {
+			t.Errorf("ApplyPathInPlace gives %v, want %v", p, want)
+			break
+		}
+	}
+}
+
+func TestAffineApplyRect(t *testing.T) {
+	r := Rect(0, 0, 2, 1)
+	var tests = []struct {
+		a    Affine
+		want Rectangle
+	}{
+		{IdentityAffine, r},
+		{Translation(Vec{1, 1}), Rect(1, 1, 3, 2)},
+		{Rotation(math.Pi / 2), Rect(-1, 0, 0, 2)},
+		{Rotation(math.Pi / 4), Rect(-math.Sqrt2/2, 0, math.Sqrt2, 3*math.Sqrt2/2)},
+		{Scaling(-1, 2), Rect(-2, 0, 0, 2)},
+	}
+	for _, tt := range tests {
+		got := tt.a.ApplyRect(r)
+		if !got.Min.ApproxEpsilon(tt.want.Min, 1e-12) || !got.Max.ApproxEpsilon(tt.want.Max, 1e-12) {
+			t.Errorf("%v.ApplyRect(%v) = %v, want %v", tt.a, r, got, tt.want)
+		}
+	}
+}
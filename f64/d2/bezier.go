@@ -0,0 +1,467 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/arl/gogeo/f64"
+)
+
+// A QuadBezier is a quadratic Bézier curve, going from P0 to P2, with P1 as
+// control point. Its points are parametrized by t in [0, 1].
+type QuadBezier struct {
+	P0, P1, P2 Vec
+}
+
+// A CubicBezier is a cubic Bézier curve, going from P0 to P3, with P1 and P2
+// as control points. Its points are parametrized by t in [0, 1].
+type CubicBezier struct {
+	P0, P1, P2, P3 Vec
+}
+
+// A BezierIntersection is an intersection point between a Bézier curve and
+// another curve or a segment.
+type BezierIntersection struct {
+	Point Vec
+	T     float64 // parameter of Point on the first curve
+	U     float64 // parameter of Point on the other curve, or segment
+}
+
+// bezierMaxDepth is the maximum number of subdivisions of the curves during
+// flattening and intersection.
+const bezierMaxDepth = 32
+
+// bezierEps is the precision, relative to the size of the curves, of the
+// computations that rely on subdivision.
+const bezierEps = 1e-9
+
+// lerp returns the point at parameter t along the segment a, b.
+func lerp(a, b Vec, t float64) Vec {
+	return a.Add(b.Sub(a).Mul(t))
+}
+
+// At returns the point of q at parameter t.
+func (q QuadBezier) At(t float64) Vec {
+	mt := 1 - t
+	return q.P0.Mul(mt * mt).Add(q.P1.Mul(2 * mt * t)).Add(q.P2.Mul(t * t))
+}
+
+// Derivative returns the derivative of q at parameter t, that is its tangent
+// vector.
+func (q QuadBezier) Derivative(t float64) Vec {
+	return lerp(q.P1.Sub(q.P0), q.P2.Sub(q.P1), t).Mul(2)
+}
+
+// SecondDerivative returns the second derivative of q, which is constant.
+func (q QuadBezier) SecondDerivative() Vec {
+	return q.P2.Sub(q.P1.Mul(2)).Add(q.P0).Mul(2)
+}
+
+// Split splits q at parameter t, using the de Casteljau algorithm, and returns
+// the curves going from q.P0 to q.At(t) and from q.At(t) to q.P2.
+func (q QuadBezier) Split(t float64) (QuadBezier, QuadBezier) {
+	a, b := lerp(q.P0, q.P1, t), lerp(q.P1, q.P2, t)
+	m := lerp(a, b, t)
+	return QuadBezier{q.P0, a, m}, QuadBezier{m, b, q.P2}
+}
+
+// Reverse returns the curve going from q.P2 to q.P0.
+func (q QuadBezier) Reverse() QuadBezier {
+	return QuadBezier{q.P2, q.P1, q.P0}
+}
+
+// Cubic returns the cubic Bézier curve identical to q, having the same
+// parametrization.
+func (q QuadBezier) Cubic() CubicBezier {
+	return CubicBezier{q.P0, lerp(q.P0, q.P1, 2.0/3), lerp(q.P2, q.P1, 2.0/3), q.P2}
+}
+
+// Rectangle returns the smallest rectangle containing q.
+//
+// Note that a Rectangle Max bound is exclusive, so the rightmost and lowest
+// points of q are not In the returned rectangle.
+func (q QuadBezier) Rectangle() Rectangle {
+	return q.Cubic().Rectangle()
+}
+
+// Length returns the arc length of q.
+func (q QuadBezier) Length() float64 {
+	return q.Cubic().Length()
+}
+
+// Project returns the parameter t of the point of q that is the closest to p,
+// so that q.At(t) == q.ClosestPoint(p).
+func (q QuadBezier) Project(p Vec) float64 {
+	return q.Cubic().Project(p)
+}
+
+// ClosestPoint returns the point of q that is the closest to p.
+func (q QuadBezier) ClosestPoint(p Vec) Vec {
+	return q.At(q.Project(p))
+}
+
+// Dist returns the shortest distance between p and any point of q.
+func (q QuadBezier) Dist(p Vec) float64 {
+	return p.Dist(q.ClosestPoint(p))
+}
+
+// IntersectSegment returns the intersections of q and s, by increasing
+// parameter along q. U is the parameter of the intersection along s.
+func (q QuadBezier) IntersectSegment(s Segment) []BezierIntersection {
+	return q.Cubic().IntersectSegment(s)
+}
+
+// IntersectQuad returns the intersections of q and q2, by increasing
+// parameter along q.
+func (q QuadBezier) IntersectQuad(q2 QuadBezier) []BezierIntersection {
+	return q.Cubic().IntersectCubic(q2.Cubic())
+}
+
+// IntersectCubic returns the intersections of q and c, by increasing
+// parameter along q.
+func (q QuadBezier) IntersectCubic(c CubicBezier) []BezierIntersection {
+	return q.Cubic().IntersectCubic(c)
+}
+
+// Flatten returns the polyline approximating q, so that no point of q is
+// further than tolerance from it. Its points are on q, the first and last
+// ones being q.P0 and q.P2.
+func (q QuadBezier) Flatten(tolerance float64) Path {
+	return q.Cubic().Flatten(tolerance)
+}
+
+// String returns a string representation of q like "Q[(0,0),(1,2),(2,0)]".
+func (q QuadBezier) String() string {
+	return fmt.Sprintf("Q[%v,%v,%v]", q.P0, q.P1, q.P2)
+}
+
+// At returns the point of c at parameter t.
+func (c CubicBezier) At(t float64) Vec {
+	mt := 1 - t
+	return c.P0.Mul(mt * mt * mt).
+		Add(c.P1.Mul(3 * mt * mt * t)).
+		Add(c.P2.Mul(3 * mt * t * t)).
+		Add(c.P3.Mul(t * t * t))
+}
+
+// Derivative returns the derivative of c at parameter t, that is its tangent
+// vector.
+func (c CubicBezier) Derivative(t float64) Vec {
+	return QuadBezier{c.P1.Sub(c.P0), c.P2.Sub(c.P1), c.P3.Sub(c.P2)}.At(t).Mul(3)
+}
+
+// SecondDerivative returns the second derivative of c at parameter t.
+func (c CubicBezier) SecondDerivative(t float64) Vec {
+	a := c.P2.Sub(c.P1.Mul(2)).Add(c.P0)
+	b := c.P3.Sub(c.P2.Mul(2)).Add(c.P1)
+	return lerp(a, b, t).Mul(6)
+}
+
+// Split splits c at parameter t, using the de Casteljau algorithm, and returns
+// the curves going from c.P0 to c.At(t) and from c.At(t) to c.P3.
+func (c CubicBezier) Split(t float64) (CubicBezier, CubicBezier) {
+	a, b, d := lerp(c.P0, c.P1, t), lerp(c.P1, c.P2, t), lerp(c.P2, c.P3, t)
+	ab, bd := lerp(a, b, t), lerp(b, d, t)
+	m := lerp(ab, bd, t)
+	return CubicBezier{c.P0, a, ab, m}, CubicBezier{m, bd, d, c.P3}
+}
+
+// Reverse returns the curve going from c.P3 to c.P0.
+func (c CubicBezier) Reverse() CubicBezier {
+	return CubicBezier{c.P3, c.P2, c.P1, c.P0}
+}
+
+// points returns the control points of c.
+func (c CubicBezier) points() Path {
+	return Path{c.P0, c.P1, c.P2, c.P3}
+}
+
+// Rectangle returns the smallest rectangle containing c.
+//
+// Note that a Rectangle Max bound is exclusive, so the rightmost and lowest
+// points of c are not In the returned rectangle.
+func (c CubicBezier) Rectangle() Rectangle {
+	pts := Path{c.P0, c.P3}
+	// the extrema are at the end points or where a coordinate of the
+	// derivative is null.
+	d0, d1, d2 := c.P1.Sub(c.P0), c.P2.Sub(c.P1), c.P3.Sub(c.P2)
+	roots := append(
+		solveQuadratic(d0.X-2*d1.X+d2.X, 2*(d1.X-d0.X), d0.X),
+		solveQuadratic(d0.Y-2*d1.Y+d2.Y, 2*(d1.Y-d0.Y), d0.Y)...)
+	for _, t := range roots {
+		if t > 0 && t < 1 {
+			pts = append(pts, c.At(t))
+		}
+	}
+	return pts.Rectangle()
+}
+
+// Length returns the arc length of c.
+func (c CubicBezier) Length() float64 {
+	tol := bezierEps * (c.P0.Dist(c.P1) + c.P1.Dist(c.P2) + c.P2.Dist(c.P3))
+	return integrate(func(t float64) float64 { return c.Derivative(t).Len() }, 0, 1, tol)
+}
+
+// Project returns the parameter t of the point of c that is the closest to p,
+// so that c.At(t) == c.ClosestPoint(p).
+func (c CubicBezier) Project(p Vec) float64 {
+	// the distance to p is minimal at the end points or where its derivative,
+	// proportional to f, goes from negative to positive. f is sampled to
+	// find such intervals, then its roots are refined by Newton iterations,
+	// falling back to bisection.
+	f := func(t float64) float64 { return c.At(t).Sub(p).Dot(c.Derivative(t)) }
+	df := func(t float64) float64 {
+		d := c.Derivative(t)
+		return d.Dot(d) + c.At(t).Sub(p).Dot(c.SecondDerivative(t))
+	}
+
+	best, dbest := 0.0, p.Dist(c.P0)
+	if d := p.Dist(c.P3); d < dbest {
+		best, dbest = 1, d
+	}
+	const n = 32
+	prev := f(0)
+	for i := 1; i <= n; i++ {
+		lo, hi := float64(i-1)/n, float64(i)/n
+		cur := f(hi)
+		if prev <= 0 && cur >= 0 {
+			t := lo
+			for it := 0; it < 50 && hi-lo > 1e-15; it++ {
+				ft := f(t)
+				if ft < 0 {
+					lo = t
+				} else {
+					hi = t
+				}
+				// Newton step, or bisection if it leaves the bracket
+				t -= ft / df(t)
+				if !(t > lo && t < hi) {
+					t = (lo + hi) / 2
+				}
+			}
+			if d := p.Dist(c.At(t)); d < dbest {
+				best, dbest = t, d
+			}
+		}
+		prev = cur
+	}
+	return best
+}
+
+// ClosestPoint returns the point of c that is the closest to p.
+func (c CubicBezier) ClosestPoint(p Vec) Vec {
+	return c.At(c.Project(p))
+}
+
+// Dist returns the shortest distance between p and any point of c.
+func (c CubicBezier) Dist(p Vec) float64 {
+	return p.Dist(c.ClosestPoint(p))
+}
+
+// IntersectSegment returns the intersections of c and s, by increasing
+// parameter along c. U is the parameter of the intersection along s.
+//
+// If c overlaps s, the end points of the overlapping parts are returned.
+func (c CubicBezier) IntersectSegment(s Segment) []BezierIntersection {
+	dir := s.Vec()
+	l2 := dir.Dot(dir)
+	tol := bezierEps * extent(append(c.points(), s.A, s.B))
+	if l2 == 0 {
+		if t := c.Project(s.A); c.At(t).Dist(s.A) <= tol {
+			return []BezierIntersection{{Point: c.At(t), T: t}}
+		}
+		return nil
+	}
+
+	var res []BezierIntersection
+	add := func(t float64) {
+		t = f64.Clamp(t, 0, 1)
+		p := c.At(t)
+		u := p.Sub(s.A).Dot(dir) / l2
+		if u < -bezierEps || u > 1+bezierEps {
+			return
+		}
+		res = append(res, BezierIntersection{Point: p, T: t, U: f64.Clamp(u, 0, 1)})
+	}
+
+	// signed distances of the control points to the line of s, times |dir|
+	n := Vec{-dir.Y, dir.X}
+	d0, d1, d2, d3 := c.P0.Sub(s.A).Dot(n), c.P1.Sub(s.A).Dot(n), c.P2.Sub(s.A).Dot(n), c.P3.Sub(s.A).Dot(n)
+	if ltol := tol * math.Sqrt(l2); math.Abs(d0) <= ltol && math.Abs(d1) <= ltol && math.Abs(d2) <= ltol && math.Abs(d3) <= ltol {
+		// c lies on the line of s
+		for _, t := range []float64{0, 1, c.Project(s.A), c.Project(s.B)} {
+			add(t)
+		}
+		return dedupIntersections(res, tol)
+	}
+	roots := solveCubic(-d0+3*d1-3*d2+d3, 3*d0-6*d1+3*d2, 3*(d1-d0), d0)
+	for _, t := range roots {
+		if t >= -bezierEps && t <= 1+bezierEps {
+			add(t)
+		}
+	}
+	return dedupIntersections(res, tol)
+}
+
+// IntersectQuad returns the intersections of c and q, by increasing
+// parameter along c.
+func (c CubicBezier) IntersectQuad(q QuadBezier) []BezierIntersection {
+	return c.IntersectCubic(q.Cubic())
+}
+
+// IntersectCubic returns the intersections of c and c2, by increasing
+// parameter along c.
+//
+// They are found by recursively splitting the curves until they're flat
+// enough to be considered as segments, so they're only approximated, with a
+// precision relative to the size of the curves.
+//
+// If c overlaps c2, the end points of the overlapping part are returned.
+func (c CubicBezier) IntersectCubic(c2 CubicBezier) []BezierIntersection {
+	tol := bezierEps * extent(append(c.points(), c2.points()...))
+	if res := c.overlap(c2, tol); res != nil {
+		return res
+	}
+
+	var res []BezierIntersection
+	var rec func(a, b bezierPart, depth int)
+	rec = func(a, b bezierPart, depth int) {
+		ra, rb := a.c.points().Rectangle(), b.c.points().Rectangle()
+		if ra.Min.X > rb.Max.X+tol || rb.Min.X > ra.Max.X+tol ||
+			ra.Min.Y > rb.Max.Y+tol || rb.Min.Y > ra.Max.Y+tol {
+			return
+		}
+		fa, fb := a.c.flatness(), b.c.flatness()
+		if depth == 2*bezierMaxDepth || fa <= tol && fb <= tol {
+			sa, sb := Segment{a.c.P0, a.c.P3}, Segment{b.c.P0, b.c.P3}
+			kind, is := sa.Intersect(sb)
+			if kind == NoIntersection {
+				return
+			}
+			p := is.A
+			res = append(res, BezierIntersection{
+				Point: p,
+				T:     a.t0 + sa.Project(p)*(a.t1-a.t0),
+				U:     b.t0 + sb.Project(p)*(b.t1-b.t0),
+			})
+			return
+		}
+		// split the least flat curve
+		if fa >= fb {
+			a1, a2 := a.split()
+			rec(a1, b, depth+1)
+			rec(a2, b, depth+1)
+		} else {
+			b1, b2 := b.split()
+			rec(a, b1, depth+1)
+			rec(a, b2, depth+1)
+		}
+	}
+	rec(bezierPart{c, 0, 1}, bezierPart{c2, 0, 1}, 0)
+	return dedupIntersections(res, 1e3*tol)
+}
+
+// overlap returns the end points of the part of c that lies on c2, up to
+// tol, or nil if the curves don't overlap.
+func (c CubicBezier) overlap(c2 CubicBezier, tol float64) []BezierIntersection {
+	// the ends of the overlapping part are end points of the curves
+	var ends []BezierIntersection
+	for _, p := range []Vec{c.P0, c.P3, c2.P0, c2.P3} {
+		t, u := c.Project(p), c2.Project(p)
+		if c.At(t).Dist(p) <= tol && c2.At(u).Dist(p) <= tol {
+			ends = append(ends, BezierIntersection{Point: c.At(t), T: t, U: u})
+		}
+	}
+	ends = dedupIntersections(ends, tol)
+	if len(ends) < 2 {
+		return nil
+	}
+	ends = []BezierIntersection{ends[0], ends[len(ends)-1]}
+
+	// distinct cubic curves have at most 9 common points, so c2 contains
+	// the part of c between the ends if it contains more of its points.
+	const n = 16
+	t0, t1 := ends[0].T, ends[1].T
+	for i := 1; i < n; i++ {
+		if c2.Dist(c.At(t0+(t1-t0)*float64(i)/n)) > tol {
+			return nil
+		}
+	}
+	return ends
+}
+
+// dedupIntersections sorts the intersections by increasing T and removes
+// those closer than tol to the previous one.
+func dedupIntersections(is []BezierIntersection, tol float64) []BezierIntersection {
+	sort.Slice(is, func(i, j int) bool { return is[i].T < is[j].T })
+	var res []BezierIntersection
+	for _, in := range is {
+		if len(res) > 0 && res[len(res)-1].Point.Dist(in.Point) <= tol {
+			continue
+		}
+		res = append(res, in)
+	}
+	return res
+}
+
+// extent returns the largest dimension of the rectangle containing p.
+func extent(p Path) float64 {
+	r := p.Rectangle()
+	return math.Max(r.Dx(), r.Dy())
+}
+
+// A bezierPart is the part of a cubic Bézier curve between the parameters t0
+// and t1.
+type bezierPart struct {
+	c      CubicBezier
+	t0, t1 float64
+}
+
+// split splits the part at its middle.
+func (p bezierPart) split() (bezierPart, bezierPart) {
+	c1, c2 := p.c.Split(0.5)
+	m := (p.t0 + p.t1) / 2
+	return bezierPart{c1, p.t0, m}, bezierPart{c2, m, p.t1}
+}
+
+// flatness returns the maximum distance of the control points of c to the
+// segment joining its end points, which is also a bound of the distance of
+// the points of c to that segment.
+func (c CubicBezier) flatness() float64 {
+	s := Segment{c.P0, c.P3}
+	return math.Max(s.Dist(c.P1), s.Dist(c.P2))
+}
+
+// Flatten returns the polyline approximating c, so that no point of c is
+// further than tolerance from it. Its points are on c, the first and last
+// ones being c.P0 and c.P3.
+//
+// The curve is recursively split until its parts are flat enough, so the
+// returned points are more dense where c is more curved.
+func (c CubicBezier) Flatten(tolerance float64) Path {
+	tolerance = math.Max(tolerance, bezierEps*extent(c.points()))
+	p := Path{c.P0}
+	var rec func(c CubicBezier, depth int)
+	rec = func(c CubicBezier, depth int) {
+		if depth == bezierMaxDepth || c.flatness() <= tolerance {
+			p = append(p, c.P3)
+			return
+		}
+		c1, c2 := c.Split(0.5)
+		rec(c1, depth+1)
+		rec(c2, depth+1)
+	}
+	rec(c, 0)
+	return p
+}
+
+// String returns a string representation of c like
+// "C[(0,0),(1,2),(2,2),(3,0)]".
+func (c CubicBezier) String() string {
+	return fmt.Sprintf("C[%v,%v,%v,%v]", c.P0, c.P1, c.P2, c.P3)
+}
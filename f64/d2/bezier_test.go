@@ -0,0 +1,335 @@
+package d2
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// arch is a cubic curve going from (0,0) to (1,0), up to y = 0.75.
+var arch = CubicBezier{Vec{0, 0}, Vec{0, 1}, Vec{1, 1}, Vec{1, 0}}
+
+// randomCubic returns a random cubic curve in [0,10]x[0,10].
+func randomCubic(rng *rand.Rand) CubicBezier {
+	var pts [4]Vec
+	for i := range pts {
+		pts[i] = Vec{rng.Float64() * 10, rng.Float64() * 10}
+	}
+	return CubicBezier{pts[0], pts[1], pts[2], pts[3]}
+}
+
+func TestBezierAt(t *testing.T) {
+	q := QuadBezier{Vec{0, 0}, Vec{1, 2}, Vec{2, 0}}
+	var tests = []struct {
+		t          float64
+		quad, cub  Vec
+		dquad, dcb Vec
+	}{
+		{0, Vec{0, 0}, Vec{0, 0}, Vec{2, 4}, Vec{0, 3}},
+		{0.5, Vec{1, 1}, Vec{0.5, 0.75}, Vec{2, 0}, Vec{1.5, 0}},
+		{1, Vec{2, 0}, Vec{1, 0}, Vec{2, -4}, Vec{0, -3}},
+	}
+	for _, tt := range tests {
+		if got := q.At(tt.t); !got.ApproxEpsilon(tt.quad, 1e-12) {
+			t.Errorf("%v.At(%v) = %v, want %v", q, tt.t, got, tt.quad)
+		}
+		if got := arch.At(tt.t); !got.ApproxEpsilon(tt.cub, 1e-12) {
+			t.Errorf("%v.At(%v) = %v, want %v", arch, tt.t, got, tt.cub)
+		}
+		if got := q.Derivative(tt.t); !got.ApproxEpsilon(tt.dquad, 1e-12) {
+			t.Errorf("%v.Derivative(%v) = %v, want %v", q, tt.t, got, tt.dquad)
+		}
+		if got := arch.Derivative(tt.t); !got.ApproxEpsilon(tt.dcb, 1e-12) {
+			t.Errorf("%v.Derivative(%v) = %v, want %v", arch, tt.t, got, tt.dcb)
+		}
+	}
+	if got := q.SecondDerivative(); got != (Vec{0, -8}) {
+		t.Errorf("%v.SecondDerivative() = %v, want (0,-8)", q, got)
+	}
+
+	// derivatives match finite differences, and the cubic form of the
+	// quadratic curve has the same parametrization.
+	rng := rand.New(rand.NewSource(1))
+	const h = 1e-6
+	for i := 0; i < 20; i++ {
+		c := randomCubic(rng)
+		tt := rng.Float64()
+		fd := c.At(tt + h).Sub(c.At(tt - h)).Div(2 * h)
+		if d := c.Derivative(tt); !d.ApproxEpsilon(fd, 1e-6) {
+			t.Errorf("%v.Derivative(%v) = %v, finite difference gives %v", c, tt, d, fd)
+		}
+		fd2 := c.Derivative(tt + h).Sub(c.Derivative(tt - h)).Div(2 * h)
+		if d := c.SecondDerivative(tt); !d.ApproxEpsilon(fd2, 1e-6) {
+			t.Errorf("%v.SecondDerivative(%v) = %v, finite difference gives %v", c, tt, d, fd2)
+		}
+		q := QuadBezier{c.P0, c.P1, c.P2}
+		if a, b := q.At(tt), q.Cubic().At(tt); !a.ApproxEpsilon(b, 1e-12) {
+			t.Errorf("%v.At(%v) = %v, its cubic form gives %v", q, tt, a, b)
+		}
+	}
+}
+
+func TestBezierSplit(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		c := randomCubic(rng)
+		q := QuadBezier{c.P0, c.P1, c.P2}
+		ts := rng.Float64()
+		c1, c2 := c.Split(ts)
+		q1, q2 := q.Split(ts)
+		for _, u := range []float64{0, 0.3, 1} {
+			if a, b := c1.At(u), c.At(u*ts); !a.ApproxEpsilon(b, 1e-12) {
+				t.Errorf("%v.Split(%v): first part at %v = %v, want %v", c, ts, u, a, b)
+			}
+			if a, b := c2.At(u), c.At(ts+u*(1-ts)); !a.ApproxEpsilon(b, 1e-12) {
+				t.Errorf("%v.Split(%v): second part at %v = %v, want %v", c, ts, u, a, b)
+			}
+			if a, b := q1.At(u), q.At(u*ts); !a.ApproxEpsilon(b, 1e-12) {
+				t.Errorf("%v.Split(%v): first part at %v = %v, want %v", q, ts, u, a, b)
+			}
+			if a, b := q2.At(u), q.At(ts+u*(1-ts)); !a.ApproxEpsilon(b, 1e-12) {
+				t.Errorf("%v.Split(%v): second part at %v = %v, want %v", q, ts, u, a, b)
+			}
+		}
+		if a, b := c.Reverse().At(0.2), c.At(0.8); !a.ApproxEpsilon(b, 1e-12) {
+			t.Errorf("%v.Reverse().At(0.2) = %v, want %v", c, a, b)
+		}
+	}
+}
+
+func TestBezierRectangle(t *testing.T) {
+	var tests = []struct {
+		r    Rectangler
+		want Rectangle
+	}{
+		{arch, Rect(0, 0, 1, 0.75)},
+		{QuadBezier{Vec{0, 0}, Vec{1, 2}, Vec{2, 0}}, Rect(0, 0, 2, 1)},
+		{QuadBezier{Vec{0, 0}, Vec{1, 1}, Vec{2, 2}}, Rect(0, 0, 2, 2)},
+		{CubicBezier{Vec{0, 0}, Vec{2, 0}, Vec{-1, 0}, Vec{1, 0}}, Rect(0, 0, 1, 0)},
+	}
+	for _, tt := range tests {
+		got := tt.r.Rectangle()
+		if !got.Min.ApproxEpsilon(tt.want.Min, 1e-12) || !got.Max.ApproxEpsilon(tt.want.Max, 1e-12) {
+			t.Errorf("%v.Rectangle() = %v, want %v", tt.r, got, tt.want)
+		}
+	}
+
+	// the rectangle is tight, and contains the curve
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		c := randomCubic(rng)
+		r := c.Rectangle()
+		flat := c.Flatten(1e-9)
+		if fr := flat.Rectangle(); !fr.Min.ApproxEpsilon(r.Min, 1e-6) || !fr.Max.ApproxEpsilon(r.Max, 1e-6) {
+			t.Errorf("%v.Rectangle() = %v, want %v", c, r, fr)
+		}
+	}
+}
+
+func TestBezierLength(t *testing.T) {
+	var tests = []struct {
+		c    CubicBezier
+		want float64
+	}{
+		{CubicBezier{Vec{0, 0}, Vec{1, 0}, Vec{2, 0}, Vec{3, 0}}, 3},
+		{CubicBezier{Vec{0, 0}, Vec{3, 4}, Vec{3, 4}, Vec{3, 4}}, 5},
+		{CubicBezier{Vec{1, 1}, Vec{1, 1}, Vec{1, 1}, Vec{1, 1}}, 0},
+		// cusp
+		{CubicBezier{Vec{0, 0}, Vec{2, 1}, Vec{-1, 1}, Vec{1, 0}}, -1},
+	}
+	for _, tt := range tests {
+		want := tt.want
+		if want < 0 {
+			want = tt.c.Flatten(1e-10).Length()
+		}
+		if got := tt.c.Length(); math.Abs(got-want) > 1e-7 {
+			t.Errorf("%v.Length() = %v, want %v", tt.c, got, want)
+		}
+	}
+
+	// parabola y = x(2-x) for x in [0, 2]
+	q := QuadBezier{Vec{0, 0}, Vec{1, 2}, Vec{2, 0}}
+	want := math.Sqrt(5) + math.Asinh(2)/2
+	if got := q.Length(); math.Abs(got-want) > 1e-9 {
+		t.Errorf("%v.Length() = %v, want %v", q, got, want)
+	}
+}
+
+func TestBezierProject(t *testing.T) {
+	var tests = []struct {
+		p    Vec
+		want float64
+	}{
+		{Vec{0.5, 2}, 0.5},
+		{Vec{0.5, 0.7}, 0.5},
+		{Vec{-1, -1}, 0},
+		{Vec{3, -1}, 1},
+	}
+	for _, tt := range tests {
+		if got := arch.Project(tt.p); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%v.Project(%v) = %v, want %v", arch, tt.p, got, tt.want)
+		}
+	}
+	q := QuadBezier{Vec{0, 0}, Vec{1, 2}, Vec{2, 0}}
+	if got := q.Dist(Vec{1, 3}); math.Abs(got-2) > 1e-9 {
+		t.Errorf("%v.Dist((1,3)) = %v, want 2", q, got)
+	}
+
+	// compare with the closest of many points of the curve
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		c := randomCubic(rng)
+		p := Vec{rng.Float64()*14 - 2, rng.Float64()*14 - 2}
+		dmin := math.Inf(1)
+		for k := 0; k <= 10000; k++ {
+			dmin = math.Min(dmin, p.Dist(c.At(float64(k)/10000)))
+		}
+		if got := c.Dist(p); got > dmin+1e-9 || got < dmin-1e-2 {
+			t.Errorf("%v.Dist(%v) = %v, want %v", c, p, got, dmin)
+		}
+		if got := c.ClosestPoint(p); !got.ApproxEpsilon(c.At(c.Project(p)), 1e-12) {
+			t.Errorf("%v.ClosestPoint(%v) = %v, want %v", c, p, got, c.At(c.Project(p)))
+		}
+	}
+}
+
+// checkIntersections checks that the intersections are on both curves and
+// compares them with want, the expected points.
+func checkIntersections(t *testing.T, name string, got []BezierIntersection, at1, at2 func(float64) Vec, want []Vec) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Errorf("%s: got %d intersections %v, want %v", name, len(got), got, want)
+		return
+	}
+	for i, in := range got {
+		if !in.Point.ApproxEpsilon(want[i], 1e-7) {
+			t.Errorf("%s: intersection %d = %v, want %v", name, i, in.Point, want[i])
+		}
+		if p := at1(in.T); !p.ApproxEpsilon(in.Point, 1e-7) {
+			t.Errorf("%s: intersection %d is at %v on the first curve, not %v", name, i, p, in.Point)
+		}
+		if p := at2(in.U); !p.ApproxEpsilon(in.Point, 1e-7) {
+			t.Errorf("%s: intersection %d is at %v on the second curve, not %v", name, i, p, in.Point)
+		}
+	}
+}
+
+func TestBezierIntersectSegment(t *testing.T) {
+	// x of the points of arch at height 0.5
+	x0 := arch.At((1 - math.Sqrt(1.0/3)) / 2).X
+	var tests = []struct {
+		name string
+		c    CubicBezier
+		s    Segment
+		want []Vec
+	}{
+		{"2 points", arch, Seg(-1, 0.5, 2, 0.5), []Vec{{x0, 0.5}, {1 - x0, 0.5}}},
+		{"reversed", arch, Seg(2, 0.5, -1, 0.5), []Vec{{x0, 0.5}, {1 - x0, 0.5}}},
+		{"1 point", arch, Seg(0.5, 0.5, 2, 0.5), []Vec{{1 - x0, 0.5}}},
+		{"none", arch, Seg(-1, 1, 2, 1), nil},
+		{"tangent", arch, Seg(-1, 0.75, 2, 0.75), []Vec{{0.5, 0.75}}},
+		{"end points", arch, Seg(-1, 0, 2, 0), []Vec{{0, 0}, {1, 0}}},
+		{"vertical", arch, Seg(0.5, -1, 0.5, 1), []Vec{{0.5, 0.75}}},
+		{"point", arch, Seg(0.5, 0.75, 0.5, 0.75), []Vec{{0.5, 0.75}}},
+		{"overlap", CubicBezier{Vec{0, 0}, Vec{1, 0}, Vec{2, 0}, Vec{3, 0}}, Seg(1, 0, 5, 0), []Vec{{1, 0}, {3, 0}}},
+	}
+	for _, tt := range tests {
+		got := tt.c.IntersectSegment(tt.s)
+		checkIntersections(t, tt.name, got, tt.c.At, tt.s.At, tt.want)
+	}
+
+	q := QuadBezier{Vec{0, 0}, Vec{1, 2}, Vec{2, 0}}
+	got := q.IntersectSegment(Seg(0, 0.75, 2, 0.75))
+	checkIntersections(t, "quad", got, q.At, Seg(0, 0.75, 2, 0.75).At, []Vec{{0.5, 0.75}, {1.5, 0.75}})
+}
+
+func TestBezierIntersectCurves(t *testing.T) {
+	// parabolas y = x(2-x) and y = (1-x)², crossing at x = 1 ± √2/2
+	q1 := QuadBezier{Vec{0, 0}, Vec{1, 2}, Vec{2, 0}}
+	q2 := QuadBezier{Vec{0, 1}, Vec{1, -1}, Vec{2, 1}}
+	x1, x2 := 1-math.Sqrt2/2, 1+math.Sqrt2/2
+	want := []Vec{{x1, x1 * (2 - x1)}, {x2, x2 * (2 - x2)}}
+	checkIntersections(t, "quad/quad", q1.IntersectQuad(q2), q1.At, q2.At, want)
+	checkIntersections(t, "quad/cubic", q1.IntersectCubic(q2.Cubic()), q1.At, q2.Cubic().At, want)
+	checkIntersections(t, "cubic/quad", q1.Cubic().IntersectQuad(q2), q1.Cubic().At, q2.At, want)
+
+	// a straight cubic gives the same intersections as a segment
+	line := CubicBezier{Vec{-1, 0.5}, Vec{0, 0.5}, Vec{1, 0.5}, Vec{2, 0.5}}
+	x0 := arch.At((1 - math.Sqrt(1.0/3)) / 2).X
+	checkIntersections(t, "arch/line", arch.IntersectCubic(line), arch.At, line.At, []Vec{{x0, 0.5}, {1 - x0, 0.5}})
+	checkIntersections(t, "line/arch", line.IntersectCubic(arch), line.At, arch.At, []Vec{{x0, 0.5}, {1 - x0, 0.5}})
+
+	// shared end point
+	c := CubicBezier{Vec{1, 0}, Vec{2, 1}, Vec{3, 1}, Vec{4, 0}}
+	checkIntersections(t, "end point", arch.IntersectCubic(c), arch.At, c.At, []Vec{{1, 0}})
+
+	// overlapping curves give the end points of the overlapping part
+	checkIntersections(t, "identical", arch.IntersectCubic(arch), arch.At, arch.At, []Vec{{0, 0}, {1, 0}})
+	rev := arch.Reverse()
+	checkIntersections(t, "reversed", arch.IntersectCubic(rev), arch.At, rev.At, []Vec{{0, 0}, {1, 0}})
+	left, _ := arch.Split(0.6)
+	_, right := arch.Split(0.4)
+	checkIntersections(t, "partial overlap", left.IntersectCubic(right), left.At, right.At, []Vec{arch.At(0.4), arch.At(0.6)})
+	checkIntersections(t, "inner part", arch.IntersectCubic(right), arch.At, right.At, []Vec{arch.At(0.4), {1, 0}})
+	line2 := CubicBezier{Vec{1, 0.5}, Vec{2, 0.5}, Vec{2.5, 0.5}, Vec{3, 0.5}}
+	checkIntersections(t, "straight overlap", line.IntersectCubic(line2), line.At, line2.At, []Vec{{1, 0.5}, {2, 0.5}})
+
+	// self-crossing curves cross each other as many times as they cross their
+	// flattened form
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		c1, c2 := randomCubic(rng), randomCubic(rng)
+		got := c1.IntersectCubic(c2)
+		var want []Vec
+		for _, s := range pathSegments(c2.Flatten(1e-10)) {
+			for _, in := range c1.IntersectSegment(s) {
+				if len(want) == 0 || !want[len(want)-1].ApproxEpsilon(in.Point, 1e-6) {
+					want = append(want, in.Point)
+				}
+			}
+		}
+		if len(got) != len(want) {
+			t.Errorf("%v.IntersectCubic(%v) = %v, want %v", c1, c2, got, want)
+		}
+		for _, in := range got {
+			if p := c1.At(in.T); !p.ApproxEpsilon(in.Point, 1e-7) {
+				t.Errorf("%v.IntersectCubic(%v): %v is at %v on the first curve", c1, c2, in, p)
+			}
+			if p := c2.At(in.U); !p.ApproxEpsilon(in.Point, 1e-7) {
+				t.Errorf("%v.IntersectCubic(%v): %v is at %v on the second curve", c1, c2, in, p)
+			}
+		}
+	}
+}
+
+// pathSegments returns the segments of p.
+func pathSegments(p Path) []Segment {
+	var segs []Segment
+	for i := 0; i < len(p)-1; i++ {
+		segs = append(segs, Segment{p[i], p[i+1]})
+	}
+	return segs
+}
+
+func TestBezierFlatten(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		c := randomCubic(rng)
+		for _, tol := range []float64{1, 0.1, 0.001} {
+			p := c.Flatten(tol)
+			if p[0] != c.P0 || p[len(p)-1] != c.P3 {
+				t.Fatalf("%v.Flatten(%v) doesn't start and end at the curve end points", c, tol)
+			}
+			for k := 0; k <= 1000; k++ {
+				v := c.At(float64(k) / 1000)
+				if d := distToPath(p, v); d > tol {
+					t.Fatalf("%v.Flatten(%v): %v is at %v from the polyline", c, tol, v, d)
+				}
+			}
+		}
+	}
+
+	if got := (QuadBezier{Vec{0, 0}, Vec{1, 1}, Vec{2, 2}}).Flatten(0.1); len(got) != 2 {
+		t.Errorf("flattening a straight curve gives %v", got)
+	}
+}
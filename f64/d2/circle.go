@@ -0,0 +1,39 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import "fmt"
+
+// A Circle is defined by its center and radius.
+type Circle struct {
+	Center Vec
+	Radius float64
+}
+
+// Circ is shorthand for Circle{Vec{x, y}, r}.
+func Circ(x, y, r float64) Circle {
+	return Circle{Vec{x, y}, r}
+}
+
+// Contains reports whether p is inside c or on its boundary.
+func (c Circle) Contains(p Vec) bool {
+	d := p.Sub(c.Center)
+	return d.Dot(d) <= c.Radius*c.Radius
+}
+
+// Rectangle returns the smallest rectangle containing c.
+func (c Circle) Rectangle() Rectangle {
+	return RectFromCircle(c.Center, c.Radius)
+}
+
+// Hit implements the Hitter interface.
+func (c Circle) Hit(r Ray) (RayHit, bool) {
+	return r.HitCircle(c)
+}
+
+// String returns a string representation of c like "(c:(1,2),r:3)".
+func (c Circle) String() string {
+	return fmt.Sprintf("(c:%v,r:%.4g)", c.Center, c.Radius)
+}
@@ -0,0 +1,702 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+)
+
+// ClipOp is a boolean operation between 2 sets of polygons.
+type ClipOp int
+
+const (
+	// Intersection keeps the areas that are inside both operands.
+	Intersection ClipOp = iota
+
+	// Union keeps the areas that are inside any of the operands.
+	Union
+
+	// Difference keeps the areas of the subject that are outside the
+	// clipping operand.
+	Difference
+
+	// Xor keeps the areas that are inside exactly one operand.
+	Xor
+)
+
+func (op ClipOp) String() string {
+	switch op {
+	case Intersection:
+		return "intersection"
+	case Union:
+		return "union"
+	case Difference:
+		return "difference"
+	case Xor:
+		return "xor"
+	}
+	return "unknown"
+}
+
+// Clip performs the boolean operation op between the polygons of subject and
+// those of clipping, using the Martinez-Rueda-Feito sweep line algorithm.
+//
+// The polygons of each operand may have holes and their rings may touch each
+// other. The orientation of the input rings does not matter: overlapping
+// polygons of a same operand are handled as if they were xor-ed. The returned
+// polygons are normalized: their outer rings are counter-clockwise and their
+// holes are clockwise.
+//
+// To make the results robust to rounding errors, vertices that are extremely
+// close to each other, relatively to the size of the operands, are merged.
+func Clip(subject, clipping MultiPolygon, op ClipOp) MultiPolygon {
+	sbox, cbox := mpBounds(subject), mpBounds(clipping)
+
+	// trivial operation
+	if op == Intersection && (len(sbox) == 0 || len(cbox) == 0 ||
+		sbox[0].X > cbox[1].X || cbox[0].X > sbox[1].X ||
+		sbox[0].Y > cbox[1].Y || cbox[0].Y > sbox[1].Y) {
+		return nil
+	}
+
+	// even if the operands don't intersect, the other operations go through
+	// the sweep, that resolves the overlaps between polygons of an operand.
+	subject, clipping = subject.clone(), clipping.clone()
+	box := mpBounds(append(append(MultiPolygon{}, subject...), clipping...))
+	if len(box) == 0 {
+		return nil
+	}
+	snapVertices(append(subject.Rings(), clipping.Rings()...), box[0], box[1])
+
+	var q eventQueue
+	contourID := 0
+	for _, p := range subject {
+		contourID++
+		for _, ring := range p.Rings() {
+			q.pushRing(ring, true, contourID)
+		}
+	}
+	for _, p := range clipping {
+		contourID++
+		for _, ring := range p.Rings() {
+			q.pushRing(ring, false, contourID)
+		}
+	}
+	heap.Init(&q)
+
+	sright, cright := math.Inf(-1), math.Inf(-1)
+	if len(sbox) != 0 {
+		sright = sbox[1].X
+	}
+	if len(cbox) != 0 {
+		cright = cbox[1].X
+	}
+	events := subdivide(&q, sright, cright, op)
+	return connectEdges(events)
+}
+
+// mpBounds returns the min and max corners of the bounding box of mp, or nil
+// if mp has no vertex. Contrary to a Rectangle, both bounds are inclusive.
+func mpBounds(mp MultiPolygon) []Vec {
+	var b []Vec
+	for _, p := range mp {
+		for _, ring := range p.Rings() {
+			for _, v := range ring {
+				if b == nil {
+					b = []Vec{v, v}
+					continue
+				}
+				b[0].X, b[0].Y = math.Min(b[0].X, v.X), math.Min(b[0].Y, v.Y)
+				b[1].X, b[1].Y = math.Max(b[1].X, v.X), math.Max(b[1].Y, v.Y)
+			}
+		}
+	}
+	return b
+}
+
+// vertexSnapEps is the distance, relative to the size of the operands, under
+// which input vertices are merged by snapVertices.
+const vertexSnapEps = 1e-13
+
+// snapVertices merges, in place, the vertices of rings that are closer than a
+// tolerance relative to the size of the bounding box min, max. This way
+// vertices computed by different operations, that only differ by rounding
+// errors, are considered the same.
+func snapVertices(rings []Polygon, min, max Vec) {
+	tol := vertexSnapEps * (max.X - min.X + max.Y - min.Y)
+	var pts []*Vec
+	for _, ring := range rings {
+		for i := range ring {
+			pts = append(pts, &ring[i])
+		}
+	}
+	sort.Slice(pts, func(i, j int) bool { return pts[i].X < pts[j].X })
+	for i, p := range pts {
+		for _, q := range pts[i+1:] {
+			if q.X-p.X > tol {
+				break
+			}
+			if math.Abs(q.Y-p.Y) <= tol {
+				*q = *p
+			}
+		}
+	}
+}
+
+// clone returns a deep copy of mp.
+func (mp MultiPolygon) clone() MultiPolygon {
+	res := make(MultiPolygon, len(mp))
+	for i, p := range mp {
+		res[i].Outer = append(Polygon(nil), p.Outer...)
+		for _, h := range p.Holes {
+			res[i].Holes = append(res[i].Holes, append(Polygon(nil), h...))
+		}
+	}
+	return res
+}
+
+// pushRing adds the events of the edges of ring to q. Null length edges are
+// ignored.
+func (q *eventQueue) pushRing(ring Polygon, isSubject bool, contourID int) {
+	for i := range ring {
+		e := ring.Edge(i)
+		if e.Degenerate() {
+			continue
+		}
+		e1 := &sweepEvent{point: e.A, isSubject: isSubject, contourID: contourID}
+		e2 := &sweepEvent{point: e.B, isSubject: isSubject, contourID: contourID}
+		e1.other, e2.other = e2, e1
+		if compareEvents(e1, e2) > 0 {
+			e2.left = true
+		} else {
+			e1.left = true
+		}
+		*q = append(*q, e1, e2)
+	}
+}
+
+// subdivide runs the sweep line over the events of q, subdividing the edges
+// at their intersections, and computes which edges belong to the result.
+// sright and cright are the right bounds of subject and clipping. It returns
+// the processed events, in the order they have been processed.
+func subdivide(q *eventQueue, sright, cright float64, op ClipOp) []*sweepEvent {
+	var (
+		sl     sweepLine
+		sorted []*sweepEvent
+	)
+	rightBound := math.Min(sright, cright)
+
+	for q.Len() > 0 {
+		e := heap.Pop(q).(*sweepEvent)
+		sorted = append(sorted, e)
+
+		// no more edges can be part of the result
+		if op == Intersection && e.point.X > rightBound ||
+			op == Difference && e.point.X > sright {
+			break
+		}
+
+		if e.left {
+			qlen := q.Len()
+			pos := sl.insert(e)
+			var prev, next *sweepEvent
+			if pos > 0 {
+				prev = sl[pos-1]
+			}
+			if pos < len(sl)-1 {
+				next = sl[pos+1]
+			}
+
+			computeFields(e, prev, op)
+			if next != nil && possibleIntersection(e, next, q) == 2 {
+				computeFields(e, prev, op)
+				computeFields(next, e, op)
+			}
+			if prev != nil && possibleIntersection(prev, e, q) == 2 {
+				var prevprev *sweepEvent
+				if pos := sl.find(prev); pos > 0 {
+					prevprev = sl[pos-1]
+				}
+				computeFields(prev, prevprev, op)
+				computeFields(e, prev, op)
+			}
+			if q.Len() > qlen && compareEvents((*q)[0], e) < 0 {
+				// e lies on the edge of a neighbor that has just been divided
+				// at e.point: process the end of that edge first.
+				sl.remove(sl.find(e))
+				sorted = sorted[:len(sorted)-1]
+				heap.Push(q, e)
+			}
+			continue
+		}
+
+		e = e.other
+		pos := sl.find(e)
+		if pos < 0 {
+			continue
+		}
+		var prev, next *sweepEvent
+		if pos > 0 {
+			prev = sl[pos-1]
+		}
+		if pos < len(sl)-1 {
+			next = sl[pos+1]
+		}
+		// a neighbor passing through the end point of e is divided there, so
+		// that rings of the result only touch each other at their vertices.
+		for _, n := range [2]*sweepEvent{prev, next} {
+			p := e.other.point
+			if n != nil && onSegment(n.point, n.other.point, p, snapTol(n.point, n.other.point, p, p)) {
+				divideSegment(n, p, q)
+			}
+		}
+		sl.remove(pos)
+		if prev != nil && next != nil {
+			possibleIntersection(prev, next, q)
+		}
+	}
+	return sorted
+}
+
+// computeFields computes the in/out fields of the left event e, given prev,
+// the event of the edge immediately below it in the sweep line.
+func computeFields(e, prev *sweepEvent, op ClipOp) {
+	switch {
+	case prev == nil:
+		e.inOut = false
+		e.otherInOut = true
+	case e.isSubject == prev.isSubject:
+		e.inOut = !prev.inOut
+		e.otherInOut = prev.otherInOut
+	default:
+		e.inOut = !prev.otherInOut
+		if prev.isVertical() {
+			e.otherInOut = !prev.inOut
+		} else {
+			e.otherInOut = prev.inOut
+		}
+	}
+
+	e.resultTransition = 0
+	if inResult(e, op) {
+		e.resultTransition = resultTransition(e, op)
+	}
+}
+
+// inResult reports whether the edge of e belongs to the result of op.
+func inResult(e *sweepEvent, op ClipOp) bool {
+	switch e.typ {
+	case edgeNormal:
+		switch op {
+		case Intersection:
+			return !e.otherInOut
+		case Union:
+			return e.otherInOut
+		case Difference:
+			return e.isSubject && e.otherInOut || !e.isSubject && !e.otherInOut
+		case Xor:
+			return true
+		}
+	case edgeSameTransition:
+		return op == Intersection || op == Union
+	case edgeDifferentTransition:
+		return op == Difference
+	}
+	return false
+}
+
+// resultTransition returns +1 if the edge of e is an outside-inside transition
+// of the result, for a vertical ray going upward, and -1 otherwise.
+func resultTransition(e *sweepEvent, op ClipOp) int {
+	thisIn, thatIn := !e.inOut, !e.otherInOut
+	// the other polygon transitions along an overlapping edge too
+	switch e.typ {
+	case edgeSameTransition:
+		thatIn = thisIn
+	case edgeDifferentTransition:
+		thatIn = !thisIn
+	}
+	var in bool
+	switch op {
+	case Intersection:
+		in = thisIn && thatIn
+	case Union:
+		in = thisIn || thatIn
+	case Xor:
+		in = thisIn != thatIn
+	case Difference:
+		if e.isSubject {
+			in = thisIn && !thatIn
+		} else {
+			in = thatIn && !thisIn
+		}
+	}
+	if in {
+		return 1
+	}
+	return -1
+}
+
+// possibleIntersection checks whether the edges of the left events e1 and e2
+// intersect and subdivides them if needed. It returns 0 if they do not
+// intersect or only at a common end point, 1 if they intersect at a single
+// point, 2 if they overlap and share their left end point and 3 for other
+// overlaps.
+func possibleIntersection(e1, e2 *sweepEvent, q *eventQueue) int {
+	n, ip0, _ := edgeIntersection(e1.point, e1.other.point, e2.point, e2.other.point)
+	if n == 0 {
+		return 0
+	}
+	if n == 1 && (e1.point == e2.point || e1.other.point == e2.other.point) {
+		// edges intersect at a common end point
+		return 0
+	}
+	if n == 2 && e1.isSubject == e2.isSubject {
+		// overlapping edges of the same polygon
+		return 0
+	}
+
+	if n == 1 {
+		if e1.point != ip0 && e1.other.point != ip0 {
+			divideSegment(e1, ip0, q)
+		}
+		if e2.point != ip0 && e2.other.point != ip0 {
+			divideSegment(e2, ip0, q)
+		}
+		return 1
+	}
+
+	// edges overlap
+	var (
+		events      []*sweepEvent
+		leftEqual   = e1.point == e2.point
+		rightEqual  = e1.other.point == e2.other.point
+		left1First  = compareEvents(e1, e2) != 1
+		right1First = compareEvents(e1.other, e2.other) != 1
+	)
+	if !leftEqual {
+		if left1First {
+			events = append(events, e1, e2)
+		} else {
+			events = append(events, e2, e1)
+		}
+	}
+	if !rightEqual {
+		if right1First {
+			events = append(events, e1.other, e2.other)
+		} else {
+			events = append(events, e2.other, e1.other)
+		}
+	}
+
+	if leftEqual {
+		// the overlapping part is only counted once
+		e2.typ = edgeNonContributing
+		if e1.inOut == e2.inOut {
+			e1.typ = edgeSameTransition
+		} else {
+			e1.typ = edgeDifferentTransition
+		}
+		if !rightEqual {
+			divideSegment(events[1].other, events[0].point, q)
+		}
+		return 2
+	}
+	if rightEqual {
+		divideSegment(events[0], events[1].point, q)
+		return 3
+	}
+	if events[0] != events[3].other {
+		// no edge includes the other one
+		divideSegment(events[0], events[1].point, q)
+		divideSegment(events[1], events[2].point, q)
+		return 3
+	}
+	// one edge includes the other one
+	divideSegment(events[0], events[1].point, q)
+	divideSegment(events[3].other, events[2].point, q)
+	return 3
+}
+
+// divideSegment splits the edge of the left event e at point p, adding the 2
+// new events to q.
+func divideSegment(e *sweepEvent, p Vec, q *eventQueue) {
+	r := &sweepEvent{point: p, other: e, isSubject: e.isSubject, contourID: e.contourID}
+	l := &sweepEvent{point: p, left: true, other: e.other, isSubject: e.isSubject, contourID: e.contourID}
+
+	// avoid rounding errors, the left event would be processed after the
+	// right one
+	if compareEvents(l, e.other) > 0 {
+		e.other.left = true
+		l.left = false
+	}
+
+	e.other.other = l
+	e.other = r
+	heap.Push(q, l)
+	heap.Push(q, r)
+}
+
+// snapEps is the relative distance under which an intersection point is
+// snapped to a segment end point, and under which a segment end point is
+// considered to lie on another segment.
+const snapEps = 1e-13
+
+// snapTol returns the absolute snapping tolerance for the segments a0a1 and
+// b0b1.
+func snapTol(a0, a1, b0, b1 Vec) float64 {
+	return snapEps * (a1.Sub(a0).Len() + b1.Sub(b0).Len() +
+		math.Max(math.Abs(a0.X), math.Abs(a1.X)) + math.Max(math.Abs(a0.Y), math.Abs(a1.Y)))
+}
+
+// lineDist returns the parameter of the projection of p on the line going
+// through a0 with direction v, and the distance from p to that line.
+func lineDist(a0, v, p Vec) (s, d float64) {
+	w := p.Sub(a0)
+	l2 := v.Dot(v)
+	return v.Dot(w) / l2, math.Abs(v.Cross(w)) / math.Sqrt(l2)
+}
+
+// onSegment reports whether p lies on the segment a0a1, strictly between its
+// end points, up to the distance tol.
+func onSegment(a0, a1, p Vec, tol float64) bool {
+	s, d := lineDist(a0, a1.Sub(a0), p)
+	return s > 0 && s < 1 && d <= tol
+}
+
+// edgeIntersection computes the intersection of segments a0a1 and b0b1. It
+// returns the number of intersection points: 0, 1 or 2 if the segments
+// overlap, in which case ip0 and ip1 are the ends of the overlapping part.
+// Intersections points located at a segment end are exactly that end point.
+//
+// Segment end points lying on the other segment up to rounding errors are
+// considered to be exactly on it. Otherwise the rings of the result would
+// have spikes of null width where the operands nearly touch.
+func edgeIntersection(a0, a1, b0, b1 Vec) (n int, ip0, ip1 Vec) {
+	va, vb := a1.Sub(a0), b1.Sub(b0)
+	e := b0.Sub(a0)
+	tol := snapTol(a0, a1, b0, b1)
+
+	_, db0 := lineDist(a0, va, b0)
+	_, db1 := lineDist(a0, va, b1)
+	_, da0 := lineDist(b0, vb, a0)
+	_, da1 := lineDist(b0, vb, a1)
+	collinear := db0 <= tol && db1 <= tol || da0 <= tol && da1 <= tol
+
+	if !collinear {
+		switch {
+		case onSegment(a0, a1, b0, tol):
+			return 1, b0, ip1
+		case onSegment(a0, a1, b1, tol):
+			return 1, b1, ip1
+		case onSegment(b0, b1, a0, tol):
+			return 1, a0, ip1
+		case onSegment(b0, b1, a1, tol):
+			return 1, a1, ip1
+		}
+	}
+
+	if kross := va.Cross(vb); kross != 0 && !collinear {
+		s := e.Cross(vb) / kross
+		if s < 0 || s > 1 {
+			return 0, ip0, ip1
+		}
+		t := e.Cross(va) / kross
+		if t < 0 || t > 1 {
+			return 0, ip0, ip1
+		}
+		switch {
+		case s == 0:
+			return 1, a0, ip1
+		case s == 1:
+			return 1, a1, ip1
+		case t == 0:
+			return 1, b0, ip1
+		case t == 1:
+			return 1, b1, ip1
+		}
+		// snap intersections that are extremely close to an end point, which
+		// happens when several edges meet at a vertex. Otherwise the
+		// intersection points computed with different edges would differ by
+		// rounding errors.
+		p := a0.Add(va.Mul(s))
+		for _, end := range [4]Vec{a0, a1, b0, b1} {
+			if math.Abs(p.X-end.X) <= tol && math.Abs(p.Y-end.Y) <= tol {
+				return 1, end, ip1
+			}
+		}
+		return 1, p, ip1
+	}
+
+	if !collinear {
+		// parallel, not collinear
+		return 0, ip0, ip1
+	}
+
+	// collinear, express b0 and b1 in a parametric space
+	sqrLenA := va.Dot(va)
+	sa := va.Dot(e) / sqrLenA
+	sb := sa + va.Dot(vb)/sqrLenA
+	pmin, pmax := b0, b1
+	smin, smax := sa, sb
+	if sb < sa {
+		pmin, pmax = b1, b0
+		smin, smax = sb, sa
+	}
+	if smin > 1 || smax < 0 {
+		return 0, ip0, ip1
+	}
+	if smin <= 0 {
+		pmin, smin = a0, 0
+	}
+	if smax >= 1 {
+		pmax, smax = a1, 1
+	}
+	if smin == smax {
+		return 1, pmin, ip1
+	}
+	return 2, pmin, pmax
+}
+
+// connectEdges connects the edges of the result into rings and assembles them
+// into polygons.
+//
+// Result edges are oriented so that the result is on their left. Following
+// them, and turning as much as possible toward the result at each vertex,
+// gives the boundary of each connected part of the result. Counter-clockwise
+// rings are outer rings, clockwise ones are holes.
+func connectEdges(sorted []*sweepEvent) MultiPolygon {
+	// outgoing result edges, by origin
+	out := make(map[Vec][]Vec)
+	for _, e := range sorted {
+		if !e.left || !e.inResult() {
+			continue
+		}
+		a, b := e.point, e.other.point
+		if e.resultTransition < 0 {
+			a, b = b, a
+		}
+		out[a] = append(out[a], b)
+	}
+
+	var outers, holes []Polygon
+	for len(out) > 0 {
+		// start from the lowest point to get a deterministic result
+		var start Vec
+		first := true
+		for v := range out {
+			if first || v.X < start.X || v.X == start.X && v.Y < start.Y {
+				start, first = v, false
+			}
+		}
+
+		ring := Polygon{start}
+		cur, prev := start, start
+		for {
+			next, ok := popNextEdge(out, prev, cur)
+			if !ok || next == start {
+				break
+			}
+			ring = append(ring, next)
+			prev, cur = cur, next
+		}
+
+		for _, l := range splitRing(ring) {
+			if len(l) < 3 {
+				continue
+			}
+			switch l.Orientation() {
+			case CounterClockwise:
+				outers = append(outers, l)
+			case Clockwise:
+				holes = append(holes, l)
+			}
+		}
+	}
+
+	res := make(MultiPolygon, len(outers))
+	areas := make([]float64, len(outers))
+	for i, o := range outers {
+		res[i].Outer = o
+		areas[i] = o.Area()
+	}
+	for _, h := range holes {
+		// a hole belongs to the smallest outer ring containing it
+		best := -1
+		for i, o := range outers {
+			if (best < 0 || areas[i] < areas[best]) && !ringsCross(h, o) && ringInside(h, o) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			res[best].Holes = append(res[best].Holes, h)
+		}
+	}
+	return res
+}
+
+// popNextEdge removes from out and returns the end of the edge leaving cur
+// that comes first when turning clockwise from the edge that arrived at cur
+// from prev. If cur is the starting point of the walk, prev is cur. ok is
+// false if there's no edge leaving cur.
+func popNextEdge(out map[Vec][]Vec, prev, cur Vec) (next Vec, ok bool) {
+	edges := out[cur]
+	if len(edges) == 0 {
+		return next, false
+	}
+	best := 0
+	if len(edges) > 1 && prev != cur {
+		r := prev.Sub(cur)
+		bestAngle := math.Inf(1)
+		for i, v := range edges {
+			d := v.Sub(cur)
+			a := -math.Atan2(r.Cross(d), r.Dot(d))
+			if a <= 0 {
+				a += 2 * math.Pi
+			}
+			if a < bestAngle {
+				best, bestAngle = i, a
+			}
+		}
+	}
+	next = edges[best]
+	if len(edges) == 1 {
+		delete(out, cur)
+	} else {
+		edges[best] = edges[len(edges)-1]
+		out[cur] = edges[:len(edges)-1]
+	}
+	return next, true
+}
+
+// splitRing splits a ring that touches itself at some of its vertices into
+// simple loops. The first returned loop is what remains of ring once the
+// other loops have been removed from it. Loops with less than 3 vertices are
+// dropped.
+func splitRing(ring Polygon) []Polygon {
+	var (
+		loops []Polygon
+		stack Polygon
+	)
+	seen := make(map[Vec]int, len(ring))
+	for _, v := range ring {
+		if i, ok := seen[v]; ok {
+			loop := append(Polygon(nil), stack[i:]...)
+			for _, lv := range stack[i+1:] {
+				delete(seen, lv)
+			}
+			stack = stack[:i+1]
+			if len(loop) >= 3 {
+				loops = append(loops, loop)
+			}
+			continue
+		}
+		seen[v] = len(stack)
+		stack = append(stack, v)
+	}
+	return append([]Polygon{stack}, loops...)
+}
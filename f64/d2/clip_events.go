@@ -0,0 +1,221 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+// This file contains the sweep line machinery of the Martinez-Rueda polygon
+// clipping algorithm, see clip.go.
+
+// edgeType classifies the edges that overlap an edge of the other polygon.
+type edgeType int
+
+const (
+	edgeNormal edgeType = iota
+	edgeNonContributing
+	edgeSameTransition
+	edgeDifferentTransition
+)
+
+// A sweepEvent is one of the two end points of an edge. The left event of an
+// edge is the one processed first by the sweep line.
+type sweepEvent struct {
+	point     Vec
+	left      bool
+	other     *sweepEvent
+	isSubject bool
+	typ       edgeType
+	contourID int
+
+	// inOut indicates if the edge represents an inside-outside transition of
+	// its own polygon, for a vertical ray going upward from the edge.
+	inOut bool
+	// otherInOut is the same as inOut, for the closest edge below of the
+	// other polygon.
+	otherInOut bool
+	// resultTransition is 0 if the edge is not in the result, +1 if it's an
+	// outside-inside transition of the result and -1 otherwise.
+	resultTransition int
+}
+
+// isBelow reports whether the edge of e is below p, that is if p is on the
+// left of the edge oriented from its left to its right end point.
+func (e *sweepEvent) isBelow(p Vec) bool {
+	if e.left {
+		return signedArea(e.point, e.other.point, p) > 0
+	}
+	return signedArea(e.other.point, e.point, p) > 0
+}
+
+// isAbove reports whether the edge of e is above p.
+func (e *sweepEvent) isAbove(p Vec) bool {
+	return !e.isBelow(p)
+}
+
+func (e *sweepEvent) isVertical() bool {
+	return e.point.X == e.other.point.X
+}
+
+func (e *sweepEvent) inResult() bool {
+	return e.resultTransition != 0
+}
+
+// signedArea returns twice the signed area of the triangle p0, p1, p2.
+func signedArea(p0, p1, p2 Vec) float64 {
+	return (p0.X-p2.X)*(p1.Y-p2.Y) - (p1.X-p2.X)*(p0.Y-p2.Y)
+}
+
+// compareEvents defines the order in which events are processed: from left to
+// right, then bottom to top, right events before left ones and, for the same
+// point, lower edges first.
+func compareEvents(e1, e2 *sweepEvent) int {
+	p1, p2 := e1.point, e2.point
+	switch {
+	case p1.X > p2.X:
+		return 1
+	case p1.X < p2.X:
+		return -1
+	case p1.Y != p2.Y:
+		if p1.Y > p2.Y {
+			return 1
+		}
+		return -1
+	}
+	if e1.left != e2.left {
+		if e1.left {
+			return 1
+		}
+		return -1
+	}
+	if signedArea(p1, e1.other.point, e2.other.point) != 0 {
+		// not collinear, the event of the lower edge is processed first
+		if !e1.isBelow(e2.other.point) {
+			return 1
+		}
+		return -1
+	}
+	if !e1.isSubject && e2.isSubject {
+		return 1
+	}
+	return -1
+}
+
+// compareSegments defines the order of the edges in the sweep line status,
+// from bottom to top. le1 and le2 are left events.
+func compareSegments(le1, le2 *sweepEvent) int {
+	if le1 == le2 {
+		return 0
+	}
+
+	if signedArea(le1.point, le1.other.point, le2.point) != 0 ||
+		signedArea(le1.point, le1.other.point, le2.other.point) != 0 {
+		// segments are not collinear
+		if le1.point == le2.point {
+			// same left end point, use the right one to sort
+			if le1.isBelow(le2.other.point) {
+				return -1
+			}
+			return 1
+		}
+		if le1.point.X == le2.point.X {
+			if le1.point.Y < le2.point.Y {
+				return -1
+			}
+			return 1
+		}
+		// has le2 been inserted in the sweep line after le1?
+		if compareEvents(le1, le2) == 1 {
+			if le2.isAbove(le1.point) {
+				return -1
+			}
+			return 1
+		}
+		// le1 has been inserted in the sweep line after le2
+		if le1.isBelow(le2.point) {
+			return -1
+		}
+		return 1
+	}
+
+	if le1.isSubject == le2.isSubject {
+		// collinear edges of the same polygon
+		if le1.point == le2.point {
+			if le1.other.point == le2.other.point {
+				return 0
+			}
+			if le1.contourID > le2.contourID {
+				return 1
+			}
+			return -1
+		}
+	} else {
+		// collinear edges of different polygons
+		if le1.isSubject {
+			return -1
+		}
+		return 1
+	}
+
+	if compareEvents(le1, le2) == 1 {
+		return 1
+	}
+	return -1
+}
+
+// eventQueue is a priority queue of sweep events, implementing heap.Interface.
+type eventQueue []*sweepEvent
+
+func (q eventQueue) Len() int            { return len(q) }
+func (q eventQueue) Less(i, j int) bool  { return compareEvents(q[i], q[j]) < 0 }
+func (q eventQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x interface{}) { *q = append(*q, x.(*sweepEvent)) }
+
+func (q *eventQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+	return e
+}
+
+// sweepLine is the status of the sweep line: the edges it currently crosses,
+// sorted from bottom to top.
+type sweepLine []*sweepEvent
+
+// insert inserts e in the sweep line and returns its position.
+func (sl *sweepLine) insert(e *sweepEvent) int {
+	s := *sl
+	lo, hi := 0, len(s)
+	for lo < hi {
+		m := (lo + hi) / 2
+		if compareSegments(s[m], e) < 0 {
+			lo = m + 1
+		} else {
+			hi = m
+		}
+	}
+	s = append(s, nil)
+	copy(s[lo+1:], s[lo:])
+	s[lo] = e
+	*sl = s
+	return lo
+}
+
+// find returns the position of e in the sweep line, or -1.
+func (sl sweepLine) find(e *sweepEvent) int {
+	for i, e2 := range sl {
+		if e2 == e {
+			return i
+		}
+	}
+	return -1
+}
+
+// remove removes the event at position i from the sweep line.
+func (sl *sweepLine) remove(i int) {
+	s := *sl
+	copy(s[i:], s[i+1:])
+	s[len(s)-1] = nil
+	*sl = s[:len(s)-1]
+}
@@ -0,0 +1,158 @@
+package d2
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func sq(x, y, size float64) PolygonWithHoles {
+	return PolygonWithHoles{Outer: Polygon{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}}}
+}
+
+func TestClip(t *testing.T) {
+	var tests = []struct {
+		name           string
+		subject, clip  MultiPolygon
+		op             ClipOp
+		area           float64
+		npolys, nholes int
+	}{
+		{"overlap intersection", MultiPolygon{sq(0, 0, 4)}, MultiPolygon{sq(2, 2, 4)}, Intersection, 4, 1, 0},
+		{"overlap union", MultiPolygon{sq(0, 0, 4)}, MultiPolygon{sq(2, 2, 4)}, Union, 28, 1, 0},
+		{"overlap difference", MultiPolygon{sq(0, 0, 4)}, MultiPolygon{sq(2, 2, 4)}, Difference, 12, 1, 0},
+		{"overlap xor", MultiPolygon{sq(0, 0, 4)}, MultiPolygon{sq(2, 2, 4)}, Xor, 24, 2, 0},
+
+		{"disjoint intersection", MultiPolygon{sq(0, 0, 1)}, MultiPolygon{sq(5, 5, 1)}, Intersection, 0, 0, 0},
+		{"disjoint union", MultiPolygon{sq(0, 0, 1)}, MultiPolygon{sq(5, 5, 1)}, Union, 2, 2, 0},
+		{"disjoint difference", MultiPolygon{sq(0, 0, 1)}, MultiPolygon{sq(5, 5, 1)}, Difference, 1, 1, 0},
+		// overlapping polygons of an operand are xor-ed, even if the operands
+		// are disjoint
+		{"disjoint union of nested", MultiPolygon{sq(0, 0, 4), sq(1, 1, 2)}, MultiPolygon{sq(5, 5, 1)}, Union, 13, 2, 1},
+		{"disjoint difference of nested", MultiPolygon{sq(0, 0, 4), sq(1, 1, 2)}, MultiPolygon{sq(5, 5, 1)}, Difference, 12, 1, 1},
+		{"empty union of nested", MultiPolygon{sq(0, 0, 4), sq(1, 1, 2)}, nil, Union, 12, 1, 1},
+		{"empty difference", nil, MultiPolygon{sq(0, 0, 1)}, Difference, 0, 0, 0},
+
+		{"hole punch", MultiPolygon{sq(0, 0, 10)}, MultiPolygon{sq(2, 2, 2)}, Difference, 96, 1, 1},
+		{"hole punch xor", MultiPolygon{sq(0, 0, 10)}, MultiPolygon{sq(2, 2, 2)}, Xor, 96, 1, 1},
+		{"contained intersection", MultiPolygon{sq(0, 0, 10)}, MultiPolygon{sq(2, 2, 2)}, Intersection, 4, 1, 0},
+		{"contained union", MultiPolygon{sq(0, 0, 10)}, MultiPolygon{sq(2, 2, 2)}, Union, 100, 1, 0},
+
+		{"frame union fills hole", MultiPolygon{frame()}, MultiPolygon{sq(1, 1, 3)}, Union, 91, 1, 1},
+		{"frame intersection", MultiPolygon{frame()}, MultiPolygon{sq(0, 0, 5)}, Intersection, 16, 1, 1},
+		{"frame difference", MultiPolygon{frame()}, MultiPolygon{sq(5, 5, 5)}, Difference, 66, 1, 1},
+
+		// shared edges
+		{"adjacent union", MultiPolygon{sq(0, 0, 2)}, MultiPolygon{sq(2, 0, 2)}, Union, 8, 1, 0},
+		{"adjacent intersection", MultiPolygon{sq(0, 0, 2)}, MultiPolygon{sq(2, 0, 2)}, Intersection, 0, 0, 0},
+		{"identical union", MultiPolygon{sq(0, 0, 2)}, MultiPolygon{sq(0, 0, 2)}, Union, 4, 1, 0},
+		{"identical difference", MultiPolygon{sq(0, 0, 2)}, MultiPolygon{sq(0, 0, 2)}, Difference, 0, 0, 0},
+
+		// self-touching input: 2 squares touching at a corner
+		{"self-touching union", MultiPolygon{sq(0, 0, 2), sq(2, 2, 2)}, MultiPolygon{sq(1, 1, 2)}, Union, 10, 1, 0},
+
+		// vertices differing by rounding errors
+		{"nearly coincident vertices union",
+			MultiPolygon{sq(0, 0, 1)},
+			MultiPolygon{{Outer: Polygon{{1 + 1e-15, 0}, {2, 0}, {2, 1}, {1, 1}}}},
+			Union, 2, 1, 0},
+		{"nearly coincident vertices xor",
+			MultiPolygon{sq(0, 0, 1)},
+			MultiPolygon{{Outer: Polygon{{1, 1e-15}, {2, 0}, {2, 1}, {1, 1}}}},
+			Xor, 2, 1, 0},
+		// a vertex of each operand lies on an edge of the other, up to
+		// rounding errors
+		{"vertex near edge difference",
+			MultiPolygon{{Outer: Polygon{{-2, 7}, {-0.3333333333333335, 5.333333333333333}, {0.4999999999999997, 5.125}, {4, 6}, {4, 10}, {-1, 9}}}},
+			MultiPolygon{{Outer: Polygon{{3, 9}, {-1, 11}, {-3, 8}, {-1, 6}, {1, 5}}}},
+			Difference, 7.8125, 2, 0},
+		{"vertex near edge xor",
+			MultiPolygon{{Outer: Polygon{{-2, 7}, {-0.3333333333333335, 5.333333333333333}, {0.4999999999999997, 5.125}, {4, 6}, {4, 10}, {-1, 9}}}},
+			MultiPolygon{{Outer: Polygon{{3, 9}, {-1, 11}, {-3, 8}, {-1, 6}, {1, 5}}}},
+			Xor, 14.229166666666666, 4, 0},
+
+		// triangles
+		{"triangle intersection",
+			MultiPolygon{{Outer: Polygon{{0, 0}, {4, 0}, {0, 4}}}},
+			MultiPolygon{{Outer: Polygon{{0, 0}, {4, 4}, {0, 4}}}},
+			Intersection, 4, 1, 0},
+	}
+
+	for _, tt := range tests {
+		got := Clip(tt.subject, tt.clip, tt.op)
+		if a := got.Area(); !approx(a, tt.area) {
+			t.Errorf("%s: area = %v, want %v (%v)", tt.name, a, tt.area, got)
+		}
+		if len(got) != tt.npolys {
+			t.Errorf("%s: got %d polygons, want %d (%v)", tt.name, len(got), tt.npolys, got)
+			continue
+		}
+		nholes := 0
+		for _, p := range got {
+			nholes += len(p.Holes)
+		}
+		if nholes != tt.nholes {
+			t.Errorf("%s: got %d holes, want %d (%v)", tt.name, nholes, tt.nholes, got)
+		}
+		if err := got.Validate(); err != nil {
+			t.Errorf("%s: invalid result: %v (%v)", tt.name, err, got)
+		}
+	}
+}
+
+// TestClipRandom checks the inclusion-exclusion principle on random
+// polygons: area(a∪b) + area(a∩b) = area(a) + area(b), and that the results
+// are valid. Results are fed back as operands, their vertices being computed
+// intersections that are subject to rounding errors.
+func TestClipRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	randPoly := func() MultiPolygon {
+		// star-shaped polygon around a random center
+		c := Vec{rng.Float64() * 10, rng.Float64() * 10}
+		n := 3 + rng.Intn(8)
+		p := make(Polygon, n)
+		for i := range p {
+			a := 2 * math.Pi * (float64(i) + rng.Float64()*0.8) / float64(n)
+			r := 1 + rng.Float64()*5
+			p[i] = c.Add(Vec{r * math.Cos(a), r * math.Sin(a)})
+		}
+		return MultiPolygon{{Outer: p}}
+	}
+
+	var prev, prevB MultiPolygon
+	for i := 0; i < 1000; i++ {
+		a, b := randPoly(), randPoly()
+		if i%2 == 1 && len(prev) > 0 {
+			a = prev
+			if i%4 == 3 {
+				// b shares edges with a
+				b = prevB
+			}
+		}
+		prevB = b
+		res := make(map[ClipOp]MultiPolygon)
+		for _, op := range []ClipOp{Union, Intersection, Difference, Xor} {
+			res[op] = Clip(a, b, op)
+			if err := res[op].Validate(); err != nil {
+				t.Fatalf("%v of a=%v b=%v is invalid: %v\n%v", op, a, b, err, res[op])
+			}
+		}
+		union, inter := res[Union].Area(), res[Intersection].Area()
+		diff, xor := res[Difference].Area(), res[Xor].Area()
+		aa, ba := a.Area(), b.Area()
+		if !approxTol(union+inter, aa+ba) {
+			t.Fatalf("union %v + inter %v != %v + %v, a=%v b=%v", union, inter, aa, ba, a, b)
+		}
+		if !approxTol(diff+inter, aa) {
+			t.Fatalf("diff %v + inter %v != %v, a=%v b=%v", diff, inter, aa, a, b)
+		}
+		if !approxTol(xor+inter, union) {
+			t.Fatalf("xor %v + inter %v != union %v, a=%v b=%v", xor, inter, union, a, b)
+		}
+		prev = res[[]ClipOp{Union, Difference, Xor, Intersection}[i/2%4]]
+	}
+}
+
+func approxTol(a, b float64) bool {
+	return math.Abs(a-b) < 1e-6*(1+math.Max(math.Abs(a), math.Abs(b)))
+}
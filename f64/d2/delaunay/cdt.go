@@ -0,0 +1,982 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package delaunay
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+// ErrOutside is returned when inserting a point outside of a triangulation.
+var ErrOutside = errors.New("delaunay: point outside of the triangulation")
+
+// A CDT is a constrained Delaunay triangulation: a triangulation containing a
+// given set of edges, the constraints, and that is otherwise as close as
+// possible to a Delaunay triangulation.
+//
+// The embedded Triangulation is updated as points and constraints are added,
+// its Points slice being a copy of the one passed to NewCDT.
+type CDT struct {
+	Triangulation
+
+	// Constrained holds, for each half-edge, whether it's a constrained edge.
+	Constrained []bool
+
+	// vedge holds a half-edge starting at each point, or -1.
+	vedge []int
+	// last is a recently created half-edge, from which point location starts.
+	last int
+}
+
+// NewCDT returns the Delaunay triangulation of points, without constraints.
+func NewCDT(points []d2.Vec) (*CDT, error) {
+	t, err := Triangulate(append([]d2.Vec(nil), points...))
+	if err != nil {
+		return nil, err
+	}
+	c := &CDT{
+		Triangulation: *t,
+		Constrained:   make([]bool, len(t.Triangles)),
+	}
+	c.indexEdges()
+	return c, nil
+}
+
+// InsertPoint inserts p in the triangulation and returns its index in Points.
+// If p is already a vertex of the triangulation, its index is returned.
+// Inserting a point on a constrained edge splits it into 2 constrained edges.
+//
+// It returns ErrOutside if p is outside of the triangulation.
+func (c *CDT) InsertPoint(p d2.Vec) (int, error) {
+	e, loc := c.locate(p, c.last, false)
+	switch loc {
+	case locOutside:
+		return -1, ErrOutside
+	case locVertex:
+		return c.Triangles[e], nil
+	}
+	return c.insertAt(p, e, loc), nil
+}
+
+// AddConstraint forces the edge between the points of indices a and b to be
+// part of the triangulation.
+//
+// Vertices lying on the segment a, b split the constraint in multiple edges.
+// When the segment crosses another constrained edge, their intersection is
+// inserted as a new point.
+func (c *CDT) AddConstraint(a, b int) error {
+	for _, i := range [2]int{a, b} {
+		if i < 0 || i >= len(c.vedge) || c.vedge[i] == -1 {
+			return fmt.Errorf("delaunay: point %d is not a vertex of the triangulation", i)
+		}
+	}
+	if a == b {
+		return nil
+	}
+	if e := c.edgeBetween(a, b); e != -1 {
+		c.constrain(e)
+		return nil
+	}
+
+	pa, pb := c.Points[a], c.Points[b]
+
+	// find the triangle around a in the direction of b
+	e := -1
+	for _, f := range c.edgesFrom(a) {
+		x, y := c.Triangles[NextHalfedge(f)], c.Triangles[PrevHalfedge(f)]
+		ox := orient(pa, c.Points[x], pb)
+		oy := orient(pa, c.Points[y], pb)
+		if ox == d2.Clockwise || oy == d2.CounterClockwise {
+			continue
+		}
+		// discard the wedge going in the opposite direction
+		if ox == d2.Collinear && c.Points[x].Sub(pa).Dot(pb.Sub(pa)) <= 0 ||
+			oy == d2.Collinear && c.Points[y].Sub(pa).Dot(pb.Sub(pa)) <= 0 {
+			continue
+		}
+		// a vertex on the segment splits the constraint
+		if ox == d2.Collinear && onSegment(pa, pb, c.Points[x]) {
+			return c.addConstraints(a, x, b)
+		}
+		if oy == d2.Collinear && onSegment(pa, pb, c.Points[y]) {
+			return c.addConstraints(a, y, b)
+		}
+		e = f
+		break
+	}
+	if e == -1 {
+		return fmt.Errorf("delaunay: can't find constraint %d-%d path", a, b)
+	}
+
+	// walk along the segment, collecting the crossed edges
+	var crossing [][2]int
+	e = NextHalfedge(e)
+	for {
+		if c.Constrained[e] {
+			i, err := c.insertIntersection(e, pa, pb)
+			if err != nil {
+				return err
+			}
+			return c.addConstraints(a, i, b)
+		}
+		f := c.Halfedges[e]
+		if f == -1 {
+			return fmt.Errorf("delaunay: can't find constraint %d-%d path", a, b)
+		}
+		crossing = append(crossing, [2]int{c.Triangles[e], c.Triangles[f]})
+		z := c.Triangles[PrevHalfedge(f)]
+		if z == b {
+			break
+		}
+		switch orient(pa, pb, c.Points[z]) {
+		case d2.Collinear:
+			return c.addConstraints(a, z, b)
+		case d2.Clockwise:
+			e = PrevHalfedge(f)
+		default:
+			e = NextHalfedge(f)
+		}
+	}
+
+	newEdges, err := c.removeCrossing(crossing, pa, pb)
+	if err != nil {
+		return err
+	}
+	c.constrain(c.edgeBetween(a, b))
+	c.restoreDelaunay(newEdges)
+	return nil
+}
+
+// AddPath inserts the vertices of p in the triangulation and constrains its
+// edges. If closed is true, the last vertex of p is joined to the first one.
+//
+// The vertices of p must be inside the triangulation, they can for example be
+// part of the points given to NewCDT.
+func (c *CDT) AddPath(p d2.Path, closed bool) error {
+	idx := make([]int, len(p))
+	for i, v := range p {
+		var err error
+		if idx[i], err = c.InsertPoint(v); err != nil {
+			return err
+		}
+	}
+	for i := 1; i < len(idx); i++ {
+		if err := c.AddConstraint(idx[i-1], idx[i]); err != nil {
+			return err
+		}
+	}
+	if closed && len(idx) > 2 {
+		return c.AddConstraint(idx[len(idx)-1], idx[0])
+	}
+	return nil
+}
+
+// RemoveOutside removes the triangles that are outside of the regions
+// delimited by the constrained edges, using the even-odd rule: a triangle is
+// kept if the paths from it to the outside of the triangulation cross an odd
+// number of constrained edges.
+//
+// As the triangulation is no longer convex, Hull is set to nil.
+func (c *CDT) RemoveOutside() {
+	ntris := len(c.Triangles) / 3
+	depth := make([]int, ntris)
+	for i := range depth {
+		depth[i] = -1
+	}
+
+	// breadth-first search from the hull, one level per constrained edge.
+	queues := make([][]int, 2)
+	for e, f := range c.Halfedges {
+		if f != -1 {
+			continue
+		}
+		if c.Constrained[e] {
+			queues[1] = append(queues[1], e/3)
+		} else {
+			queues[0] = append(queues[0], e/3)
+		}
+	}
+	for d := 0; d < len(queues); d++ {
+		for q := queues[d]; len(q) > 0; {
+			t := q[len(q)-1]
+			q = q[:len(q)-1]
+			if depth[t] != -1 {
+				continue
+			}
+			depth[t] = d
+			for e := 3 * t; e < 3*t+3; e++ {
+				f := c.Halfedges[e]
+				if f == -1 || depth[f/3] != -1 {
+					continue
+				}
+				if !c.Constrained[e] {
+					q = append(q, f/3)
+					continue
+				}
+				if d+1 == len(queues) {
+					queues = append(queues, nil)
+				}
+				queues[d+1] = append(queues[d+1], f/3)
+			}
+		}
+	}
+
+	// compact the triangles that are kept
+	newTri := make([]int, ntris)
+	n := 0
+	for t, d := range depth {
+		newTri[t] = -1
+		if d%2 == 1 {
+			newTri[t] = n
+			n++
+		}
+	}
+	tris := make([]int, 0, 3*n)
+	halfedges := make([]int, 0, 3*n)
+	constrained := make([]bool, 0, 3*n)
+	for t, nt := range newTri {
+		if nt == -1 {
+			continue
+		}
+		for e := 3 * t; e < 3*t+3; e++ {
+			f := c.Halfedges[e]
+			if f != -1 {
+				if nf := newTri[f/3]; nf == -1 {
+					f = -1
+				} else {
+					f = 3*nf + f%3
+				}
+			}
+			tris = append(tris, c.Triangles[e])
+			halfedges = append(halfedges, f)
+			constrained = append(constrained, c.Constrained[e])
+		}
+	}
+	c.Triangles, c.Halfedges, c.Constrained = tris, halfedges, constrained
+	c.Hull = nil
+	c.indexEdges()
+}
+
+// Refine inserts new points in the triangulation, with Ruppert's algorithm,
+// until no triangle has an area greater than maxArea or an angle smaller than
+// minAngle, in radians. Either constraint is ignored if it is not positive.
+//
+// Constrained edges and edges on the boundary of the triangulation are split
+// but always remain part of it. Refine terminates for minAngle up to about
+// 20 degrees, but greater values may work. Angles between constrained edges
+// smaller than minAngle are left as is, as well as features smaller than a
+// billionth of the triangulation size.
+func (c *CDT) Refine(maxArea, minAngle float64) {
+	if len(c.Triangles) == 0 {
+		return
+	}
+	b := d2.Path(c.Points).Rectangle()
+	r := &refiner{
+		CDT:      c,
+		maxArea:  maxArea,
+		minAngle: minAngle,
+		minLen:   1e-9 * b.Min.Dist(b.Max),
+		ninput:   len(c.Points),
+		orig:     make(map[int][2]int),
+	}
+	for e := range c.Triangles {
+		if c.isSegment(e) {
+			r.segs = append(r.segs, c.edge(e))
+		}
+	}
+	for t := 0; t < len(c.Triangles); t += 3 {
+		r.tris = append(r.tris, t)
+	}
+	r.run()
+}
+
+// refiner holds the state of the refinement of a CDT.
+type refiner struct {
+	*CDT
+	maxArea, minAngle, minLen float64
+
+	segs [][2]int // segments to check for encroachment
+	tris []int    // triangles to check
+
+	// points with an index lower than ninput existed before the refinement.
+	ninput int
+	// orig holds the end points of the segment on which a new point has been
+	// inserted, sorted by index.
+	orig map[int][2]int
+}
+
+func (r *refiner) run() {
+	for {
+		for len(r.segs) > 0 {
+			s := r.segs[len(r.segs)-1]
+			r.segs = r.segs[:len(r.segs)-1]
+			if e := r.edgeBetween(s[0], s[1]); e != -1 && r.isSegment(e) && r.encroached(e) {
+				r.splitSegment(e)
+			}
+		}
+		if len(r.tris) == 0 {
+			return
+		}
+		t := r.tris[len(r.tris)-1]
+		r.tris = r.tris[:len(r.tris)-1]
+		if t >= len(r.Triangles) || !r.isBad(t) {
+			continue
+		}
+
+		a, b, c := r.Triangle(t / 3)
+		cc := circumcenter(a, b, c)
+		segs, e, loc := r.encroachedBy(cc, t)
+		if len(segs) > 0 {
+			split := false
+			for _, s := range segs {
+				if e := r.edgeBetween(s[0], s[1]); e != -1 && r.isSegment(e) {
+					split = r.splitSegment(e) || split
+				}
+			}
+			if split {
+				r.tris = append(r.tris, t)
+			}
+			continue
+		}
+		if loc == locOutside || loc == locVertex {
+			continue
+		}
+		r.inserted(r.insertAt(cc, e, loc))
+	}
+}
+
+// isBad reports whether the triangle of half-edge t has to be refined.
+func (r *refiner) isBad(t int) bool {
+	var l [3]float64
+	imin := 0
+	for i := range l {
+		l[i] = r.Points[r.Triangles[t+i]].Dist(r.Points[r.Triangles[NextHalfedge(t+i)]])
+		if l[i] < l[imin] {
+			imin = i
+		}
+	}
+	if l[imin] < r.minLen {
+		return false
+	}
+	a, b, c := r.Triangle(t / 3)
+	area := d2.Polygon{a, b, c}.Area()
+	if r.maxArea > 0 && area > r.maxArea {
+		return true
+	}
+	if r.minAngle <= 0 || r.seditious(r.Triangles[t+imin], r.Triangles[NextHalfedge(t+imin)]) {
+		return false
+	}
+	// the smallest angle is opposite to the shortest edge
+	j, k := (imin+1)%3, (imin+2)%3
+	if math.Asin(math.Min(1, 2*area/(l[j]*l[k]))) >= r.minAngle {
+		return false
+	}
+	// leave small angles between segments
+	return !r.isSegment(t+j) || !r.isSegment(t+k)
+}
+
+// encroached reports whether a vertex of the triangles of the segment e lies
+// in its diametral circle.
+func (r *refiner) encroached(e int) bool {
+	for _, f := range [2]int{e, r.Halfedges[e]} {
+		if f == -1 {
+			continue
+		}
+		u := r.Points[r.Triangles[f]]
+		v := r.Points[r.Triangles[NextHalfedge(f)]]
+		w := r.Points[r.Triangles[PrevHalfedge(f)]]
+		if u.Sub(w).Dot(v.Sub(w)) < 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// encroachedBy returns the segments whose diametral circle contains p, that
+// would be visible from p once inserted, and the location of p. t is a
+// triangle close to p.
+func (r *refiner) encroachedBy(p d2.Vec, t int) (segs [][2]int, e, loc int) {
+	e, loc = r.locate(p, t, true)
+	if loc == locOutside {
+		// p is behind a segment
+		if r.isSegment(e) {
+			segs = append(segs, r.edge(e))
+		}
+		return
+	}
+
+	// look for the segments on the boundary of the cavity of p, made of the
+	// triangles whose circumcircle contains p.
+	seen := map[int]bool{e / 3: true}
+	queue := []int{e / 3}
+	for len(queue) > 0 {
+		t := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+		for f := 3 * t; f < 3*t+3; f++ {
+			if r.isSegment(f) {
+				u := r.Points[r.Triangles[f]]
+				v := r.Points[r.Triangles[NextHalfedge(f)]]
+				if p.Sub(u).Dot(p.Sub(v)) < 0 {
+					segs = append(segs, r.edge(f))
+				}
+				continue
+			}
+			g := r.Halfedges[f]
+			if seen[g/3] {
+				continue
+			}
+			seen[g/3] = true
+			a, b, c := r.Triangle(g / 3)
+			if inCircle(a, b, c, p) {
+				queue = append(queue, g/3)
+			}
+		}
+	}
+	return
+}
+
+// splitSegment splits the segment e, if it's not too short, and reports
+// whether it has been split.
+//
+// Segments are split at their middle, unless one of their end points is an
+// input vertex: they're then split at a power of 2 distance from it, so that
+// segments making a small angle get split at the same distances from their
+// shared vertex ("concentric shells").
+func (r *refiner) splitSegment(e int) bool {
+	u, v := r.Triangles[e], r.Triangles[NextHalfedge(e)]
+	pu, pv := r.Points[u], r.Points[v]
+	l := pu.Dist(pv)
+	if l < 2*r.minLen {
+		return false
+	}
+
+	p := pu.Add(pv).Div(2)
+	iu, iv := u < r.ninput, v < r.ninput
+	if iu != iv {
+		from, to := pu, pv
+		if iv {
+			from, to = pv, pu
+		}
+		d := math.Exp2(math.Round(math.Log2(l / 2)))
+		p = from.Add(to.Sub(from).Mul(d / l))
+	}
+	i := r.insertAt(p, e, locEdge)
+
+	switch {
+	case iu && iv:
+		if u > v {
+			u, v = v, u
+		}
+		r.orig[i] = [2]int{u, v}
+	case !iu:
+		r.orig[i] = r.orig[u]
+	default:
+		r.orig[i] = r.orig[v]
+	}
+	r.inserted(i)
+	return true
+}
+
+// seditious reports whether the edge between the points i and j joins 2
+// segments meeting at a small angle, at the same distance from their shared
+// vertex. Such edges can't be improved and are left as is, as Shewchuk does in
+// Triangle.
+func (r *refiner) seditious(i, j int) bool {
+	oi, iok := r.orig[i]
+	oj, jok := r.orig[j]
+	if !iok || !jok || oi == oj {
+		return false
+	}
+	apex, si, sj := -1, -1, -1
+	for a := 0; a < 2; a++ {
+		for b := 0; b < 2; b++ {
+			if oi[a] == oj[b] {
+				apex, si, sj = oi[a], oi[1-a], oj[1-b]
+			}
+		}
+	}
+	if apex == -1 {
+		return false
+	}
+	w := r.Points[apex]
+	di, dj := w.Dist(r.Points[i]), w.Dist(r.Points[j])
+	if math.Abs(di-dj) > 1e-6*di {
+		return false
+	}
+	u, v := r.Points[si].Sub(w), r.Points[sj].Sub(w)
+	return u.Dot(v) > 0.5*u.Len()*v.Len()
+}
+
+// inserted queues the segments and triangles around the new point i, so that
+// they get checked.
+func (r *refiner) inserted(i int) {
+	for _, e := range r.edgesFrom(i) {
+		r.tris = append(r.tris, e-e%3)
+		for _, f := range [2]int{e, NextHalfedge(e)} {
+			if r.isSegment(f) {
+				r.segs = append(r.segs, r.edge(f))
+			}
+		}
+	}
+}
+
+// isSegment reports whether e is a constrained edge or a boundary edge.
+func (c *CDT) isSegment(e int) bool {
+	return c.Constrained[e] || c.Halfedges[e] == -1
+}
+
+// edge returns the indices of the points of the half-edge e.
+func (c *CDT) edge(e int) [2]int {
+	return [2]int{c.Triangles[e], c.Triangles[NextHalfedge(e)]}
+}
+
+// constrain marks e and its twin as constrained.
+func (c *CDT) constrain(e int) {
+	c.Constrained[e] = true
+	if f := c.Halfedges[e]; f != -1 {
+		c.Constrained[f] = true
+	}
+}
+
+// unconstrain marks e and its twin as unconstrained.
+func (c *CDT) unconstrain(e int) {
+	c.Constrained[e] = false
+	if f := c.Halfedges[e]; f != -1 {
+		c.Constrained[f] = false
+	}
+}
+
+// addConstraints adds the constraints from a to i and from i to b.
+func (c *CDT) addConstraints(a, i, b int) error {
+	if err := c.AddConstraint(a, i); err != nil {
+		return err
+	}
+	return c.AddConstraint(i, b)
+}
+
+// insertIntersection inserts the intersection of the constrained edge e and
+// of the segment pa, pb, and returns its index.
+func (c *CDT) insertIntersection(e int, pa, pb d2.Vec) (int, error) {
+	x, y := c.Triangles[e], c.Triangles[NextHalfedge(e)]
+	px, py := c.Points[x], c.Points[y]
+	d := py.Sub(px)
+	t := pa.Sub(px).Cross(pb.Sub(pa)) / d.Cross(pb.Sub(pa))
+	p := px.Add(d.Mul(t))
+
+	// snap p to a close vertex, so that constraints crossing at the same
+	// point do not create clusters of points.
+	tol := 1e-9 * (d.Len() + pb.Sub(pa).Len())
+	snap, dmin := -1, tol
+	for _, f := range [2]int{e, c.Halfedges[e]} {
+		if f == -1 {
+			continue
+		}
+		for _, g := range [3]int{f, NextHalfedge(f), PrevHalfedge(f)} {
+			if dist := p.Dist(c.Points[c.Triangles[g]]); dist <= dmin {
+				snap, dmin = c.Triangles[g], dist
+			}
+		}
+	}
+	switch {
+	case snap == x || snap == y:
+		return snap, nil
+	case snap != -1:
+		// bend e so that it goes through the snapped vertex
+		c.unconstrain(e)
+		return snap, c.addConstraints(x, snap, y)
+	case t <= 0:
+		return x, nil
+	case t >= 1:
+		return y, nil
+	}
+
+	// due to rounding errors, p is generally not exactly on e
+	valid := true
+	for _, f := range [2]int{e, c.Halfedges[e]} {
+		if f == -1 {
+			continue
+		}
+		u := c.Points[c.Triangles[f]]
+		v := c.Points[c.Triangles[NextHalfedge(f)]]
+		w := c.Points[c.Triangles[PrevHalfedge(f)]]
+		valid = valid && orient(u, p, w) == d2.CounterClockwise &&
+			orient(p, v, w) == d2.CounterClockwise
+	}
+	if valid {
+		return c.insertAt(p, e, locEdge), nil
+	}
+
+	// splitting e at p would invert a triangle: insert p where it is
+	// and replace e by 2 constraints joined at p.
+	c.unconstrain(e)
+	f, loc := c.locate(p, e, false)
+	i := c.Triangles[f]
+	if loc != locVertex {
+		i = c.insertAt(p, f, loc)
+	}
+	return i, c.addConstraints(x, i, y)
+}
+
+// removeCrossing flips the edges of crossing, given as pairs of point
+// indices, until none of them crosses the segment pa, pb. It returns the
+// edges that have been created.
+func (c *CDT) removeCrossing(crossing [][2]int, pa, pb d2.Vec) ([][2]int, error) {
+	var newEdges [][2]int
+	stuck := 0
+	for len(crossing) > 0 {
+		if stuck > len(crossing) {
+			return nil, errors.New("delaunay: can't insert constraint")
+		}
+		uv := crossing[0]
+		crossing = crossing[1:]
+		e := c.edgeBetween(uv[0], uv[1])
+		f := c.Halfedges[e]
+		p0, p1 := c.Triangles[PrevHalfedge(e)], c.Triangles[PrevHalfedge(f)]
+		q0, q1 := c.Points[p0], c.Points[p1]
+
+		// the quadrilateral around e must be strictly convex to flip it
+		ou := orient(q0, q1, c.Points[uv[0]])
+		ov := orient(q0, q1, c.Points[uv[1]])
+		if ou == d2.Collinear || ov == d2.Collinear || ou == ov {
+			crossing = append(crossing, uv)
+			stuck++
+			continue
+		}
+		stuck = 0
+		c.flip(e)
+		if crosses(q0, q1, pa, pb) {
+			crossing = append(crossing, [2]int{p0, p1})
+		} else {
+			newEdges = append(newEdges, [2]int{p0, p1})
+		}
+	}
+	return newEdges, nil
+}
+
+// restoreDelaunay flips the edges of edges, given as pairs of point indices,
+// until they all satisfy the Delaunay condition.
+func (c *CDT) restoreDelaunay(edges [][2]int) {
+	for flipped := true; flipped; {
+		flipped = false
+		for i, uv := range edges {
+			e := c.edgeBetween(uv[0], uv[1])
+			if e == -1 || !c.illegal(e) {
+				continue
+			}
+			f := c.Halfedges[e]
+			edges[i] = [2]int{c.Triangles[PrevHalfedge(e)], c.Triangles[PrevHalfedge(f)]}
+			c.flip(e)
+			flipped = true
+		}
+	}
+}
+
+// crosses reports whether the segments a, b and c, d intersect at a single
+// point that is not one of their end points.
+func crosses(a, b, c, d d2.Vec) bool {
+	o1, o2 := orient(a, b, c), orient(a, b, d)
+	o3, o4 := orient(c, d, a), orient(c, d, b)
+	return o1 != d2.Collinear && o2 != d2.Collinear && o1 != o2 &&
+		o3 != d2.Collinear && o4 != d2.Collinear && o3 != o4
+}
+
+// onSegment reports whether p, collinear with a and b, is strictly between
+// them.
+func onSegment(a, b, p d2.Vec) bool {
+	return p.Sub(a).Dot(b.Sub(a)) > 0 && p.Sub(b).Dot(a.Sub(b)) > 0
+}
+
+// indexEdges recomputes vedge.
+func (c *CDT) indexEdges() {
+	c.vedge = make([]int, len(c.Points))
+	for i := range c.vedge {
+		c.vedge[i] = -1
+	}
+	for e, p := range c.Triangles {
+		c.vedge[p] = e
+	}
+	c.last = 0
+}
+
+// edgesFrom returns the half-edges starting at the point i.
+func (c *CDT) edgesFrom(i int) []int {
+	start := c.vedge[i]
+	if start == -1 {
+		return nil
+	}
+	edges := []int{start}
+	for e := start; ; {
+		e = c.Halfedges[PrevHalfedge(e)]
+		if e == start {
+			return edges
+		}
+		if e == -1 {
+			break
+		}
+		edges = append(edges, e)
+	}
+	// i is on the boundary, turn in the other direction
+	for e := c.Halfedges[start]; e != -1; e = c.Halfedges[e] {
+		e = NextHalfedge(e)
+		edges = append(edges, e)
+	}
+	return edges
+}
+
+// edgeBetween returns a half-edge joining the points i and j, or -1.
+func (c *CDT) edgeBetween(i, j int) int {
+	for _, e := range c.edgesFrom(i) {
+		if c.Triangles[NextHalfedge(e)] == j {
+			return e
+		}
+		if p := PrevHalfedge(e); c.Triangles[p] == j {
+			return p
+		}
+	}
+	return -1
+}
+
+// Point location results.
+const (
+	locInside = iota
+	locEdge
+	locVertex
+	locOutside
+)
+
+// locate finds the triangle containing p, walking from the half-edge start.
+//
+// It returns locVertex and a half-edge starting at p if p is a vertex,
+// locEdge and the half-edge on which p is, locInside and a half-edge of the
+// triangle containing p, or locOutside and the boundary half-edge behind
+// which p is. If stop is true, the walk stops at constrained edges as if they
+// were on the boundary.
+func (c *CDT) locate(p d2.Vec, start int, stop bool) (int, int) {
+	if start < 0 || start >= len(c.Triangles) {
+		start = 0
+	}
+	t := start - start%3
+	for steps := 0; ; steps++ {
+		if steps > len(c.Triangles) {
+			// the walk is cycling, which may happen when constraints are
+			// crossed: scan all triangles
+			return c.scan(p)
+		}
+		next := -1
+		for k := 0; k < 3; k++ {
+			e := t + (k+steps)%3
+			a, b := c.Points[c.Triangles[e]], c.Points[c.Triangles[NextHalfedge(e)]]
+			if orient(a, b, p) == d2.Clockwise {
+				next = e
+				break
+			}
+		}
+		if next == -1 {
+			break
+		}
+		f := c.Halfedges[next]
+		if f == -1 || stop && c.Constrained[next] {
+			return next, locOutside
+		}
+		t = f - f%3
+	}
+	return c.where(p, t)
+}
+
+// scan finds the triangle containing p by testing all triangles.
+func (c *CDT) scan(p d2.Vec) (int, int) {
+	for t := 0; t < len(c.Triangles); t += 3 {
+		a, b, cc := c.Triangle(t / 3)
+		if orient(a, b, p) != d2.Clockwise &&
+			orient(b, cc, p) != d2.Clockwise &&
+			orient(cc, a, p) != d2.Clockwise {
+			return c.where(p, t)
+		}
+	}
+	return -1, locOutside
+}
+
+// where locates p, inside the triangle t or on its boundary.
+func (c *CDT) where(p d2.Vec, t int) (int, int) {
+	for e := t; e < t+3; e++ {
+		if c.Points[c.Triangles[e]] == p {
+			return e, locVertex
+		}
+	}
+	for e := t; e < t+3; e++ {
+		a, b := c.Points[c.Triangles[e]], c.Points[c.Triangles[NextHalfedge(e)]]
+		if orient(a, b, p) == d2.Collinear {
+			return e, locEdge
+		}
+	}
+	return t, locInside
+}
+
+// insertAt inserts p, located at e, and returns its index.
+func (c *CDT) insertAt(p d2.Vec, e, loc int) int {
+	i := len(c.Points)
+	c.Points = append(c.Points, p)
+	c.vedge = append(c.vedge, -1)
+	if loc == locEdge {
+		c.splitEdge(e, i)
+	} else {
+		c.splitTriangle(e-e%3, i)
+	}
+	c.last = c.vedge[i]
+	return i
+}
+
+// addTriangle adds the triangle i0, i1, i2 and returns its first half-edge.
+func (c *CDT) addTriangle(i0, i1, i2 int) int {
+	t := len(c.Triangles)
+	c.Triangles = append(c.Triangles, i0, i1, i2)
+	c.Halfedges = append(c.Halfedges, -1, -1, -1)
+	c.Constrained = append(c.Constrained, false, false, false)
+	c.vedge[i0], c.vedge[i1], c.vedge[i2] = t, t+1, t+2
+	return t
+}
+
+func (c *CDT) link(a, b int) {
+	c.Halfedges[a] = b
+	if b != -1 {
+		c.Halfedges[b] = a
+	}
+}
+
+// splitTriangle splits the triangle t in 3 triangles joined at point i.
+func (c *CDT) splitTriangle(t, i int) {
+	a, b, pc := c.Triangles[t], c.Triangles[t+1], c.Triangles[t+2]
+	h1, h2 := c.Halfedges[t+1], c.Halfedges[t+2]
+	c1, c2 := c.Constrained[t+1], c.Constrained[t+2]
+
+	c.Triangles[t+2] = i
+	c.vedge[a], c.vedge[b], c.vedge[i] = t, t+1, t+2
+	t1 := c.addTriangle(b, pc, i)
+	t2 := c.addTriangle(pc, a, i)
+
+	c.link(t1, h1)
+	c.link(t2, h2)
+	c.Constrained[t1], c.Constrained[t2] = c1, c2
+	c.Constrained[t+1], c.Constrained[t+2] = false, false
+	c.link(t+1, t1+2)
+	c.link(t1+1, t2+2)
+	c.link(t2+1, t+2)
+
+	c.legalize(t)
+	c.legalize(t1)
+	c.legalize(t2)
+}
+
+// splitEdge splits the edge e, and its twin, at point i.
+func (c *CDT) splitEdge(e, i int) {
+	en, ep := NextHalfedge(e), PrevHalfedge(e)
+	a, b, pc := c.Triangles[e], c.Triangles[en], c.Triangles[ep]
+	f := c.Halfedges[e]
+	hn, cn, ce := c.Halfedges[en], c.Constrained[en], c.Constrained[e]
+
+	// a, b, pc becomes a, i, pc and i, b, pc is added
+	c.Triangles[en] = i
+	c.vedge[a], c.vedge[i], c.vedge[pc] = e, en, ep
+	t1 := c.addTriangle(i, b, pc)
+	c.link(en, t1+2)
+	c.Constrained[en] = false
+	c.link(t1+1, hn)
+	c.Constrained[t1+1] = cn
+	c.Constrained[t1] = ce
+
+	if f == -1 {
+		c.Halfedges[e] = -1
+		c.insertHull(a, i)
+	} else {
+		// b, a, d becomes b, i, d and i, a, d is added
+		fn, fp := NextHalfedge(f), PrevHalfedge(f)
+		d := c.Triangles[fp]
+		hfn, cfn := c.Halfedges[fn], c.Constrained[fn]
+		c.Triangles[fn] = i
+		c.vedge[b], c.vedge[d] = f, fp
+		u1 := c.addTriangle(i, a, d)
+		c.link(fn, u1+2)
+		c.Constrained[fn] = false
+		c.link(u1+1, hfn)
+		c.Constrained[u1+1] = cfn
+		c.link(e, u1)
+		c.link(t1, f)
+		c.Constrained[u1] = ce
+
+		c.legalize(fp)
+		c.legalize(u1 + 1)
+	}
+	c.legalize(ep)
+	c.legalize(t1 + 1)
+}
+
+// insertHull inserts the point i after the point a in the hull.
+func (c *CDT) insertHull(a, i int) {
+	for k, h := range c.Hull {
+		if h == a {
+			c.Hull = append(c.Hull, 0)
+			copy(c.Hull[k+2:], c.Hull[k+1:])
+			c.Hull[k+1] = i
+			return
+		}
+	}
+}
+
+// illegal reports whether the edge e does not satisfy the Delaunay condition
+// and can be flipped.
+func (c *CDT) illegal(e int) bool {
+	f := c.Halfedges[e]
+	if f == -1 || c.Constrained[e] {
+		return false
+	}
+	return inCircle(
+		c.Points[c.Triangles[e]],
+		c.Points[c.Triangles[NextHalfedge(e)]],
+		c.Points[c.Triangles[PrevHalfedge(e)]],
+		c.Points[c.Triangles[PrevHalfedge(f)]])
+}
+
+// legalize flips the edge a, whose opposite point is the one that has just
+// been inserted, and recursively the edges of the resulting triangles, until
+// they all satisfy the Delaunay condition. Constrained edges are not flipped.
+func (c *CDT) legalize(a int) {
+	stack := []int{a}
+	for len(stack) > 0 {
+		a := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if !c.illegal(a) {
+			continue
+		}
+		b := c.Halfedges[a]
+		c.flip(a)
+		stack = append(stack, a, b-b%3+(b+1)%3)
+	}
+}
+
+// flip replaces the edge a, shared by triangles pr, pl, p0 and pl, pr, p1, by
+// the edge joining p0 and p1. a and its twin now start at p1 and p0.
+func (c *CDT) flip(a int) {
+	b := c.Halfedges[a]
+	a0, b0 := a-a%3, b-b%3
+	al, ar := a0+(a+1)%3, a0+(a+2)%3
+	bl, br := b0+(b+2)%3, b0+(b+1)%3
+	p0, p1 := c.Triangles[ar], c.Triangles[bl]
+	hbl, har := c.Halfedges[bl], c.Halfedges[ar]
+	cbl, car := c.Constrained[bl], c.Constrained[ar]
+
+	c.Triangles[a], c.Triangles[b] = p1, p0
+	c.link(a, hbl)
+	c.link(b, har)
+	c.link(ar, bl)
+	c.Constrained[a], c.Constrained[b] = cbl, car
+	c.Constrained[ar], c.Constrained[bl] = false, false
+	for _, e := range [...]int{a, al, ar, b, bl, br} {
+		c.vedge[c.Triangles[e]] = e
+	}
+	c.last = a
+}
@@ -0,0 +1,236 @@
+package delaunay
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+// checkCDT checks the half-edges of c and that its unconstrained edges
+// satisfy the Delaunay condition. It returns the total area of the triangles.
+func checkCDT(tb testing.TB, c *CDT) float64 {
+	tb.Helper()
+	if len(c.Halfedges) != len(c.Triangles) || len(c.Constrained) != len(c.Triangles) {
+		tb.Fatalf("inconsistent lengths")
+	}
+	for e, opp := range c.Halfedges {
+		if opp == -1 {
+			continue
+		}
+		if c.Halfedges[opp] != e {
+			tb.Fatalf("half-edge %d: twin of twin is %d", e, c.Halfedges[opp])
+		}
+		if c.Triangles[e] != c.Triangles[NextHalfedge(opp)] ||
+			c.Triangles[opp] != c.Triangles[NextHalfedge(e)] {
+			tb.Fatalf("half-edge %d and its twin %d do not share the same points", e, opp)
+		}
+		if c.Constrained[e] != c.Constrained[opp] {
+			tb.Fatalf("half-edge %d and its twin %d have different constraints", e, opp)
+		}
+		if c.Constrained[e] {
+			continue
+		}
+		p := c.Points[c.Triangles[PrevHalfedge(opp)]]
+		a, b, cc := c.Triangle(e / 3)
+		if inCircle(a, b, cc, p) && circleMargin(a, b, cc, p) > 1e-9 {
+			tb.Fatalf("edge %d is not Delaunay: %v is in circle %v %v %v", e, p, a, b, cc)
+		}
+	}
+	var area float64
+	for i := 0; i < c.Len(); i++ {
+		a, b, cc := c.Triangle(i)
+		tri := d2.Polygon{a, b, cc}
+		if orient(tri[0], tri[1], tri[2]) != d2.CounterClockwise {
+			tb.Fatalf("triangle %d %v is not counter-clockwise", i, tri)
+		}
+		area += tri.Area()
+	}
+	return area
+}
+
+// hasConstraint reports whether the segment between a and b is made of
+// constrained edges of c.
+func hasConstraint(c *CDT, a, b d2.Vec) bool {
+	var l float64
+	for e, ok := range c.Constrained {
+		if !ok {
+			continue
+		}
+		u, v := c.Points[c.Triangles[e]], c.Points[c.Triangles[NextHalfedge(e)]]
+		if math.Abs(d2.Seg(a.X, a.Y, b.X, b.Y).Dist(u)) < 1e-9 &&
+			math.Abs(d2.Seg(a.X, a.Y, b.X, b.Y).Dist(v)) < 1e-9 {
+			l += u.Dist(v)
+		}
+	}
+	// each edge is counted twice, except on the boundary
+	return l >= a.Dist(b)-1e-9
+}
+
+func TestCDTAddConstraint(t *testing.T) {
+	// the Delaunay triangulation of this quad contains the 1-3 diagonal
+	pts := vecs(0, 0, 10, -1, 20, 0, 10, 1)
+	c, err := NewCDT(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.edgeBetween(0, 2) != -1 {
+		t.Fatalf("edge 0-2 should not be in the Delaunay triangulation")
+	}
+	if err := c.AddConstraint(0, 2); err != nil {
+		t.Fatalf("AddConstraint(0, 2) = %v", err)
+	}
+	e := c.edgeBetween(0, 2)
+	if e == -1 || !c.Constrained[e] {
+		t.Errorf("edge 0-2 is not constrained")
+	}
+	checkCDT(t, c)
+
+	// adding a point splits the constraint
+	i, err := c.InsertPoint(d2.Vec{X: 10, Y: 0})
+	if err != nil {
+		t.Fatalf("InsertPoint() error = %v", err)
+	}
+	for _, j := range []int{0, 2} {
+		if e := c.edgeBetween(i, j); e == -1 || !c.Constrained[e] {
+			t.Errorf("edge %d-%d is not constrained", i, j)
+		}
+	}
+	checkCDT(t, c)
+
+	if _, err := c.InsertPoint(d2.Vec{X: 30, Y: 0}); err != ErrOutside {
+		t.Errorf("InsertPoint() outside error = %v, want %v", err, ErrOutside)
+	}
+	if err := c.AddConstraint(0, 42); err == nil {
+		t.Errorf("AddConstraint(0, 42) error = nil, want error")
+	}
+}
+
+func TestCDTCrossingConstraints(t *testing.T) {
+	pts := vecs(0, 0, 10, 0, 10, 10, 0, 10, 5, 1, 5, 9, 1, 5, 9, 5, 3, 3)
+	c, err := NewCDT(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 2 crossing segments and one going through a vertex
+	for _, s := range [][2]int{{4, 5}, {6, 7}, {0, 2}} {
+		if err := c.AddConstraint(s[0], s[1]); err != nil {
+			t.Fatalf("AddConstraint(%d, %d) = %v", s[0], s[1], err)
+		}
+	}
+	checkCDT(t, c)
+	for _, s := range [][2]int{{4, 5}, {6, 7}, {0, 2}} {
+		if a, b := c.Points[s[0]], c.Points[s[1]]; !hasConstraint(c, a, b) {
+			t.Errorf("segment %v %v is not constrained", a, b)
+		}
+	}
+	if len(c.Points) != len(pts)+1 {
+		t.Errorf("len(Points) = %d, want %d", len(c.Points), len(pts)+1)
+	}
+}
+
+func TestCDTRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for iter := 0; iter < 50; iter++ {
+		pts := make([]d2.Vec, 100)
+		for i := range pts {
+			pts[i] = d2.Vec{X: math.Floor(rng.Float64() * 100), Y: math.Floor(rng.Float64() * 100)}
+		}
+		c, err := NewCDT(pts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := checkCDT(t, c)
+		var segs [][2]d2.Vec
+		for k := 0; k < 10; k++ {
+			// duplicate points are not vertices
+			s := [2]d2.Vec{pts[rng.Intn(len(pts))], pts[rng.Intn(len(pts))]}
+			a, _ := c.InsertPoint(s[0])
+			b, _ := c.InsertPoint(s[1])
+			if err := c.AddConstraint(a, b); err != nil {
+				t.Fatalf("AddConstraint(%d, %d) = %v", a, b, err)
+			}
+			segs = append(segs, s)
+		}
+		if got := checkCDT(t, c); math.Abs(got-want) > 1e-9*want {
+			t.Errorf("area = %v, want %v", got, want)
+		}
+		for _, s := range segs {
+			if s[0] != s[1] && !hasConstraint(c, s[0], s[1]) {
+				t.Errorf("segment %v is not constrained", s)
+			}
+		}
+	}
+}
+
+// domain returns a triangulation of an L-shaped polygon with a square hole.
+func domain(t *testing.T) *CDT {
+	outer := d2.Path(vecs(0, 0, 20, 0, 20, 10, 10, 10, 10, 20, 0, 20))
+	hole := d2.Path(vecs(2, 2, 2, 6, 6, 6, 6, 2))
+	pts := append(append(vecs(15, 15, 8, 8), outer...), hole...)
+	c, err := NewCDT(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range []d2.Path{outer, hole} {
+		if err := c.AddPath(p, true); err != nil {
+			t.Fatalf("AddPath(%v) = %v", p, err)
+		}
+	}
+	return c
+}
+
+func TestCDTRemoveOutside(t *testing.T) {
+	c := domain(t)
+	c.RemoveOutside()
+	if got := checkCDT(t, c); got != 300-16 {
+		t.Errorf("area = %v, want %v", got, 300-16)
+	}
+	if c.Hull != nil {
+		t.Errorf("Hull = %v, want nil", c.Hull)
+	}
+	for e, opp := range c.Halfedges {
+		if opp == -1 && !c.Constrained[e] {
+			t.Errorf("boundary edge %d is not constrained", e)
+		}
+	}
+}
+
+func TestCDTRefine(t *testing.T) {
+	const (
+		maxArea  = 2
+		minAngle = 20 * math.Pi / 180
+	)
+	for _, remove := range []bool{false, true} {
+		c := domain(t)
+		if remove {
+			c.RemoveOutside()
+		}
+		want := checkCDT(t, c)
+		c.Refine(maxArea, minAngle)
+		if got := checkCDT(t, c); math.Abs(got-want) > 1e-9*want {
+			t.Errorf("area = %v, want %v", got, want)
+		}
+		for i := 0; i < c.Len(); i++ {
+			a, b, cc := c.Triangle(i)
+			if area := (d2.Polygon{a, b, cc}).Area(); area > maxArea {
+				t.Errorf("triangle %d area = %v, want <= %v", i, area, maxArea)
+			}
+			if ang := minTriangleAngle(a, b, cc); ang < minAngle-1e-9 {
+				t.Errorf("triangle %d min angle = %v, want >= %v", i, ang, minAngle)
+			}
+		}
+		if !hasConstraint(c, d2.Vec{X: 20, Y: 10}, d2.Vec{X: 10, Y: 10}) {
+			t.Errorf("constraint has not been preserved")
+		}
+	}
+}
+
+func minTriangleAngle(a, b, c d2.Vec) float64 {
+	angle := func(o, p, q d2.Vec) float64 {
+		u, v := p.Sub(o), q.Sub(o)
+		return math.Abs(math.Atan2(u.Cross(v), u.Dot(v)))
+	}
+	return math.Min(angle(a, b, c), math.Min(angle(b, c, a), angle(c, a, b)))
+}
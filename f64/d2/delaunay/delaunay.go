@@ -0,0 +1,425 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+// Package delaunay computes the Delaunay triangulation, constrained or not,
+// and the Voronoi diagram of sets of d2.Vec.
+package delaunay
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+// ErrDegenerate is returned when the points can't be triangulated, because
+// there are less than 3 distinct points or because they are all collinear.
+var ErrDegenerate = errors.New("delaunay: less than 3 points or collinear points")
+
+// Triangulation is a Delaunay triangulation, represented with half-edges.
+//
+// Triangle t is made of the half-edges 3*t, 3*t+1 and 3*t+2. Half-edge e
+// starts at Points[Triangles[e]] and ends at the start of NextHalfedge(e).
+// Triangles are counter-clockwise (in a Y-up frame).
+type Triangulation struct {
+	// Points are the triangulated points.
+	Points []d2.Vec
+
+	// Triangles holds the index in Points of the starting point of each
+	// half-edge, 3 consecutive half-edges forming a triangle.
+	Triangles []int
+
+	// Halfedges holds the index of the twin of each half-edge, that is the
+	// half-edge of the adjacent triangle going in the opposite direction, or
+	// -1 for half-edges on the convex hull.
+	Halfedges []int
+
+	// Hull holds the indices in Points of the convex hull, counter-clockwise.
+	Hull []int
+}
+
+// NextHalfedge returns the half-edge following e in its triangle.
+func NextHalfedge(e int) int {
+	if e%3 == 2 {
+		return e - 2
+	}
+	return e + 1
+}
+
+// PrevHalfedge returns the half-edge preceding e in its triangle.
+func PrevHalfedge(e int) int {
+	if e%3 == 0 {
+		return e + 2
+	}
+	return e - 1
+}
+
+// Len returns the number of triangles of t.
+func (t *Triangulation) Len() int {
+	return len(t.Triangles) / 3
+}
+
+// Triangle returns the vertices of the i-th triangle of t.
+func (t *Triangulation) Triangle(i int) (a, b, c d2.Vec) {
+	return t.Points[t.Triangles[3*i]], t.Points[t.Triangles[3*i+1]], t.Points[t.Triangles[3*i+2]]
+}
+
+// Neighbors returns the triangles sharing an edge with the i-th triangle of
+// t, -1 meaning that the corresponding edge is on the convex hull. The j-th
+// neighbor is across the edge starting at the j-th vertex of the triangle.
+func (t *Triangulation) Neighbors(i int) [3]int {
+	var n [3]int
+	for j := range n {
+		n[j] = -1
+		if e := t.Halfedges[3*i+j]; e != -1 {
+			n[j] = e / 3
+		}
+	}
+	return n
+}
+
+// EdgesAroundPoint returns the half-edges ending at the point at which the
+// half-edge e ends, in clockwise order and starting with e. If the point is on
+// the convex hull, e must be the first of these half-edges for the result to
+// be complete.
+func (t *Triangulation) EdgesAroundPoint(e int) []int {
+	var edges []int
+	start := e
+	for {
+		edges = append(edges, e)
+		opp := t.Halfedges[NextHalfedge(e)]
+		if opp == -1 || opp == start {
+			break
+		}
+		e = opp
+	}
+	return edges
+}
+
+// Triangulate computes the Delaunay triangulation of points with the sweep
+// hull algorithm of Delaunator, which runs in O(n log n).
+//
+// Duplicate points are ignored, so they do not appear in any triangle.
+// Triangulate returns ErrDegenerate if no triangle can be made.
+func Triangulate(points []d2.Vec) (*Triangulation, error) {
+	n := len(points)
+	if n < 3 {
+		return nil, ErrDegenerate
+	}
+	b := d2.Path(points).Rectangle()
+	c := b.Min.Add(b.Max).Div(2)
+
+	// seed triangle: the point closest to the center, the one closest to it
+	// and the one forming the smallest circumcircle with them.
+	i0, i1, i2 := -1, -1, -1
+	minDist := math.Inf(1)
+	for i, p := range points {
+		if d := dist2(c, p); d < minDist {
+			i0, minDist = i, d
+		}
+	}
+	p0 := points[i0]
+	minDist = math.Inf(1)
+	for i, p := range points {
+		if d := dist2(p0, p); d < minDist && d > 0 {
+			i1, minDist = i, d
+		}
+	}
+	if i1 == -1 {
+		return nil, ErrDegenerate
+	}
+	p1 := points[i1]
+	minRadius := math.Inf(1)
+	for i, p := range points {
+		if i == i0 || i == i1 {
+			continue
+		}
+		if r := circumradius2(p0, p1, p); r < minRadius {
+			i2, minRadius = i, r
+		}
+	}
+	if i2 == -1 {
+		return nil, ErrDegenerate
+	}
+	if orient(p0, p1, points[i2]) == d2.Clockwise {
+		i1, i2 = i2, i1
+		p1 = points[i1]
+	}
+	p2 := points[i2]
+
+	s := &sweep{
+		points:   points,
+		center:   circumcenter(p0, p1, p2),
+		hullPrev: make([]int, n),
+		hullNext: make([]int, n),
+		hullTri:  make([]int, n),
+		hullHash: make([]int, int(math.Ceil(math.Sqrt(float64(n))))),
+	}
+	maxTris := 2*n - 5
+	s.triangles = make([]int, 0, 3*maxTris)
+	s.halfedges = make([]int, 0, 3*maxTris)
+
+	// sort the points by distance from the seed triangle circumcenter
+	dists := make([]float64, n)
+	ids := make([]int, n)
+	for i, p := range points {
+		ids[i] = i
+		dists[i] = dist2(s.center, p)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return dists[ids[i]] < dists[ids[j]]
+	})
+
+	for i := range s.hullHash {
+		s.hullHash[i] = -1
+	}
+	s.hullStart = i0
+	s.hullNext[i0], s.hullPrev[i2] = i1, i1
+	s.hullNext[i1], s.hullPrev[i0] = i2, i2
+	s.hullNext[i2], s.hullPrev[i1] = i0, i0
+	s.hullTri[i0], s.hullTri[i1], s.hullTri[i2] = 0, 1, 2
+	s.hullHash[s.hashKey(p0)] = i0
+	s.hullHash[s.hashKey(p1)] = i1
+	s.hullHash[s.hashKey(p2)] = i2
+	s.addTriangle(i0, i1, i2, -1, -1, -1)
+
+	for k, i := range ids {
+		if k > 0 && points[i] == points[ids[k-1]] || i == i0 || i == i1 || i == i2 {
+			continue
+		}
+		s.add(i)
+	}
+
+	t := &Triangulation{
+		Points:    points,
+		Triangles: s.triangles,
+		Halfedges: s.halfedges,
+	}
+	e := s.hullStart
+	for {
+		t.Hull = append(t.Hull, e)
+		if e = s.hullNext[e]; e == s.hullStart {
+			break
+		}
+	}
+	return t, nil
+}
+
+// sweep holds the state of the sweep hull algorithm.
+type sweep struct {
+	points    []d2.Vec
+	center    d2.Vec
+	triangles []int
+	halfedges []int
+
+	// the advancing convex hull, as a circular doubly linked list of point
+	// indices, hullTri being the hull half-edge starting at each point.
+	hullStart int
+	hullPrev  []int
+	hullNext  []int
+	hullTri   []int
+	// hullHash maps angles around center to hull points, to quickly find
+	// the hull edges visible from a new point.
+	hullHash []int
+
+	edgeStack []int
+}
+
+// add adds the point of index i, located outside the current hull, to the
+// triangulation.
+func (s *sweep) add(i int) {
+	p := s.points[i]
+
+	// find a visible edge on the hull, starting from a close hull point
+	start := 0
+	key := s.hashKey(p)
+	for j := range s.hullHash {
+		start = s.hullHash[(key+j)%len(s.hullHash)]
+		if start != -1 && start != s.hullNext[start] {
+			break
+		}
+	}
+	start = s.hullPrev[start]
+	e := start
+	for !s.visible(p, e, s.hullNext[e]) {
+		if e = s.hullNext[e]; e == start {
+			// p is on the hull, or almost
+			return
+		}
+	}
+
+	t := s.addTriangle(e, i, s.hullNext[e], -1, -1, s.hullTri[e])
+	s.hullTri[i] = s.legalize(t + 2)
+	s.hullTri[e] = t
+
+	// add triangles with the next visible edges, removing them from the hull
+	n := s.hullNext[e]
+	for q := s.hullNext[n]; s.visible(p, n, q); q = s.hullNext[n] {
+		t = s.addTriangle(n, i, q, s.hullTri[i], -1, s.hullTri[n])
+		s.hullTri[i] = s.legalize(t + 2)
+		s.hullNext[n] = n
+		n = q
+	}
+
+	// same with the previous visible edges
+	if e == start {
+		for q := s.hullPrev[e]; s.visible(p, q, e); q = s.hullPrev[e] {
+			t = s.addTriangle(q, i, e, -1, s.hullTri[e], s.hullTri[q])
+			s.legalize(t + 2)
+			s.hullTri[q] = t
+			s.hullNext[e] = e
+			e = q
+		}
+	}
+
+	s.hullStart = e
+	s.hullPrev[i], s.hullNext[e] = e, i
+	s.hullPrev[n], s.hullNext[i] = i, n
+	s.hullHash[s.hashKey(p)] = i
+	s.hullHash[s.hashKey(s.points[e])] = e
+}
+
+// visible reports whether the hull edge from a to b is visible from p.
+func (s *sweep) visible(p d2.Vec, a, b int) bool {
+	return orient(p, s.points[a], s.points[b]) == d2.Clockwise
+}
+
+// hashKey returns the hull hash bucket of p.
+func (s *sweep) hashKey(p d2.Vec) int {
+	return int(math.Floor(pseudoAngle(p.Sub(s.center))*float64(len(s.hullHash)))) % len(s.hullHash)
+}
+
+// pseudoAngle returns a number in [0, 1] that increases monotonically with
+// the angle of v.
+func pseudoAngle(v d2.Vec) float64 {
+	p := v.X / (math.Abs(v.X) + math.Abs(v.Y))
+	if v.Y > 0 {
+		return (3 - p) / 4
+	}
+	return (1 + p) / 4
+}
+
+// addTriangle adds the triangle i0, i1, i2 whose half-edges have the twins
+// a, b and c, and returns its first half-edge.
+func (s *sweep) addTriangle(i0, i1, i2, a, b, c int) int {
+	t := len(s.triangles)
+	s.triangles = append(s.triangles, i0, i1, i2)
+	s.halfedges = append(s.halfedges, -1, -1, -1)
+	s.link(t, a)
+	s.link(t+1, b)
+	s.link(t+2, c)
+	return t
+}
+
+func (s *sweep) link(a, b int) {
+	s.halfedges[a] = b
+	if b != -1 {
+		s.halfedges[b] = a
+	}
+}
+
+// legalize flips the half-edge a, and recursively the edges of the resulting
+// triangles, until they all satisfy the Delaunay condition. It returns the
+// half-edge preceding a in its triangle after the flips.
+func (s *sweep) legalize(a int) int {
+	var ar int
+	s.edgeStack = s.edgeStack[:0]
+	for {
+		b := s.halfedges[a]
+
+		//         pl                    pl
+		//        /||\                  /  \
+		//     al/ || \bl            al/    \a
+		//      /  ||  \              /      \
+		//     /  a||b  \    flip    /___ar___\
+		//   p0\   ||   /p1   =>   p0\---bl---/p1
+		//      \  ||  /              \      /
+		//     ar\ || /br             b\    /br
+		//        \||/                  \  /
+		//         pr                    pr
+		a0 := a - a%3
+		ar = a0 + (a+2)%3
+		if b == -1 {
+			// hull edge
+			if len(s.edgeStack) == 0 {
+				break
+			}
+			a = s.popEdge()
+			continue
+		}
+		b0 := b - b%3
+		al := a0 + (a+1)%3
+		bl := b0 + (b+2)%3
+
+		p0 := s.triangles[ar]
+		pr := s.triangles[a]
+		pl := s.triangles[al]
+		p1 := s.triangles[bl]
+		if !inCircle(s.points[p0], s.points[pr], s.points[pl], s.points[p1]) {
+			if len(s.edgeStack) == 0 {
+				break
+			}
+			a = s.popEdge()
+			continue
+		}
+
+		s.triangles[a] = p1
+		s.triangles[b] = p0
+		hbl := s.halfedges[bl]
+		if hbl == -1 {
+			// the edge was swapped on the other side of the hull, fix the
+			// hull half-edge reference
+			e := s.hullStart
+			for {
+				if s.hullTri[e] == bl {
+					s.hullTri[e] = a
+					break
+				}
+				if e = s.hullPrev[e]; e == s.hullStart {
+					break
+				}
+			}
+		}
+		s.link(a, hbl)
+		s.link(b, s.halfedges[ar])
+		s.link(ar, bl)
+		s.edgeStack = append(s.edgeStack, b0+(b+1)%3)
+	}
+	return ar
+}
+
+func (s *sweep) popEdge() int {
+	e := s.edgeStack[len(s.edgeStack)-1]
+	s.edgeStack = s.edgeStack[:len(s.edgeStack)-1]
+	return e
+}
+
+func dist2(a, b d2.Vec) float64 {
+	d := a.Sub(b)
+	return d.X*d.X + d.Y*d.Y
+}
+
+// circumradius2 returns the squared radius of the circumcircle of a, b, c, or
+// +Inf if they're collinear.
+func circumradius2(a, b, c d2.Vec) float64 {
+	d := circumcenter(a, b, c).Sub(a)
+	r := d.X*d.X + d.Y*d.Y
+	if math.IsNaN(r) {
+		return math.Inf(1)
+	}
+	return r
+}
+
+// circumcenter returns the center of the circumcircle of a, b, c.
+func circumcenter(a, b, c d2.Vec) d2.Vec {
+	d, e := b.Sub(a), c.Sub(a)
+	bl := d.X*d.X + d.Y*d.Y
+	cl := e.X*e.X + e.Y*e.Y
+	k := 0.5 / (d.X*e.Y - d.Y*e.X)
+	return d2.Vec{
+		X: a.X + (e.Y*bl-d.Y*cl)*k,
+		Y: a.Y + (d.X*cl-e.X*bl)*k,
+	}
+}
@@ -0,0 +1,217 @@
+package delaunay
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+// checkTriangulation checks the half-edges, the Delaunay condition, using a
+// brute force method if brute is true, and the convex hull of t.
+func checkTriangulation(tb testing.TB, t *Triangulation, brute bool) {
+	tb.Helper()
+	for e, opp := range t.Halfedges {
+		if opp == -1 {
+			continue
+		}
+		if t.Halfedges[opp] != e {
+			tb.Fatalf("half-edge %d: twin of twin is %d", e, t.Halfedges[opp])
+		}
+		if t.Triangles[e] != t.Triangles[NextHalfedge(opp)] ||
+			t.Triangles[opp] != t.Triangles[NextHalfedge(e)] {
+			tb.Fatalf("half-edge %d and its twin %d do not share the same points", e, opp)
+		}
+		// Delaunay condition across each edge
+		p := t.Points[t.Triangles[PrevHalfedge(opp)]]
+		a, b, c := t.Triangle(e / 3)
+		if inCircle(a, b, c, p) && circleMargin(a, b, c, p) > 1e-9 {
+			tb.Fatalf("triangle %d is not Delaunay: %v is in circle %v %v %v", e/3, p, a, b, c)
+		}
+	}
+	var area float64
+	for i := 0; i < t.Len(); i++ {
+		a, b, c := t.Triangle(i)
+		tri := d2.Polygon{a, b, c}
+		if orient(tri[0], tri[1], tri[2]) != d2.CounterClockwise {
+			tb.Fatalf("triangle %d %v is not counter-clockwise", i, tri)
+		}
+		area += tri.Area()
+		if !brute {
+			continue
+		}
+		for _, p := range t.Points {
+			if p != a && p != b && p != c && inCircle(a, b, c, p) && circleMargin(a, b, c, p) > 1e-9 {
+				tb.Fatalf("triangle %d %v contains %v in its circumcircle", i, tri, p)
+			}
+		}
+	}
+
+	hull := d2.ConvexHull(t.Points)
+	hullArea := d2.Polygon(hull).Area()
+	if d := area - hullArea; d > 1e-9*hullArea || d < -1e-9*hullArea {
+		tb.Fatalf("triangles area = %v, want hull area %v", area, hullArea)
+	}
+	var nhull int
+	for i, j := range t.Hull {
+		if t.Hull[(i+1)%len(t.Hull)] == j {
+			tb.Fatalf("duplicate point in hull")
+		}
+		nhull++
+	}
+	if nhull < len(hull) {
+		tb.Fatalf("hull has %d points, want at least %d", nhull, len(hull))
+	}
+}
+
+// circleMargin returns by how much p is inside the circumcircle of a, b, c,
+// relatively to its radius.
+func circleMargin(a, b, c, p d2.Vec) float64 {
+	o := circumcenter(a, b, c)
+	r := o.Dist(a)
+	return (r - o.Dist(p)) / r
+}
+
+// vecs returns the points whose coordinates are xy, in pairs.
+func vecs(xy ...float64) []d2.Vec {
+	pts := make([]d2.Vec, len(xy)/2)
+	for i := range pts {
+		pts[i] = d2.Vec{X: xy[2*i], Y: xy[2*i+1]}
+	}
+	return pts
+}
+
+func TestTriangulate(t *testing.T) {
+	var tests = []struct {
+		name   string
+		points []d2.Vec
+		ntris  int
+	}{
+		{"triangle", vecs(0, 0, 1, 0, 0, 1), 1},
+		{"cw triangle", vecs(0, 0, 0, 1, 1, 0), 1},
+		{"square", vecs(0, 0, 1, 0, 1, 1, 0, 1), 2},
+		{"square and center", vecs(0, 0, 2, 0, 2, 2, 0, 2, 1, 1), 4},
+		{"duplicates", vecs(0, 0, 2, 0, 0, 0, 2, 2, 0, 2, 2, 2, 1, 1), 4},
+		{"collinear on hull", vecs(0, 0, 1, 0, 2, 0, 1, 1), 2},
+		// the origin is not a duplicate of the skipped seed points
+		{"origin after seeds", vecs(2, 3, 0, 0, 1, 3, 2, 2), 2},
+	}
+	for _, tt := range tests {
+		tri, err := Triangulate(tt.points)
+		if err != nil {
+			t.Errorf("%s: Triangulate() error = %v", tt.name, err)
+			continue
+		}
+		if tri.Len() != tt.ntris {
+			t.Errorf("%s: Triangulate().Len() = %v, want %v", tt.name, tri.Len(), tt.ntris)
+		}
+		checkTriangulation(t, tri, true)
+	}
+
+	for _, pts := range [][]d2.Vec{
+		nil,
+		vecs(0, 0, 1, 1),
+		vecs(0, 0, 0, 0, 0, 0),
+		vecs(0, 0, 1, 1, 2, 2, 3, 3),
+	} {
+		if _, err := Triangulate(pts); err != ErrDegenerate {
+			t.Errorf("Triangulate(%v) error = %v, want %v", pts, err, ErrDegenerate)
+		}
+	}
+}
+
+func TestTriangulateRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{10, 100, 1000} {
+		pts := make([]d2.Vec, n)
+		for i := range pts {
+			pts[i] = d2.Vec{X: rng.Float64() * 100, Y: rng.Float64() * 100}
+		}
+		tri, err := Triangulate(pts)
+		if err != nil {
+			t.Fatalf("Triangulate() error = %v", err)
+		}
+		if want := 2*n - 2 - len(tri.Hull); tri.Len() != want {
+			t.Errorf("Triangulate() of %d points has %d triangles, want %d", n, tri.Len(), want)
+		}
+		checkTriangulation(t, tri, n <= 100)
+	}
+}
+
+func TestTriangulateGrid(t *testing.T) {
+	// many cocircular and collinear points
+	var pts []d2.Vec
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			pts = append(pts, d2.Vec{X: float64(x), Y: float64(y)})
+		}
+	}
+	tri, err := Triangulate(pts)
+	if err != nil {
+		t.Fatalf("Triangulate() error = %v", err)
+	}
+	if tri.Len() != 2*19*19 {
+		t.Errorf("Triangulate().Len() = %v, want %v", tri.Len(), 2*19*19)
+	}
+	checkTriangulation(t, tri, false)
+}
+
+func TestTriangulateNearlyCollinear(t *testing.T) {
+	// points of a line, that are not exactly collinear due to rounding, and
+	// points far away on a circle: the orientation of the thin triangles they
+	// form must not be decided by rounding errors.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		var pts []d2.Vec
+		for j := 0; j < 50; j++ {
+			x := rng.Float64()
+			a := rng.Float64() * 2 * math.Pi
+			pts = append(pts,
+				d2.Vec{X: 1 + 0.1*x, Y: 1 + 0.3*x},
+				d2.Vec{X: 1000 * math.Cos(a), Y: 1000 * math.Sin(a)})
+		}
+		tri, err := Triangulate(pts)
+		if err != nil {
+			t.Fatalf("Triangulate() error = %v", err)
+		}
+		checkTriangulation(t, tri, false)
+	}
+}
+
+func TestEdgesAroundPoint(t *testing.T) {
+	pts := vecs(0, 0, 2, 0, 2, 2, 0, 2, 1, 1)
+	tri, err := Triangulate(pts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for e := range tri.Triangles {
+		if tri.Triangles[NextHalfedge(e)] != 4 {
+			continue
+		}
+		edges := tri.EdgesAroundPoint(e)
+		if len(edges) != 4 {
+			t.Errorf("EdgesAroundPoint(%d) = %v, want 4 half-edges", e, edges)
+		}
+		for _, e2 := range edges {
+			if end := tri.Triangles[NextHalfedge(e2)]; end != 4 {
+				t.Errorf("EdgesAroundPoint(%d): half-edge %d ends at %d, want 4", e, e2, end)
+			}
+		}
+		break
+	}
+}
+
+func BenchmarkTriangulate(b *testing.B) {
+	rng := rand.New(rand.NewSource(1))
+	pts := make([]d2.Vec, 100000)
+	for i := range pts {
+		pts[i] = d2.Vec{X: rng.Float64() * 1000, Y: rng.Float64() * 1000}
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Triangulate(pts); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package delaunay
+
+import (
+	"math"
+	"math/big"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+// The geometric predicates are first evaluated with floating-point
+// arithmetic. When the result is too close to 0 to be trusted, according to
+// the error bounds given by Shewchuk in "Adaptive Precision Floating-Point
+// Arithmetic and Fast Robust Geometric Predicates", it's computed again with
+// exact rational arithmetic.
+
+const (
+	epsilon     = 1.1102230246251565e-16 // 2^-53
+	ccwErrBound = (3 + 16*epsilon) * epsilon
+	iccErrBound = (10 + 96*epsilon) * epsilon
+)
+
+// orient returns the exact orientation of the triangle a, b, c.
+func orient(a, b, c d2.Vec) d2.Orientation {
+	detLeft := (a.X - c.X) * (b.Y - c.Y)
+	detRight := (a.Y - c.Y) * (b.X - c.X)
+	det := detLeft - detRight
+	if bound := ccwErrBound * (math.Abs(detLeft) + math.Abs(detRight)); det > bound || -det > bound {
+		return sign(det)
+	}
+
+	ax, ay, bx, by, cx, cy := rat(a.X), rat(a.Y), rat(b.X), rat(b.Y), rat(c.X), rat(c.Y)
+	l := mul(sub(ax, cx), sub(by, cy))
+	r := mul(sub(ay, cy), sub(bx, cx))
+	switch l.Cmp(r) {
+	case 1:
+		return d2.CounterClockwise
+	case -1:
+		return d2.Clockwise
+	}
+	return d2.Collinear
+}
+
+func sign(det float64) d2.Orientation {
+	switch {
+	case det > 0:
+		return d2.CounterClockwise
+	case det < 0:
+		return d2.Clockwise
+	}
+	return d2.Collinear
+}
+
+// inCircle reports whether p is strictly inside the circumcircle of the
+// counter-clockwise triangle a, b, c.
+func inCircle(a, b, c, p d2.Vec) bool {
+	adx, ady := a.X-p.X, a.Y-p.Y
+	bdx, bdy := b.X-p.X, b.Y-p.Y
+	cdx, cdy := c.X-p.X, c.Y-p.Y
+
+	bdxcdy, cdxbdy := bdx*cdy, cdx*bdy
+	alift := adx*adx + ady*ady
+	cdxady, adxcdy := cdx*ady, adx*cdy
+	blift := bdx*bdx + bdy*bdy
+	adxbdy, bdxady := adx*bdy, bdx*ady
+	clift := cdx*cdx + cdy*cdy
+
+	det := alift*(bdxcdy-cdxbdy) + blift*(cdxady-adxcdy) + clift*(adxbdy-bdxady)
+	permanent := (math.Abs(bdxcdy)+math.Abs(cdxbdy))*alift +
+		(math.Abs(cdxady)+math.Abs(adxcdy))*blift +
+		(math.Abs(adxbdy)+math.Abs(bdxady))*clift
+	if bound := iccErrBound * permanent; det > bound || -det > bound {
+		return det > 0
+	}
+
+	px, py := rat(p.X), rat(p.Y)
+	ax, ay := sub(rat(a.X), px), sub(rat(a.Y), py)
+	bx, by := sub(rat(b.X), px), sub(rat(b.Y), py)
+	cx, cy := sub(rat(c.X), px), sub(rat(c.Y), py)
+	al := add(mul(ax, ax), mul(ay, ay))
+	bl := add(mul(bx, bx), mul(by, by))
+	cl := add(mul(cx, cx), mul(cy, cy))
+	d := mul(al, sub(mul(bx, cy), mul(cx, by)))
+	d = add(d, mul(bl, sub(mul(cx, ay), mul(ax, cy))))
+	d = add(d, mul(cl, sub(mul(ax, by), mul(bx, ay))))
+	return d.Sign() > 0
+}
+
+func rat(x float64) *big.Rat     { return new(big.Rat).SetFloat64(x) }
+func add(x, y *big.Rat) *big.Rat { return new(big.Rat).Add(x, y) }
+func sub(x, y *big.Rat) *big.Rat { return new(big.Rat).Sub(x, y) }
+func mul(x, y *big.Rat) *big.Rat { return new(big.Rat).Mul(x, y) }
@@ -0,0 +1,218 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package delaunay
+
+import (
+	"sort"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+// Voronoi is a Voronoi diagram whose cells are clipped to a rectangle.
+//
+// The cell of a site is the region of the plane made of the points that are
+// closer to this site than to any other one.
+type Voronoi struct {
+	// Sites are the points around which the cells are built.
+	Sites []d2.Vec
+
+	// Bounds is the rectangle the cells are clipped to.
+	Bounds d2.Rectangle
+
+	// Cells holds the cell of each site, as a counter-clockwise (in a Y-up
+	// frame) convex polygon. A cell is nil if it doesn't intersect Bounds or
+	// if its site is a duplicate of another one, in which case only one of
+	// the duplicate sites has a cell.
+	Cells []d2.Polygon
+
+	// Neighbors holds, for each site, the sorted indices of the sites whose
+	// cells share an edge with its cell.
+	Neighbors [][]int
+}
+
+// NewVoronoi returns the Voronoi diagram of sites, with cells clipped to
+// bounds. It's computed as the dual of the Delaunay triangulation of sites,
+// but also handles the cases where there's no triangulation, for example
+// when sites are collinear.
+func NewVoronoi(sites []d2.Vec, bounds d2.Rectangle) *Voronoi {
+	t, err := Triangulate(sites)
+	if err != nil {
+		adj, used := collinearAdjacency(sites)
+		return voronoi(sites, bounds, adj, used)
+	}
+	return t.Voronoi(bounds)
+}
+
+// Voronoi returns the Voronoi diagram, dual of t, with cells clipped to
+// bounds. The sites of the diagram are the points of t.
+func (t *Triangulation) Voronoi(bounds d2.Rectangle) *Voronoi {
+	adj := make([][]int, len(t.Points))
+	used := make([]bool, len(t.Points))
+	for e, opp := range t.Halfedges {
+		if opp > e {
+			// interior edges are only visited once
+			continue
+		}
+		a, b := t.Triangles[e], t.Triangles[NextHalfedge(e)]
+		adj[a] = append(adj[a], b)
+		adj[b] = append(adj[b], a)
+		used[a], used[b] = true, true
+	}
+	return voronoi(t.Points, bounds, adj, used)
+}
+
+// collinearAdjacency returns, for sites that are all collinear, the adjacent
+// sites of each site along their line. Only the first of duplicate sites is
+// used.
+func collinearAdjacency(sites []d2.Vec) (adj [][]int, used []bool) {
+	ids := make([]int, len(sites))
+	for i := range ids {
+		ids[i] = i
+	}
+	sort.SliceStable(ids, func(i, j int) bool {
+		a, b := sites[ids[i]], sites[ids[j]]
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Y < b.Y
+	})
+
+	adj = make([][]int, len(sites))
+	used = make([]bool, len(sites))
+	prev := -1
+	for _, i := range ids {
+		if prev != -1 && sites[i] == sites[prev] {
+			continue
+		}
+		used[i] = true
+		if prev != -1 {
+			adj[prev] = append(adj[prev], i)
+			adj[i] = append(adj[i], prev)
+		}
+		prev = i
+	}
+	return adj, used
+}
+
+// voronoi builds the Voronoi diagram of sites. The cell of each used site is
+// obtained by clipping bounds with the bisectors between the site and its
+// adjacent sites in adj, which must contain at least its Voronoi neighbors.
+func voronoi(sites []d2.Vec, bounds d2.Rectangle, adj [][]int, used []bool) *Voronoi {
+	v := &Voronoi{
+		Sites:     sites,
+		Bounds:    bounds,
+		Cells:     make([]d2.Polygon, len(sites)),
+		Neighbors: make([][]int, len(sites)),
+	}
+	if bounds.Empty() {
+		return v
+	}
+
+	// points closer than tol are merged, so that cocircular sites do not
+	// generate tiny edges and spurious neighbors.
+	tol := 1e-10 * (bounds.Dx() + bounds.Dy())
+	rect := cell{
+		poly: d2.Polygon{
+			bounds.Min,
+			{X: bounds.Max.X, Y: bounds.Min.Y},
+			bounds.Max,
+			{X: bounds.Min.X, Y: bounds.Max.Y},
+		},
+		sites: []int{-1, -1, -1, -1},
+	}
+	for i, p := range sites {
+		if !used[i] {
+			continue
+		}
+		c := rect
+		for _, j := range adj[i] {
+			if c = c.clip(p, sites[j], j, tol); len(c.poly) < 3 {
+				break
+			}
+		}
+		if len(c.poly) < 3 {
+			continue
+		}
+		v.Cells[i] = c.poly
+		for _, j := range c.sites {
+			if j != -1 {
+				v.Neighbors[i] = append(v.Neighbors[i], j)
+			}
+		}
+	}
+
+	// the edge shared by 2 cells is computed twice, make sure both cells
+	// agree on being neighbors.
+	for i, ns := range v.Neighbors {
+		for _, j := range ns {
+			if !contains(v.Neighbors[j], i) {
+				v.Neighbors[j] = append(v.Neighbors[j], i)
+			}
+		}
+	}
+	for _, ns := range v.Neighbors {
+		sort.Ints(ns)
+	}
+	return v
+}
+
+// A cell is a convex polygon whose k-th edge, going from poly[k] to
+// poly[k+1], lies on the bisector with the site of index sites[k], or on the
+// bounds if sites[k] is -1.
+type cell struct {
+	poly  d2.Polygon
+	sites []int
+}
+
+// clip returns the part of c that is closer to p than to q, the site of index
+// j.
+func (c cell) clip(p, q d2.Vec, j int, tol float64) cell {
+	// f(x) = (x-m).d is positive on the side of q.
+	m := p.Add(q).Div(2)
+	d := q.Sub(p)
+	eps := tol * d.Len()
+	f := func(x d2.Vec) float64 { return x.Sub(m).Dot(d) }
+
+	var res cell
+	push := func(x d2.Vec, site int) {
+		if n := len(res.poly); n > 0 && res.poly[n-1].Dist(x) <= tol {
+			// drop the null edge
+			res.sites[n-1] = site
+			return
+		}
+		res.poly = append(res.poly, x)
+		res.sites = append(res.sites, site)
+	}
+
+	n := len(c.poly)
+	for k, cur := range c.poly {
+		next := c.poly[(k+1)%n]
+		fc, fn := f(cur), f(next)
+		in, nextIn := fc <= eps, fn <= eps
+		switch {
+		case in && nextIn:
+			push(cur, c.sites[k])
+		case in:
+			push(cur, c.sites[k])
+			push(cur.Add(next.Sub(cur).Mul(fc/(fc-fn))), j)
+		case nextIn:
+			push(cur.Add(next.Sub(cur).Mul(fc/(fc-fn))), c.sites[k])
+		}
+	}
+	if n := len(res.poly); n > 1 && res.poly[n-1].Dist(res.poly[0]) <= tol {
+		res.poly, res.sites = res.poly[:n-1], res.sites[:n-1]
+	}
+	return res
+}
+
+// contains reports whether s contains i.
+func contains(s []int, i int) bool {
+	for _, j := range s {
+		if j == i {
+			return true
+		}
+	}
+	return false
+}
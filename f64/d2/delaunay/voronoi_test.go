@@ -0,0 +1,154 @@
+package delaunay
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+// checkVoronoi checks that the cells of v are convex, counter-clockwise, cover
+// the bounds and that their vertices are not closer to another site.
+func checkVoronoi(tb testing.TB, v *Voronoi) {
+	tb.Helper()
+	tol := 1e-9 * (v.Bounds.Dx() + v.Bounds.Dy())
+	var area float64
+	for i, c := range v.Cells {
+		if c == nil {
+			continue
+		}
+		if !c.IsConvex() || c.Orientation() != d2.CounterClockwise {
+			tb.Fatalf("cell %d %v is not convex and counter-clockwise", i, c)
+		}
+		area += c.Area()
+		for _, p := range c {
+			if p.X < v.Bounds.Min.X-tol || p.X > v.Bounds.Max.X+tol ||
+				p.Y < v.Bounds.Min.Y-tol || p.Y > v.Bounds.Max.Y+tol {
+				tb.Fatalf("cell %d: vertex %v is out of bounds %v", i, p, v.Bounds)
+			}
+			d := p.Dist(v.Sites[i])
+			for j, s := range v.Sites {
+				if p.Dist(s) < d-tol {
+					tb.Fatalf("cell %d: vertex %v is closer to site %d than to site %d", i, p, j, i)
+				}
+			}
+		}
+		for _, j := range v.Neighbors[i] {
+			if !contains(v.Neighbors[j], i) {
+				tb.Fatalf("site %d is a neighbor of %d but not the opposite", j, i)
+			}
+		}
+	}
+	want := v.Bounds.Dx() * v.Bounds.Dy()
+	if math.Abs(area-want) > 1e-9*want {
+		tb.Fatalf("cells area = %v, want %v", area, want)
+	}
+}
+
+func TestVoronoi(t *testing.T) {
+	bounds := d2.Rect(0, 0, 10, 10)
+	var tests = []struct {
+		name      string
+		sites     []d2.Vec
+		areas     []float64
+		neighbors [][]int
+	}{
+		{
+			name:      "single site",
+			sites:     vecs(2, 3),
+			areas:     []float64{100},
+			neighbors: [][]int{nil},
+		},
+		{
+			name:      "2 sites",
+			sites:     vecs(2, 5, 6, 5),
+			areas:     []float64{40, 60},
+			neighbors: [][]int{{1}, {0}},
+		},
+		{
+			name:      "collinear",
+			sites:     vecs(1, 1, 5, 5, 3, 3),
+			areas:     []float64{8, 68, 24},
+			neighbors: [][]int{{2}, {2}, {0, 1}},
+		},
+		{
+			name:      "duplicates",
+			sites:     vecs(2, 5, 2, 5, 6, 5),
+			areas:     []float64{40, 0, 60},
+			neighbors: [][]int{{2}, nil, {0}},
+		},
+		{
+			name:      "quadrants",
+			sites:     vecs(2, 2, 8, 2, 8, 8, 2, 8),
+			areas:     []float64{25, 25, 25, 25},
+			neighbors: [][]int{{1, 3}, {0, 2}, {1, 3}, {0, 2}},
+		},
+		{
+			name:      "outside bounds",
+			sites:     vecs(2, 5, 8, 5, 30, 5),
+			areas:     []float64{50, 50, 0},
+			neighbors: [][]int{{1}, {0}, nil},
+		},
+	}
+	for _, tt := range tests {
+		v := NewVoronoi(tt.sites, bounds)
+		checkVoronoi(t, v)
+		for i, c := range v.Cells {
+			if got := c.Area(); math.Abs(got-tt.areas[i]) > 1e-9 {
+				t.Errorf("%s: cell %d area = %v, want %v", tt.name, i, got, tt.areas[i])
+			}
+		}
+		if !reflect.DeepEqual(v.Neighbors, tt.neighbors) {
+			t.Errorf("%s: Neighbors = %v, want %v", tt.name, v.Neighbors, tt.neighbors)
+		}
+	}
+
+	v := NewVoronoi(vecs(1, 1, 2, 2, 3, 1), d2.ZR)
+	for i, c := range v.Cells {
+		if c != nil {
+			t.Errorf("empty bounds: cell %d = %v, want nil", i, c)
+		}
+	}
+}
+
+func TestVoronoiGrid(t *testing.T) {
+	// the Delaunay diagonals of the cells of a grid are null Voronoi edges.
+	const n = 10
+	var sites []d2.Vec
+	for y := 0; y < n; y++ {
+		for x := 0; x < n; x++ {
+			sites = append(sites, d2.Vec{X: float64(x) + 0.5, Y: float64(y) + 0.5})
+		}
+	}
+	v := NewVoronoi(sites, d2.Rect(0, 0, n, n))
+	checkVoronoi(t, v)
+	for i, c := range v.Cells {
+		if len(c) != 4 || math.Abs(c.Area()-1) > 1e-9 {
+			t.Fatalf("cell %d = %v, want a unit square", i, c)
+		}
+		x, y := i%n, i/n
+		var want []int
+		for _, j := range []int{i - n, i - 1, i + 1, i + n} {
+			if j >= 0 && j < n*n && (j%n == x || j/n == y) {
+				want = append(want, j)
+			}
+		}
+		if !reflect.DeepEqual(v.Neighbors[i], want) {
+			t.Fatalf("site %d: Neighbors = %v, want %v", i, v.Neighbors[i], want)
+		}
+	}
+}
+
+func TestVoronoiRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for _, n := range []int{3, 10, 100, 500} {
+		sites := make([]d2.Vec, n)
+		for i := range sites {
+			// some sites are out of bounds
+			sites[i] = d2.Vec{X: rng.Float64()*120 - 10, Y: rng.Float64()*120 - 10}
+		}
+		checkVoronoi(t, NewVoronoi(sites, d2.Rect(0, 0, 100, 100)))
+	}
+}
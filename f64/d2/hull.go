@@ -0,0 +1,116 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import "sort"
+
+// ConvexHull returns the convex hull of points, computed with Andrew's
+// monotone chain algorithm. points is not modified.
+//
+// The hull is counter-clockwise (in a Y-up frame) and starts at the point
+// having the lowest X, then the lowest Y. Only the extreme points are kept:
+// points lying on the hull edges are discarded, see ConvexHullCollinear.
+//
+// Duplicate points are ignored. If there are less than 3 distinct points or
+// if they are all collinear, the hull is degenerate and ConvexHull returns the
+// 0, 1 or 2 extreme points, sorted by X then Y.
+func ConvexHull(points []Vec) Path {
+	return convexHull(points, false)
+}
+
+// ConvexHullCollinear is like ConvexHull but also keeps the points lying on
+// the hull edges.
+//
+// If all points are collinear, it returns all the distinct points, sorted by X
+// then Y.
+func ConvexHullCollinear(points []Vec) Path {
+	return convexHull(points, true)
+}
+
+func convexHull(points []Vec, collinear bool) Path {
+	pts := sortedUnique(points)
+	if len(pts) < 3 {
+		return pts
+	}
+
+	if collinear && allCollinear(pts) {
+		// both chains would contain all the points
+		return pts
+	}
+	lower := hullChain(pts, collinear)
+	upper := hullChain(reversed(pts), collinear)
+
+	// each chain ends with the first point of the other one
+	return append(lower[:len(lower)-1], upper[:len(upper)-1]...)
+}
+
+// hullChain returns half of the convex hull of pts, sorted along the X axis,
+// which is the lower half if pts are in increasing order.
+func hullChain(pts []Vec, collinear bool) Path {
+	s := NewVecStack()
+	for i := range pts {
+		p := &pts[i]
+		for s.Len() >= 2 {
+			top := s.PeekN(2)
+			cr := top[0].Sub(*top[1]).Cross(p.Sub(*top[1]))
+			if cr > 0 || collinear && cr == 0 {
+				break
+			}
+			s.Pop()
+		}
+		s.Push(p)
+	}
+
+	chain := make(Path, s.Len())
+	for i := len(chain) - 1; i >= 0; i-- {
+		chain[i] = *s.Pop()
+	}
+	return chain
+}
+
+// sortedUnique returns a copy of points, sorted by X then Y and without
+// duplicates.
+func sortedUnique(points []Vec) Path {
+	if len(points) == 0 {
+		return nil
+	}
+	pts := make(Path, len(points))
+	copy(pts, points)
+	sort.Slice(pts, func(i, j int) bool {
+		if pts[i].X != pts[j].X {
+			return pts[i].X < pts[j].X
+		}
+		return pts[i].Y < pts[j].Y
+	})
+	n := 1
+	for _, v := range pts[1:] {
+		if v != pts[n-1] {
+			pts[n] = v
+			n++
+		}
+	}
+	return pts[:n]
+}
+
+// allCollinear reports whether all the points of pts are on the line joining
+// the first and last ones.
+func allCollinear(pts []Vec) bool {
+	a, b := pts[0], pts[len(pts)-1]
+	for _, v := range pts[1 : len(pts)-1] {
+		if Orient(a, b, v) != Collinear {
+			return false
+		}
+	}
+	return true
+}
+
+// reversed returns a reversed copy of p.
+func reversed(p Path) Path {
+	r := make(Path, len(p))
+	for i, v := range p {
+		r[len(p)-1-i] = v
+	}
+	return r
+}
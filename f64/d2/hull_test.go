@@ -0,0 +1,70 @@
+package d2
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestConvexHull(t *testing.T) {
+	var tests = []struct {
+		points    []Vec
+		want      Path
+		wantColin Path
+	}{
+		{nil, nil, nil},
+		{[]Vec{{1, 1}}, Path{{1, 1}}, Path{{1, 1}}},
+		{[]Vec{{1, 1}, {1, 1}, {1, 1}}, Path{{1, 1}}, Path{{1, 1}}},
+		{[]Vec{{2, 2}, {1, 1}}, Path{{1, 1}, {2, 2}}, Path{{1, 1}, {2, 2}}},
+		{
+			[]Vec{{2, 2}, {0, 0}, {3, 3}, {1, 1}, {2, 2}},
+			Path{{0, 0}, {3, 3}},
+			Path{{0, 0}, {1, 1}, {2, 2}, {3, 3}},
+		},
+		{
+			[]Vec{{0, 0}, {2, 0}, {2, 2}, {0, 2}, {1, 1}},
+			Path{{0, 0}, {2, 0}, {2, 2}, {0, 2}},
+			Path{{0, 0}, {2, 0}, {2, 2}, {0, 2}},
+		},
+		{
+			// points on edges and duplicates
+			[]Vec{{1, 0}, {0, 0}, {2, 0}, {2, 1}, {2, 2}, {0, 2}, {0, 1}, {1, 1}, {2, 2}},
+			Path{{0, 0}, {2, 0}, {2, 2}, {0, 2}},
+			Path{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}, {0, 2}, {0, 1}},
+		},
+		{
+			[]Vec{{0, 0}, {4, 0}, {2, 3}, {2, 1}, {1, 1}},
+			Path{{0, 0}, {4, 0}, {2, 3}},
+			Path{{0, 0}, {4, 0}, {2, 3}},
+		},
+	}
+	for _, tt := range tests {
+		if got := ConvexHull(tt.points); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("ConvexHull(%v) = %v, want %v", tt.points, got, tt.want)
+		}
+		if got := ConvexHullCollinear(tt.points); !reflect.DeepEqual(got, tt.wantColin) {
+			t.Errorf("ConvexHullCollinear(%v) = %v, want %v", tt.points, got, tt.wantColin)
+		}
+	}
+}
+
+func TestConvexHullRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for n := 3; n < 200; n += 7 {
+		pts := make([]Vec, n)
+		for i := range pts {
+			pts[i] = Vec{float64(rng.Intn(20)), float64(rng.Intn(20))}
+		}
+		for _, hull := range []Path{ConvexHull(pts), ConvexHullCollinear(pts)} {
+			p := Polygon(hull)
+			if !p.IsConvex() || p.Orientation() != CounterClockwise {
+				t.Fatalf("hull %v of %v is not a ccw convex polygon", hull, pts)
+			}
+			for _, v := range pts {
+				if !p.Contains(v, EvenOdd) && !p.onBoundary(v) {
+					t.Fatalf("hull %v does not contain %v", hull, v)
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,289 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"fmt"
+	"math"
+)
+
+// A PolygonWithHoles is a polygonal area delimited by an outer ring and that
+// may have holes, each one being delimited by an inner ring.
+//
+// It's valid if the outer ring is counter-clockwise, the inner rings are
+// clockwise, all rings are simple, holes are inside the outer ring and do not
+// overlap each other. Rings may touch each other at isolated points.
+type PolygonWithHoles struct {
+	Outer Polygon
+	Holes []Polygon
+}
+
+// Rings returns all the rings of p, the outer ring being the first one.
+func (p PolygonWithHoles) Rings() []Polygon {
+	rings := make([]Polygon, 0, 1+len(p.Holes))
+	rings = append(rings, p.Outer)
+	return append(rings, p.Holes...)
+}
+
+// Area returns the area of p, that is the area of the outer ring minus those
+// of the holes.
+func (p PolygonWithHoles) Area() float64 {
+	a := p.Outer.Area()
+	for _, h := range p.Holes {
+		a -= h.Area()
+	}
+	return a
+}
+
+// Perimeter returns the total length of the rings of p.
+func (p PolygonWithHoles) Perimeter() float64 {
+	l := p.Outer.Perimeter()
+	for _, h := range p.Holes {
+		l += h.Perimeter()
+	}
+	return l
+}
+
+// Rectangle returns the smallest rectangle containing p, which is the one
+// containing its outer ring.
+func (p PolygonWithHoles) Rectangle() Rectangle {
+	return p.Outer.Rectangle()
+}
+
+// Contains reports whether pt is inside the outer ring of p and outside all
+// of its holes.
+//
+// Points located exactly on a ring may be considered either inside or outside.
+func (p PolygonWithHoles) Contains(pt Vec) bool {
+	if !p.Outer.Contains(pt, EvenOdd) {
+		return false
+	}
+	for _, h := range p.Holes {
+		if h.Contains(pt, EvenOdd) {
+			return false
+		}
+	}
+	return true
+}
+
+// Normalize orients p rings in place so that the outer ring is
+// counter-clockwise and the holes are clockwise.
+func (p PolygonWithHoles) Normalize() {
+	p.Outer.EnsureCCW()
+	for _, h := range p.Holes {
+		h.EnsureCW()
+	}
+}
+
+// Hit implements the Hitter interface.
+func (p PolygonWithHoles) Hit(r Ray) (hit RayHit, ok bool) {
+	for _, ring := range p.Rings() {
+		if h, hok := r.HitPolygon(Path(ring)); hok && (!ok || h.T < hit.T) {
+			hit, ok = h, true
+		}
+	}
+	return
+}
+
+// Validate checks that p is valid and returns an error describing the first
+// problem found otherwise.
+func (p PolygonWithHoles) Validate() error {
+	if err := validateRing(p.Outer, CounterClockwise); err != nil {
+		return fmt.Errorf("outer ring: %v", err)
+	}
+	for i, h := range p.Holes {
+		if err := validateRing(h, Clockwise); err != nil {
+			return fmt.Errorf("hole %d: %v", i, err)
+		}
+		if ringsCross(p.Outer, h) || !ringInside(h, p.Outer) {
+			return fmt.Errorf("hole %d: not inside outer ring", i)
+		}
+		for j, h2 := range p.Holes[:i] {
+			if ringsCross(h, h2) || ringInside(h, h2) || ringInside(h2, h) {
+				return fmt.Errorf("hole %d: overlaps hole %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// validateRing checks that ring is simple and has the given orientation.
+func validateRing(ring Polygon, o Orientation) error {
+	if !ring.IsSimple() {
+		return fmt.Errorf("ring is not simple")
+	}
+	if got := ring.Orientation(); got != o {
+		return fmt.Errorf("ring orientation is %v, want %v", got, o)
+	}
+	return nil
+}
+
+// ringsCross reports whether an edge of r1 crosses or overlaps an edge of r2.
+// Edges touching at a single point that is a vertex of one of the ring are
+// not considered crossing.
+func ringsCross(r1, r2 Polygon) bool {
+	for i := range r1 {
+		e1 := r1.Edge(i)
+		for j := range r2 {
+			e2 := r2.Edge(j)
+			k, s := e1.Intersect(e2)
+			if d1, d2, ok := sharedEnd(e1, e2); ok {
+				// edges sharing an end point only have this point in common,
+				// unless they overlap going in the same direction from it.
+				// Their intersection is ill-conditioned when they are almost
+				// collinear.
+				if k == OverlapIntersection && d1.Dot(d2) > 0 {
+					return true
+				}
+				continue
+			}
+			switch k {
+			case OverlapIntersection:
+				return true
+			case PointIntersection:
+				tol := 1e-9 * (e1.Len() + e2.Len())
+				if s.A.Dist(e1.A) > tol && s.A.Dist(e1.B) > tol &&
+					s.A.Dist(e2.A) > tol && s.A.Dist(e2.B) > tol {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// sharedEnd reports whether s1 and s2 have a common end point and returns the
+// vectors going from it to their other end points.
+func sharedEnd(s1, s2 Segment) (d1, d2 Vec, ok bool) {
+	switch {
+	case s1.A == s2.A:
+		return s1.B.Sub(s1.A), s2.B.Sub(s2.A), true
+	case s1.A == s2.B:
+		return s1.B.Sub(s1.A), s2.A.Sub(s2.B), true
+	case s1.B == s2.A:
+		return s1.A.Sub(s1.B), s2.B.Sub(s2.A), true
+	case s1.B == s2.B:
+		return s1.A.Sub(s1.B), s2.A.Sub(s2.B), true
+	}
+	return d1, d2, false
+}
+
+// ringInside reports whether r1 is inside r2, assuming that the rings do not
+// cross. It's the case if no vertex of r1 is strictly outside r2 and if at
+// least one vertex of r1 is strictly inside r2, or, when all vertices of r1
+// lie on r2 boundary, if the middle of one of its edges is inside r2.
+func ringInside(r1, r2 Polygon) bool {
+	for _, v := range r1 {
+		if r2.onBoundary(v) {
+			continue
+		}
+		return r2.Contains(v, EvenOdd)
+	}
+	for i := range r1 {
+		if m := r1.Edge(i).Mid(); !r2.onBoundary(m) {
+			return r2.Contains(m, EvenOdd)
+		}
+	}
+	// r1 and r2 are the same ring
+	return true
+}
+
+// A MultiPolygon is a collection of polygons with holes, whose interiors are
+// disjoint.
+type MultiPolygon []PolygonWithHoles
+
+// Rings returns all the rings of all the polygons of mp. The outer ring of
+// each polygon precedes its holes.
+func (mp MultiPolygon) Rings() []Polygon {
+	var rings []Polygon
+	for _, p := range mp {
+		rings = append(rings, p.Rings()...)
+	}
+	return rings
+}
+
+// Area returns the total area of mp.
+func (mp MultiPolygon) Area() float64 {
+	var a float64
+	for _, p := range mp {
+		a += p.Area()
+	}
+	return a
+}
+
+// Rectangle returns the smallest rectangle containing all polygons of mp.
+func (mp MultiPolygon) Rectangle() Rectangle {
+	if len(mp) == 0 {
+		return ZR
+	}
+	r := mp[0].Rectangle()
+	for _, p := range mp[1:] {
+		pr := p.Rectangle()
+		r.Min.X, r.Min.Y = math.Min(r.Min.X, pr.Min.X), math.Min(r.Min.Y, pr.Min.Y)
+		r.Max.X, r.Max.Y = math.Max(r.Max.X, pr.Max.X), math.Max(r.Max.Y, pr.Max.Y)
+	}
+	return r
+}
+
+// Contains reports whether pt is inside one of the polygons of mp.
+func (mp MultiPolygon) Contains(pt Vec) bool {
+	for _, p := range mp {
+		if p.Contains(pt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Normalize orients, in place, the rings of all the polygons of mp.
+func (mp MultiPolygon) Normalize() {
+	for _, p := range mp {
+		p.Normalize()
+	}
+}
+
+// Hit implements the Hitter interface.
+func (mp MultiPolygon) Hit(r Ray) (hit RayHit, ok bool) {
+	for _, p := range mp {
+		if h, hok := p.Hit(r); hok && (!ok || h.T < hit.T) {
+			hit, ok = h, true
+		}
+	}
+	return
+}
+
+// Validate checks that each polygon of mp is valid and that their interiors
+// do not overlap. It returns an error describing the first problem found.
+func (mp MultiPolygon) Validate() error {
+	for i, p := range mp {
+		if err := p.Validate(); err != nil {
+			return fmt.Errorf("polygon %d: %v", i, err)
+		}
+	}
+	for i, p := range mp {
+		for j, p2 := range mp[:i] {
+			if ringsCross(p.Outer, p2.Outer) {
+				return fmt.Errorf("polygon %d: overlaps polygon %d", i, j)
+			}
+			// a polygon can only be inside another one if it's inside one of
+			// its holes.
+			if ringInside(p.Outer, p2.Outer) && !insideHole(p.Outer, p2) ||
+				ringInside(p2.Outer, p.Outer) && !insideHole(p2.Outer, p) {
+				return fmt.Errorf("polygon %d: overlaps polygon %d", i, j)
+			}
+		}
+	}
+	return nil
+}
+
+// insideHole reports whether ring is inside one of the holes of p.
+func insideHole(ring Polygon, p PolygonWithHoles) bool {
+	for _, h := range p.Holes {
+		if !ringsCross(ring, h) && ringInside(ring, h) {
+			return true
+		}
+	}
+	return false
+}
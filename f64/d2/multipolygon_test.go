@@ -0,0 +1,159 @@
+package d2
+
+import "testing"
+
+// frame returns a counter-clockwise 10x10 square having 2 square holes.
+func frame() PolygonWithHoles {
+	return PolygonWithHoles{
+		Outer: Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}},
+		Holes: []Polygon{
+			{{1, 1}, {1, 4}, {4, 4}, {4, 1}},
+			{{6, 6}, {6, 9}, {9, 9}, {9, 6}},
+		},
+	}
+}
+
+func TestPolygonWithHoles(t *testing.T) {
+	p := frame()
+	if got := p.Area(); got != 82 {
+		t.Errorf("Area() = %v, want 82", got)
+	}
+	if got := p.Perimeter(); got != 64 {
+		t.Errorf("Perimeter() = %v, want 64", got)
+	}
+	if got := p.Rectangle(); got != Rect(0, 0, 10, 10) {
+		t.Errorf("Rectangle() = %v, want %v", got, Rect(0, 0, 10, 10))
+	}
+	if got := len(p.Rings()); got != 3 {
+		t.Errorf("len(Rings()) = %v, want 3", got)
+	}
+
+	var tests = []struct {
+		pt   Vec
+		want bool
+	}{
+		{Vec{5, 5}, true},
+		{Vec{2, 2}, false},
+		{Vec{7, 7}, false},
+		{Vec{0.5, 9.5}, true},
+		{Vec{11, 5}, false},
+	}
+	for _, tt := range tests {
+		if got := p.Contains(tt.pt); got != tt.want {
+			t.Errorf("Contains(%v) = %v, want %v", tt.pt, got, tt.want)
+		}
+	}
+}
+
+func TestPolygonWithHolesValidate(t *testing.T) {
+	valid := frame()
+	if err := valid.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+
+	// hole touching the outer ring at a vertex
+	touching := frame()
+	touching.Holes[0] = Polygon{{0, 0}, {1, 4}, {4, 4}, {4, 1}}
+	if err := touching.Validate(); err != nil {
+		t.Errorf("touching hole: Validate() = %v, want nil", err)
+	}
+
+	var invalid = []struct {
+		name   string
+		modify func(p *PolygonWithHoles)
+	}{
+		{"ccw hole", func(p *PolygonWithHoles) { p.Holes[0].Reverse() }},
+		{"cw outer", func(p *PolygonWithHoles) { p.Outer.Reverse() }},
+		{"hole outside", func(p *PolygonWithHoles) {
+			p.Holes[0] = Polygon{{11, 1}, {11, 4}, {14, 4}, {14, 1}}
+		}},
+		{"hole crossing outer", func(p *PolygonWithHoles) {
+			p.Holes[0] = Polygon{{-1, 1}, {-1, 4}, {4, 4}, {4, 1}}
+		}},
+		{"overlapping holes", func(p *PolygonWithHoles) {
+			p.Holes[1] = Polygon{{3, 3}, {3, 5}, {5, 5}, {5, 3}}
+		}},
+		{"nested holes", func(p *PolygonWithHoles) {
+			p.Holes[1] = Polygon{{2, 2}, {2, 3}, {3, 3}, {3, 2}}
+		}},
+		{"self-intersecting hole", func(p *PolygonWithHoles) {
+			p.Holes[0] = Polygon{{1, 1}, {4, 4}, {1, 4}, {4, 1}}
+		}},
+	}
+	for _, tt := range invalid {
+		p := frame()
+		tt.modify(&p)
+		if err := p.Validate(); err == nil {
+			t.Errorf("%s: Validate() = nil, want error", tt.name)
+		}
+	}
+}
+
+func TestPolygonWithHolesNormalize(t *testing.T) {
+	p := frame()
+	p.Outer.Reverse()
+	p.Holes[1].Reverse()
+	p.Normalize()
+	if err := p.Validate(); err != nil {
+		t.Errorf("Validate() after Normalize() = %v, want nil", err)
+	}
+}
+
+func TestMultiPolygon(t *testing.T) {
+	island := PolygonWithHoles{Outer: Polygon{{7, 7}, {8, 7}, {8, 8}, {7, 8}}}
+	other := PolygonWithHoles{Outer: Polygon{{20, 0}, {22, 0}, {22, 2}, {20, 2}}}
+	mp := MultiPolygon{frame(), island, other}
+
+	if err := mp.Validate(); err != nil {
+		t.Errorf("Validate() = %v, want nil", err)
+	}
+	if got := mp.Area(); got != 87 {
+		t.Errorf("Area() = %v, want 87", got)
+	}
+	if got := mp.Rectangle(); got != Rect(0, 0, 22, 10) {
+		t.Errorf("Rectangle() = %v, want %v", got, Rect(0, 0, 22, 10))
+	}
+	if got := len(mp.Rings()); got != 5 {
+		t.Errorf("len(Rings()) = %v, want 5", got)
+	}
+	for _, pt := range []Vec{{5, 5}, {7.5, 7.5}, {21, 1}} {
+		if !mp.Contains(pt) {
+			t.Errorf("Contains(%v) = false, want true", pt)
+		}
+	}
+	for _, pt := range []Vec{{2, 2}, {6.5, 6.5}, {15, 1}} {
+		if mp.Contains(pt) {
+			t.Errorf("Contains(%v) = true, want false", pt)
+		}
+	}
+
+	overlapping := MultiPolygon{frame(), PolygonWithHoles{Outer: Polygon{{4, 4}, {5, 4}, {5, 5}, {4, 5}}}}
+	if err := overlapping.Validate(); err == nil {
+		t.Errorf("overlapping polygons: Validate() = nil, want error")
+	}
+
+	// polygons touching at a vertex, where their edges are almost collinear
+	for _, touching := range []MultiPolygon{
+		{
+			{Outer: Polygon{{2.6129000735423467, 7.091300244124122}, {4.816234598515847, 6.933805723246283}, {4.816440188767214, 6.933871603246012}, {4.880545807973846, 8.39418608896736}, {2.613548656977035, 7.095098849280043}}},
+			{Outer: Polygon{{3.8955850137564405, 6.638789816649073}, {4.764022193271971, 5.7397990853993495}, {4.816436662683126, 6.933791279684105}, {4.816234598515847, 6.933805723246283}}},
+		},
+		{
+			{Outer: Polygon{{-2.96247813678422, -1.5208202830883928}, {1.2389840789614115, -0.8203829597732404}, {0.23177997198071448, 0.45996454957694377}, {-1.502005600564749, 1.6208720019704517}}},
+			{Outer: Polygon{{1.2389840789614115, -0.8203829597732404}, {3.1574586756440426, -3.2591281501374048}, {2.286324171158056, -0.401212490058839}, {1.2391629001810995, -0.820353147995807}}},
+		},
+	} {
+		if err := touching.Validate(); err != nil {
+			t.Errorf("touching polygons %v: Validate() = %v, want nil", touching, err)
+		}
+	}
+
+	// polygons sharing a vertex and overlapping edges
+	spike := MultiPolygon{
+		{Outer: Polygon{{0, 0}, {2, 0}, {1, 1}}},
+		{Outer: Polygon{{0, 0}, {1, 1}, {0, 1}}},
+	}
+	if err := spike.Validate(); err == nil {
+		t.Errorf("polygons with overlapping edges: Validate() = nil, want error")
+	}
+}
@@ -0,0 +1,353 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import "math"
+
+// JoinType is the shape given to the corners of offset polygons and
+// polylines.
+type JoinType int
+
+const (
+	// MiterJoin extends the offset edges until they meet, in a sharp corner,
+	// unless the corner is further than the miter limit.
+	MiterJoin JoinType = iota
+
+	// RoundJoin joins the offset edges with an arc of circle.
+	RoundJoin
+
+	// SquareJoin squares off the corners at the offset distance.
+	SquareJoin
+)
+
+func (j JoinType) String() string {
+	switch j {
+	case MiterJoin:
+		return "miter"
+	case RoundJoin:
+		return "round"
+	case SquareJoin:
+		return "square"
+	}
+	return "unknown"
+}
+
+// CapType is the shape given to the ends of offset polylines.
+type CapType int
+
+const (
+	// ButtCap ends the offset polyline squarely at its end points.
+	ButtCap CapType = iota
+
+	// RoundCap ends the offset polyline with half circles.
+	RoundCap
+
+	// SquareCap ends the offset polyline squarely, after extending it by the
+	// offset distance.
+	SquareCap
+)
+
+func (c CapType) String() string {
+	switch c {
+	case ButtCap:
+		return "butt"
+	case RoundCap:
+		return "round"
+	case SquareCap:
+		return "square"
+	}
+	return "unknown"
+}
+
+// OffsetOptions controls the shape of offset polygons and polylines.
+type OffsetOptions struct {
+	// Join is the shape of the corners.
+	Join JoinType
+
+	// MiterLimit is the maximum distance of a mitered corner to its original
+	// vertex, relatively to the offset distance. Further corners are cut at
+	// that distance. If 0, a limit of 2 is used. It can't be less than 1.
+	MiterLimit float64
+
+	// Cap is the shape of the ends of polylines.
+	Cap CapType
+
+	// ArcTolerance is the maximum distance between the arcs of round joins
+	// and caps and the edges approximating them. If 0, 1% of the offset
+	// distance is used.
+	ArcTolerance float64
+}
+
+// Offset returns the polygons obtained by moving the boundaries of the
+// polygons of mp by delta: outward, inflating them, if delta is positive and
+// inward, deflating them, if delta is negative.
+//
+// The returned polygons are normalized. Self-intersecting parts of the offset
+// boundaries are resolved: when inflating, polygons or parts of polygons may
+// merge and holes may fill, when deflating, polygons or parts of polygons may
+// collapse and disappear.
+func (mp MultiPolygon) Offset(delta float64, opts OffsetOptions) MultiPolygon {
+	res := mp.clone()
+	res.Normalize()
+	if delta == 0 {
+		return res
+	}
+
+	o := newOffsetter(delta, opts)
+	for _, p := range res {
+		for _, ring := range p.Rings() {
+			o.ring(ring)
+		}
+	}
+	strips := o.union()
+	if delta > 0 {
+		return Clip(res, strips, Union)
+	}
+	return Clip(res, strips, Difference)
+}
+
+// Offset returns the polygons obtained by offsetting p by delta. See
+// MultiPolygon.Offset.
+func (p PolygonWithHoles) Offset(delta float64, opts OffsetOptions) MultiPolygon {
+	return MultiPolygon{p}.Offset(delta, opts)
+}
+
+// Offset returns the polygons obtained by offsetting p by delta, whatever its
+// orientation. See MultiPolygon.Offset.
+func (p Polygon) Offset(delta float64, opts OffsetOptions) MultiPolygon {
+	return MultiPolygon{{Outer: p}}.Offset(delta, opts)
+}
+
+// Offset returns the polygons covering the points located at a distance less
+// than delta from the polyline p, which is often called its buffer, with
+// joins and caps shaped as specified by opts.
+//
+// It returns nil if delta is not positive or if p is empty.
+func (p Path) Offset(delta float64, opts OffsetOptions) MultiPolygon {
+	if delta <= 0 || len(p) == 0 {
+		return nil
+	}
+	o := newOffsetter(delta, opts)
+	o.polyline(dedup(p))
+	return o.union()
+}
+
+// An offsetter builds the pieces covering the area swept by the boundaries
+// of polygons, or by polylines, when they're offset.
+type offsetter struct {
+	delta  float64 // offset distance, always positive
+	opts   OffsetOptions
+	step   float64 // angular step of arcs
+	pieces []Polygon
+}
+
+func newOffsetter(delta float64, opts OffsetOptions) *offsetter {
+	o := &offsetter{delta: math.Abs(delta), opts: opts}
+	if o.opts.MiterLimit == 0 {
+		o.opts.MiterLimit = 2
+	}
+	o.opts.MiterLimit = math.Max(o.opts.MiterLimit, 1)
+	tol := o.opts.ArcTolerance
+	if tol <= 0 {
+		tol = o.delta / 100
+	}
+	o.step = math.Pi / 2
+	if tol < o.delta {
+		o.step = math.Min(o.step, 2*math.Acos(1-tol/o.delta))
+	}
+	return o
+}
+
+// polyline adds the pieces covering the points located at a distance less
+// than delta from the polyline pts, that has no duplicate points, with caps
+// at both ends.
+func (o *offsetter) polyline(pts Path) {
+	if len(pts) == 1 {
+		for _, u := range [2]Vec{{1, 0}, {-1, 0}} {
+			if c := o.cap(pts[0], u); c != nil {
+				o.pieces = append(o.pieces, c)
+			}
+		}
+		return
+	}
+
+	o.edges(pts, false)
+	last := len(pts) - 1
+	for _, end := range [2]struct {
+		v Vec
+		u Vec
+	}{
+		{pts[last], pts[last].Sub(pts[last-1]).Normalize()},
+		{pts[0], pts[0].Sub(pts[1]).Normalize()},
+	} {
+		if c := o.cap(end.v, end.u); c != nil {
+			o.pieces = append(o.pieces, c)
+		}
+	}
+}
+
+// ring adds the pieces covering the points located at a distance less than
+// delta from the edges of ring.
+//
+// Only the pieces on the offset side matter, but they extend on both sides of
+// the ring so that the boundary of their union doesn't touch the ring, which
+// is better handled by Clip.
+func (o *offsetter) ring(ring Polygon) {
+	pts := dedup(Path(ring))
+	if n := len(pts); n > 1 && pts[0] == pts[n-1] {
+		pts = pts[:n-1]
+	}
+	if len(pts) > 1 {
+		o.edges(pts, true)
+	}
+}
+
+// edges adds the pieces covering the points located at a distance less than
+// delta from the edges of the polyline pts, that has no duplicate points, and
+// from its corners. If closed is true, the last point is linked to the first
+// one.
+func (o *offsetter) edges(pts Path, closed bool) {
+	n := len(pts)
+	nedges := n - 1
+	if closed {
+		nedges = n
+	}
+	for i := 0; i < nedges; i++ {
+		a, b := pts[i], pts[(i+1)%n]
+		nrm := rightNormal(b.Sub(a).Normalize()).Mul(o.delta)
+		o.pieces = append(o.pieces, Polygon{a.Add(nrm), b.Add(nrm), b.Sub(nrm), a.Sub(nrm)})
+	}
+	for i := 0; i < n; i++ {
+		if !closed && (i == 0 || i == n-1) {
+			continue
+		}
+		prev, v, next := pts[(i+n-1)%n], pts[i], pts[(i+1)%n]
+		u1, u2 := v.Sub(prev).Normalize(), next.Sub(v).Normalize()
+		o.join(v, u1, u2, 1)
+		o.join(v, u1, u2, -1)
+	}
+}
+
+// join adds the piece filling the gap opened, on the given side (1 for right,
+// -1 for left), between the offsets of 2 consecutive edges of directions u1
+// and u2, meeting at v. No piece is added if there's no gap.
+func (o *offsetter) join(v, u1, u2 Vec, side float64) {
+	cr, dot := u1.Cross(u2), u1.Dot(u2)
+	if cr*side <= 0 && (cr != 0 || dot >= 0) {
+		return
+	}
+	n1, n2 := rightNormal(u1).Mul(side), rightNormal(u2).Mul(side)
+	b := bisector(u1, n1, n2)
+
+	// the apex of the piece isn't v but a point of the opposite side, that's
+	// inside the pieces of the edges, so that the union of the pieces has no
+	// edges crossing exactly at v, which is better handled by Clip.
+	piece := Polygon{v.Sub(b.Mul(o.delta / 2)), v.Add(n1.Mul(o.delta))}
+	switch o.opts.Join {
+	case MiterJoin:
+		// the miter point is at delta/cos(θ/2) from v, θ being the angle
+		// between the normals.
+		if cos2 := (1 + n1.Dot(n2)) / 2; cos2*o.opts.MiterLimit*o.opts.MiterLimit >= 1 {
+			piece = append(piece, v.Add(n1.Add(n2).Mul(o.delta/(2*cos2))))
+		} else {
+			piece = append(piece, o.square(v, u1, n1, b, o.opts.MiterLimit*o.delta)...)
+		}
+	case RoundJoin:
+		piece = append(piece, o.arc(v, n1, side*math.Abs(math.Atan2(cr, dot)))...)
+	case SquareJoin:
+		piece = append(piece, o.square(v, u1, n1, b, o.delta)...)
+	}
+	o.pieces = append(o.pieces, append(piece, v.Add(n2.Mul(o.delta))))
+}
+
+// bisector returns the unit bisector of the normals n1 and n2 of 2
+// consecutive edges, the first one having the direction u1.
+func bisector(u1, n1, n2 Vec) Vec {
+	b := n1.Add(n2)
+	if b.Len() < 1e-9 {
+		// the path goes back on itself
+		return u1
+	}
+	return b.Normalize()
+}
+
+// square returns the 2 points where the offsets of the edges meeting at v,
+// with direction u1 and normal n1 for the first one, cross the line
+// perpendicular to the bisector b of their normals, at distance d from v.
+func (o *offsetter) square(v, u1, n1, b Vec, d float64) []Vec {
+	ub := u1.Dot(b)
+	if ub < 1e-12 {
+		return nil
+	}
+	w := n1.Mul(o.delta).Add(u1.Mul((d - o.delta*n1.Dot(b)) / ub))
+	// the second point is symmetric to the first one with respect to the
+	// bisector
+	return []Vec{v.Add(w), v.Add(b.Mul(2 * w.Dot(b))).Sub(w)}
+}
+
+// arc returns the points approximating the arc of center v and radius delta
+// starting in the direction n and spanning angle radians, counter-clockwise
+// if positive, end points excluded.
+func (o *offsetter) arc(v, n Vec, angle float64) []Vec {
+	steps := int(math.Ceil(math.Abs(angle) / o.step))
+	var pts []Vec
+	for k := 1; k < steps; k++ {
+		a := angle * float64(k) / float64(steps)
+		sin, cos := math.Sincos(a)
+		r := Vec{n.X*cos - n.Y*sin, n.X*sin + n.Y*cos}
+		pts = append(pts, v.Add(r.Mul(o.delta)))
+	}
+	return pts
+}
+
+// cap returns the piece ending a polyline at v, where it goes in the
+// direction u, or nil for butt caps.
+func (o *offsetter) cap(v, u Vec) Polygon {
+	n := rightNormal(u)
+	switch o.opts.Cap {
+	case RoundCap:
+		piece := Polygon{v.Add(n.Mul(o.delta))}
+		piece = append(piece, o.arc(v, n, math.Pi)...)
+		return append(piece, v.Sub(n.Mul(o.delta)))
+	case SquareCap:
+		e := u.Mul(o.delta)
+		return Polygon{v.Add(n.Mul(o.delta)), v.Add(n.Mul(o.delta)).Add(e), v.Sub(n.Mul(o.delta)).Add(e), v.Sub(n.Mul(o.delta))}
+	}
+	return nil
+}
+
+// union returns the union of all the pieces.
+func (o *offsetter) union() MultiPolygon {
+	return unionAll(o.pieces)
+}
+
+// unionAll returns the union of polys, computed by recursively merging halves
+// of the slice, which is faster than merging polygons one by one.
+func unionAll(polys []Polygon) MultiPolygon {
+	switch len(polys) {
+	case 0:
+		return nil
+	case 1:
+		return Clip(MultiPolygon{{Outer: polys[0]}}, nil, Union)
+	}
+	mid := len(polys) / 2
+	return Clip(unionAll(polys[:mid]), unionAll(polys[mid:]), Union)
+}
+
+// rightNormal returns u rotated by -90°, that is on its right in a Y-up frame.
+func rightNormal(u Vec) Vec {
+	return Vec{u.Y, -u.X}
+}
+
+// dedup returns the points of p without consecutive duplicates.
+func dedup(p Path) Path {
+	var res Path
+	for i, v := range p {
+		if i == 0 || v != p[i-1] {
+			res = append(res, v)
+		}
+	}
+	return res
+}
@@ -0,0 +1,179 @@
+package d2
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// ringDist returns the distance from v to the closest edge of the rings of mp.
+func ringDist(mp MultiPolygon, v Vec) float64 {
+	d := math.Inf(1)
+	for _, ring := range mp.Rings() {
+		for i := range ring {
+			d = math.Min(d, ring.Edge(i).Dist(v))
+		}
+	}
+	return d
+}
+
+func TestPolygonOffset(t *testing.T) {
+	// area of a corner of a 10x10 square inflated by 1, squared off at 1
+	squareCorner := 1 - (2-math.Sqrt2)*(2-math.Sqrt2)/2
+	var tests = []struct {
+		name          string
+		mp            MultiPolygon
+		delta         float64
+		opts          OffsetOptions
+		area          float64
+		npoly, nholes int
+	}{
+		{"zero", MultiPolygon{sq(0, 0, 10)}, 0, OffsetOptions{}, 100, 1, 0},
+		{"miter", MultiPolygon{sq(0, 0, 10)}, 1, OffsetOptions{}, 144, 1, 0},
+		{"miter limit", MultiPolygon{sq(0, 0, 10)}, 1, OffsetOptions{MiterLimit: 1.5}, 144, 1, 0},
+		{"miter limited", MultiPolygon{sq(0, 0, 10)}, 1, OffsetOptions{MiterLimit: 1}, 140 + 4*squareCorner, 1, 0},
+		{"square", MultiPolygon{sq(0, 0, 10)}, 1, OffsetOptions{Join: SquareJoin}, 140 + 4*squareCorner, 1, 0},
+		{"round", MultiPolygon{sq(0, 0, 10)}, 1, OffsetOptions{Join: RoundJoin, ArcTolerance: 1e-4}, 140 + math.Pi, 1, 0},
+		{"deflate", MultiPolygon{sq(0, 0, 10)}, -1, OffsetOptions{Join: RoundJoin}, 64, 1, 0},
+		{"collapse", MultiPolygon{sq(0, 0, 10)}, -6, OffsetOptions{}, 0, 0, 0},
+		{"holes shrink", MultiPolygon{frame()}, 0.5, OffsetOptions{}, 121 - 2*4, 1, 2},
+		{"holes fill", MultiPolygon{frame()}, 3, OffsetOptions{}, 256, 1, 0},
+		{"holes grow", MultiPolygon{frame()}, -0.25, OffsetOptions{}, 9.5*9.5 - 2*3.5*3.5, 1, 2},
+		{"holes split", MultiPolygon{frame()}, -1, OffsetOptions{}, 32, 2, 0},
+		{"merge", MultiPolygon{sq(0, 0, 2), sq(3, 0, 2)}, 1, OffsetOptions{}, 7 * 4, 1, 0},
+		{
+			// 2 squares linked by a thin corridor
+			"split",
+			MultiPolygon{{Outer: Polygon{{0, 0}, {4, 0}, {4, 1.5}, {6, 1.5}, {6, 0}, {10, 0}, {10, 4}, {6, 4}, {6, 2.5}, {4, 2.5}, {4, 4}, {0, 4}}}},
+			-0.6, OffsetOptions{},
+			2 * 2.8 * 2.8, 2, 0,
+		},
+	}
+	for _, tt := range tests {
+		got := tt.mp.Offset(tt.delta, tt.opts)
+		if a := got.Area(); math.Abs(a-tt.area) > 1e-3*(1+tt.area) {
+			t.Errorf("%s: area = %v, want %v (%v)", tt.name, a, tt.area, got)
+		}
+		if len(got) != tt.npoly {
+			t.Errorf("%s: got %d polygons, want %d (%v)", tt.name, len(got), tt.npoly, got)
+			continue
+		}
+		nholes := 0
+		for _, p := range got {
+			nholes += len(p.Holes)
+		}
+		if nholes != tt.nholes {
+			t.Errorf("%s: got %d holes, want %d (%v)", tt.name, nholes, tt.nholes, got)
+		}
+		if err := got.Validate(); err != nil {
+			t.Errorf("%s: invalid result: %v (%v)", tt.name, err, got)
+		}
+	}
+
+	// orientation doesn't matter
+	cw := Polygon{{0, 0}, {0, 10}, {10, 10}, {10, 0}}
+	if a := cw.Offset(1, OffsetOptions{}).Area(); math.Abs(a-144) > 1e-9 {
+		t.Errorf("clockwise polygon offset area = %v, want 144", a)
+	}
+}
+
+func TestPolygonOffsetRandom(t *testing.T) {
+	// the vertices of round offsets are at the offset distance from the
+	// original boundary.
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 50; i++ {
+		c := Vec{rng.Float64() * 10, rng.Float64() * 10}
+		n := 3 + rng.Intn(10)
+		p := make(Polygon, n)
+		for i := range p {
+			a := 2 * math.Pi * (float64(i) + rng.Float64()*0.8) / float64(n)
+			r := 1 + rng.Float64()*5
+			p[i] = c.Add(Vec{r * math.Cos(a), r * math.Sin(a)})
+		}
+		mp := MultiPolygon{{Outer: p}}
+		for _, delta := range []float64{-0.5, 0.5, 2} {
+			opts := OffsetOptions{Join: RoundJoin}
+			got := mp.Offset(delta, opts)
+			if err := got.Validate(); err != nil {
+				t.Fatalf("Offset(%v) of %v is invalid: %v", delta, p, err)
+			}
+			d := math.Abs(delta)
+			for _, ring := range got.Rings() {
+				for _, v := range ring {
+					if dist := ringDist(mp, v); dist < d*0.99-1e-9 || dist > d+1e-9 {
+						t.Fatalf("Offset(%v) of %v: vertex %v is at %v from the polygon", delta, p, v, dist)
+					}
+					if inside := mp.Contains(v); inside != (delta < 0) && ringDist(mp, v) > 1e-9 {
+						t.Fatalf("Offset(%v) of %v: vertex %v is on the wrong side", delta, p, v)
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestPathOffset(t *testing.T) {
+	seg := Path{{0, 0}, {10, 0}}
+	corner := Path{{0, 0}, {10, 0}, {10, 10}}
+	var tests = []struct {
+		name  string
+		p     Path
+		delta float64
+		opts  OffsetOptions
+		area  float64
+		npoly int
+	}{
+		{"empty", nil, 1, OffsetOptions{}, 0, 0},
+		{"negative", seg, -1, OffsetOptions{}, 0, 0},
+		{"butt", seg, 1, OffsetOptions{}, 20, 1},
+		{"square cap", seg, 1, OffsetOptions{Cap: SquareCap}, 24, 1},
+		{"round cap", seg, 1, OffsetOptions{Cap: RoundCap, ArcTolerance: 1e-4}, 20 + math.Pi, 1},
+		{"point butt", Path{{1, 1}}, 1, OffsetOptions{}, 0, 0},
+		{"point square", Path{{1, 1}, {1, 1}}, 1, OffsetOptions{Cap: SquareCap}, 4, 1},
+		{"point round", Path{{1, 1}}, 1, OffsetOptions{Cap: RoundCap, ArcTolerance: 1e-4}, math.Pi, 1},
+		{"miter corner", corner, 1, OffsetOptions{}, 40, 1},
+		{"round corner", corner, 1, OffsetOptions{Join: RoundJoin, ArcTolerance: 1e-4}, 39 + math.Pi/4, 1},
+		{"spike", Path{{0, 0}, {10, 0}, {5, 0}}, 1, OffsetOptions{Join: SquareJoin}, 22, 1},
+		{"loop", Path{{0, 0}, {10, 0}, {10, 10}, {0, 10}, {0, 0}}, 1, OffsetOptions{}, 144 - 64 - 1, 1},
+	}
+	for _, tt := range tests {
+		got := tt.p.Offset(tt.delta, tt.opts)
+		if a := got.Area(); math.Abs(a-tt.area) > 1e-3*(1+tt.area) {
+			t.Errorf("%s: area = %v, want %v (%v)", tt.name, a, tt.area, got)
+		}
+		if len(got) != tt.npoly {
+			t.Errorf("%s: got %d polygons, want %d (%v)", tt.name, len(got), tt.npoly, got)
+		}
+		if err := got.Validate(); err != nil {
+			t.Errorf("%s: invalid result: %v (%v)", tt.name, err, got)
+		}
+	}
+}
+
+func TestPathOffsetRandom(t *testing.T) {
+	// self-overlapping polylines
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100; i++ {
+		p := make(Path, 2+rng.Intn(20))
+		for i := range p {
+			p[i] = Vec{rng.Float64() * 10, rng.Float64() * 10}
+		}
+		opts := OffsetOptions{
+			Join:       JoinType(rng.Intn(3)),
+			MiterLimit: 1 + rng.Float64()*4,
+			Cap:        CapType(rng.Intn(3)),
+		}
+		delta := 0.1 + rng.Float64()*2
+		got := p.Offset(delta, opts)
+		if err := got.Validate(); err != nil {
+			t.Fatalf("Offset(%v, %+v) of %v is invalid: %v", delta, opts, p, err)
+		}
+		for _, ring := range got.Rings() {
+			for _, v := range ring {
+				if d := distToPath(p, v); d > delta*math.Hypot(1, opts.MiterLimit)+1e-9 {
+					t.Fatalf("Offset(%v, %+v) of %v: vertex %v is at %v from the path", delta, opts, p, v, d)
+				}
+			}
+		}
+	}
+}
@@ -6,5 +6,25 @@
 
 package d2
 
+import "math"
+
 // Path is a sequence of points represented by 2D vectors
 type Path []Vec
+
+// Rectangle returns the smallest rectangle containing all the points of p.
+//
+// Note that a Rectangle Max bound is exclusive, so the rightmost and lowest
+// points of p are not In the returned rectangle.
+func (p Path) Rectangle() Rectangle {
+	if len(p) == 0 {
+		return ZR
+	}
+	r := Rectangle{p[0], p[0]}
+	for _, v := range p[1:] {
+		r.Min.X = math.Min(r.Min.X, v.X)
+		r.Min.Y = math.Min(r.Min.Y, v.Y)
+		r.Max.X = math.Max(r.Max.X, v.X)
+		r.Max.Y = math.Max(r.Max.Y, v.Y)
+	}
+	return r
+}
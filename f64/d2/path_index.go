@@ -0,0 +1,168 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"math"
+	"sort"
+)
+
+// Length returns the length of p, that is the sum of the lengths of its
+// segments.
+func (p Path) Length() float64 {
+	var l float64
+	for i := 1; i < len(p); i++ {
+		l += p[i-1].Dist(p[i])
+	}
+	return l
+}
+
+// A PathIndex indexes the points of a path by their distance along the path,
+// measured from its first point, to provide linear referencing.
+//
+// The path must not be modified while it's indexed.
+type PathIndex struct {
+	path Path
+	dist []float64 // dist[i] is the distance of path[i] along path
+}
+
+// NewPathIndex returns the index of p.
+func NewPathIndex(p Path) *PathIndex {
+	pi := &PathIndex{path: p, dist: make([]float64, len(p))}
+	for i := 1; i < len(p); i++ {
+		pi.dist[i] = pi.dist[i-1] + p[i-1].Dist(p[i])
+	}
+	return pi
+}
+
+// Path returns the indexed path.
+func (pi *PathIndex) Path() Path {
+	return pi.path
+}
+
+// Length returns the length of the indexed path.
+func (pi *PathIndex) Length() float64 {
+	if len(pi.dist) == 0 {
+		return 0
+	}
+	return pi.dist[len(pi.dist)-1]
+}
+
+// Distance returns the distance along the path of its i-th point.
+func (pi *PathIndex) Distance(i int) float64 {
+	return pi.dist[i]
+}
+
+// segment returns the index of the segment containing the point at distance
+// d along the path, that must have at least 2 points.
+func (pi *PathIndex) segment(d float64) int {
+	// first point further than d, the segment ends there
+	i := sort.Search(len(pi.dist), func(i int) bool { return pi.dist[i] > d })
+	switch {
+	case i == 0:
+		return 0
+	case i >= len(pi.dist):
+		return len(pi.dist) - 2
+	}
+	return i - 1
+}
+
+// at returns the point at distance d along the segment i.
+func (pi *PathIndex) at(i int, d float64) Vec {
+	l := pi.dist[i+1] - pi.dist[i]
+	if l == 0 {
+		return pi.path[i]
+	}
+	t := math.Max(0, math.Min(1, (d-pi.dist[i])/l))
+	return Segment{pi.path[i], pi.path[i+1]}.At(t)
+}
+
+// Interpolate returns the point located at distance d along the path. d is
+// clamped to [0, Length()], so that the end points are returned for
+// distances out of that range. The zero Vec is returned if the path is empty.
+func (pi *PathIndex) Interpolate(d float64) Vec {
+	switch len(pi.path) {
+	case 0:
+		return ZV
+	case 1:
+		return pi.path[0]
+	}
+	return pi.at(pi.segment(d), d)
+}
+
+// Locate returns the distance along the path of the point of the path that is
+// the closest to p, and the index i of the segment containing it, going from
+// the point i to the point i+1 of the path. If several points are at the same
+// distance, the first one along the path is returned.
+//
+// seg is -1 if the path has less than 2 points.
+func (pi *PathIndex) Locate(p Vec) (d float64, seg int) {
+	seg = -1
+	best := math.Inf(1)
+	for i := 0; i < len(pi.path)-1; i++ {
+		s := Segment{pi.path[i], pi.path[i+1]}
+		t := s.Project(p)
+		if dist := p.Dist(s.At(t)); dist < best {
+			best, seg = dist, i
+			d = pi.dist[i] + t*(pi.dist[i+1]-pi.dist[i])
+		}
+	}
+	return d, seg
+}
+
+// SubPath returns the part of the path located between the distances from
+// and to along it, which are clamped to [0, Length()]. The returned path is
+// reversed if from is greater than to. It returns nil if the path is empty.
+func (pi *PathIndex) SubPath(from, to float64) Path {
+	if len(pi.path) < 2 {
+		return pi.path.clone()
+	}
+	reverse := from > to
+	if reverse {
+		from, to = to, from
+	}
+	from = math.Max(0, math.Min(from, pi.Length()))
+	to = math.Max(0, math.Min(to, pi.Length()))
+
+	i, j := pi.segment(from), pi.segment(to)
+	sub := Path{pi.at(i, from)}
+	for k := i + 1; k <= j; k++ {
+		if v := pi.path[k]; v != sub[len(sub)-1] {
+			sub = append(sub, v)
+		}
+	}
+	if v := pi.at(j, to); v != sub[len(sub)-1] || len(sub) == 1 {
+		sub = append(sub, v)
+	}
+	if reverse {
+		return reversed(sub)
+	}
+	return sub
+}
+
+// Resample returns the points located every step along the path, starting
+// from its first point. The last point of the path is always added, even if
+// it's closer than step to the previous one. It returns a copy of the path if
+// step is not positive or if the path has less than 2 points.
+func (pi *PathIndex) Resample(step float64) Path {
+	l := pi.Length()
+	if step <= 0 || len(pi.path) < 2 {
+		return pi.path.clone()
+	}
+	n := int(math.Floor(l / step))
+	res := make(Path, 0, n+2)
+	seg := 0
+	for k := 0; k <= n; k++ {
+		d := float64(k) * step
+		for seg < len(pi.path)-2 && pi.dist[seg+1] <= d {
+			seg++
+		}
+		res = append(res, pi.at(seg, d))
+	}
+	if last := pi.path[len(pi.path)-1]; res[len(res)-1] != last {
+		res = append(res, last)
+	}
+	return res
+}
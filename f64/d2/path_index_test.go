@@ -0,0 +1,175 @@
+package d2
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestPathLength(t *testing.T) {
+	var tests = []struct {
+		p    Path
+		want float64
+	}{
+		{nil, 0},
+		{Path{{1, 1}}, 0},
+		{Path{{0, 0}, {3, 4}}, 5},
+		{Path{{0, 0}, {10, 0}, {10, 0}, {10, 10}}, 20},
+	}
+	for _, tt := range tests {
+		if got := tt.p.Length(); got != tt.want {
+			t.Errorf("%v.Length() = %v, want %v", tt.p, got, tt.want)
+		}
+		if got := NewPathIndex(tt.p).Length(); got != tt.want {
+			t.Errorf("NewPathIndex(%v).Length() = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPathIndexInterpolate(t *testing.T) {
+	pi := NewPathIndex(Path{{0, 0}, {10, 0}, {10, 0}, {10, 10}})
+	var tests = []struct {
+		d    float64
+		want Vec
+	}{
+		{-1, Vec{0, 0}},
+		{0, Vec{0, 0}},
+		{2.5, Vec{2.5, 0}},
+		{10, Vec{10, 0}},
+		{12, Vec{10, 2}},
+		{20, Vec{10, 10}},
+		{25, Vec{10, 10}},
+	}
+	for _, tt := range tests {
+		if got := pi.Interpolate(tt.d); !got.ApproxEpsilon(tt.want, 1e-12) {
+			t.Errorf("Interpolate(%v) = %v, want %v", tt.d, got, tt.want)
+		}
+	}
+
+	if got := NewPathIndex(nil).Interpolate(1); got != ZV {
+		t.Errorf("Interpolate(1) of an empty path = %v, want %v", got, ZV)
+	}
+	if got := NewPathIndex(Path{{1, 2}}).Interpolate(1); got != (Vec{1, 2}) {
+		t.Errorf("Interpolate(1) of a point = %v, want (1,2)", got)
+	}
+}
+
+func TestPathIndexLocate(t *testing.T) {
+	pi := NewPathIndex(Path{{0, 0}, {10, 0}, {10, 10}, {0, 10}})
+	var tests = []struct {
+		p   Vec
+		d   float64
+		seg int
+	}{
+		{Vec{0, 0}, 0, 0},
+		{Vec{3, -2}, 3, 0},
+		{Vec{-5, -5}, 0, 0},
+		{Vec{12, 4}, 14, 1},
+		{Vec{4, 11}, 26, 2},
+		{Vec{-1, 12}, 30, 2},
+		// equally close to the 1st and 3rd segments
+		{Vec{5, 5}, 5, 0},
+	}
+	for _, tt := range tests {
+		d, seg := pi.Locate(tt.p)
+		if !approx(d, tt.d) || seg != tt.seg {
+			t.Errorf("Locate(%v) = %v, %v, want %v, %v", tt.p, d, seg, tt.d, tt.seg)
+		}
+	}
+
+	if d, seg := NewPathIndex(Path{{1, 1}}).Locate(Vec{2, 2}); d != 0 || seg != -1 {
+		t.Errorf("Locate of a point = %v, %v, want 0, -1", d, seg)
+	}
+
+	// Locate and Interpolate are inverse of each other for points of the path
+	rng := rand.New(rand.NewSource(1))
+	pi = NewPathIndex(spiral(rng, 200))
+	for i := 0; i < 100; i++ {
+		d := rng.Float64() * pi.Length()
+		v := pi.Interpolate(d)
+		got, seg := pi.Locate(v)
+		if math.Abs(got-d) > 1e-9 {
+			t.Fatalf("Locate(Interpolate(%v)) = %v", d, got)
+		}
+		if d < pi.Distance(seg)-1e-9 || d > pi.Distance(seg+1)+1e-9 {
+			t.Fatalf("Locate(Interpolate(%v)): segment %d is in [%v, %v]", d, seg, pi.Distance(seg), pi.Distance(seg+1))
+		}
+	}
+}
+
+func TestPathIndexSubPath(t *testing.T) {
+	pi := NewPathIndex(Path{{0, 0}, {10, 0}, {10, 10}, {0, 10}})
+	var tests = []struct {
+		from, to float64
+		want     Path
+	}{
+		{0, 30, Path{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+		{-5, 50, Path{{0, 0}, {10, 0}, {10, 10}, {0, 10}}},
+		{2, 8, Path{{2, 0}, {8, 0}}},
+		{5, 15, Path{{5, 0}, {10, 0}, {10, 5}}},
+		{10, 20, Path{{10, 0}, {10, 10}}},
+		{5, 25, Path{{5, 0}, {10, 0}, {10, 10}, {5, 10}}},
+		{15, 5, Path{{10, 5}, {10, 0}, {5, 0}}},
+		{12, 12, Path{{10, 2}, {10, 2}}},
+	}
+	for _, tt := range tests {
+		got := pi.SubPath(tt.from, tt.to)
+		if len(got) != len(tt.want) {
+			t.Errorf("SubPath(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].ApproxEpsilon(tt.want[i], 1e-12) {
+				t.Errorf("SubPath(%v, %v) = %v, want %v", tt.from, tt.to, got, tt.want)
+				break
+			}
+		}
+	}
+
+	for _, p := range []Path{nil, {{1, 1}}} {
+		if got := NewPathIndex(p).SubPath(0, 1); !reflect.DeepEqual(got, p) {
+			t.Errorf("SubPath(0, 1) of %v = %v", p, got)
+		}
+	}
+}
+
+func TestPathIndexResample(t *testing.T) {
+	pi := NewPathIndex(Path{{0, 0}, {10, 0}, {10, 5}})
+	var tests = []struct {
+		step float64
+		want Path
+	}{
+		{5, Path{{0, 0}, {5, 0}, {10, 0}, {10, 5}}},
+		{4, Path{{0, 0}, {4, 0}, {8, 0}, {10, 2}, {10, 5}}},
+		{20, Path{{0, 0}, {10, 5}}},
+		{0, Path{{0, 0}, {10, 0}, {10, 5}}},
+	}
+	for _, tt := range tests {
+		got := pi.Resample(tt.step)
+		if len(got) != len(tt.want) {
+			t.Errorf("Resample(%v) = %v, want %v", tt.step, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if !got[i].ApproxEpsilon(tt.want[i], 1e-12) {
+				t.Errorf("Resample(%v) = %v, want %v", tt.step, got, tt.want)
+				break
+			}
+		}
+	}
+
+	// resampled points are evenly spaced along the path
+	rng := rand.New(rand.NewSource(1))
+	pi = NewPathIndex(spiral(rng, 500))
+	step := 0.37
+	got := pi.Resample(step)
+	if want := int(math.Floor(pi.Length()/step)) + 2; len(got) != want {
+		t.Fatalf("Resample(%v) has %d points, want %d", step, len(got), want)
+	}
+	for i, v := range got[:len(got)-1] {
+		if d, _ := pi.Locate(v); math.Abs(d-float64(i)*step) > 1e-9 {
+			t.Fatalf("point %d of Resample(%v) is at %v along the path, want %v", i, step, d, float64(i)*step)
+		}
+	}
+}
@@ -0,0 +1,271 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import "math"
+
+// A Polygon is a closed Path: its last vertex is implicitly joined to the
+// first one, so it must not be repeated.
+//
+// Orientations are given for a Y-up frame, with the default Y-down frame of
+// Vec, a counter-clockwise polygon appears clockwise on screen.
+type Polygon Path
+
+// Orientation is the turning direction of a sequence of points.
+type Orientation int
+
+const (
+	// Collinear indicates points that do not turn.
+	Collinear Orientation = iota
+
+	// CounterClockwise indicates points turning left (in a Y-up frame).
+	CounterClockwise
+
+	// Clockwise indicates points turning right (in a Y-up frame).
+	Clockwise
+)
+
+func (o Orientation) String() string {
+	switch o {
+	case CounterClockwise:
+		return "ccw"
+	case Clockwise:
+		return "cw"
+	}
+	return "collinear"
+}
+
+// Orient returns the orientation of the triangle a, b, c.
+func Orient(a, b, c Vec) Orientation {
+	switch cr := b.Sub(a).Cross(c.Sub(a)); {
+	case cr > 0:
+		return CounterClockwise
+	case cr < 0:
+		return Clockwise
+	}
+	return Collinear
+}
+
+// FillRule defines which points are considered inside a polygon.
+type FillRule int
+
+const (
+	// EvenOdd considers a point is inside if a ray cast from it crosses the
+	// polygon boundary an odd number of times.
+	EvenOdd FillRule = iota
+
+	// NonZero considers a point is inside if the polygon winds around it at
+	// least once.
+	NonZero
+)
+
+// SignedArea returns the signed area of p, computed with the shoelace
+// formula. It's positive if p is counter-clockwise, negative if it's
+// clockwise.
+func (p Polygon) SignedArea() float64 {
+	var a float64
+	for i, j := len(p)-1, 0; j < len(p); i, j = j, j+1 {
+		a += p[i].Cross(p[j])
+	}
+	return a / 2
+}
+
+// Area returns the area of p. If p self-intersects, the areas of the parts
+// having opposite orientations cancel each other.
+func (p Polygon) Area() float64 {
+	return math.Abs(p.SignedArea())
+}
+
+// Perimeter returns the length of the boundary of p.
+func (p Polygon) Perimeter() float64 {
+	var l float64
+	for i, j := len(p)-1, 0; j < len(p); i, j = j, j+1 {
+		l += p[i].Dist(p[j])
+	}
+	return l
+}
+
+// Centroid returns the center of mass of p.
+//
+// If p area is null, the centroid is the average of its vertices.
+func (p Polygon) Centroid() Vec {
+	if len(p) == 0 {
+		return ZV
+	}
+	var c Vec
+	var a float64
+	for i, j := len(p)-1, 0; j < len(p); i, j = j, j+1 {
+		cr := p[i].Cross(p[j])
+		a += cr
+		c.X += (p[i].X + p[j].X) * cr
+		c.Y += (p[i].Y + p[j].Y) * cr
+	}
+	if a == 0 {
+		for _, v := range p {
+			c = c.Add(v)
+		}
+		return c.Div(float64(len(p)))
+	}
+	return c.Div(3 * a)
+}
+
+// Orientation returns the orientation of p, deduced from its signed area.
+func (p Polygon) Orientation() Orientation {
+	switch a := p.SignedArea(); {
+	case a > 0:
+		return CounterClockwise
+	case a < 0:
+		return Clockwise
+	}
+	return Collinear
+}
+
+// Reverse reverses the order of the vertices of p, in place, and thus its
+// orientation.
+func (p Polygon) Reverse() {
+	for i, j := 0, len(p)-1; i < j; i, j = i+1, j-1 {
+		p[i], p[j] = p[j], p[i]
+	}
+}
+
+// EnsureCCW reverses p in place if it's clockwise.
+func (p Polygon) EnsureCCW() {
+	if p.Orientation() == Clockwise {
+		p.Reverse()
+	}
+}
+
+// EnsureCW reverses p in place if it's counter-clockwise.
+func (p Polygon) EnsureCW() {
+	if p.Orientation() == CounterClockwise {
+		p.Reverse()
+	}
+}
+
+// IsConvex reports whether p is a convex polygon. Collinear consecutive
+// vertices are allowed, but p must not self-intersect.
+func (p Polygon) IsConvex() bool {
+	if len(p) < 3 {
+		return false
+	}
+	var (
+		sign  float64
+		angle float64
+	)
+	n := len(p)
+	for i := 0; i < n; i++ {
+		e1 := p[(i+1)%n].Sub(p[i])
+		e2 := p[(i+2)%n].Sub(p[(i+1)%n])
+		cr := e1.Cross(e2)
+		if cr != 0 {
+			if sign == 0 {
+				sign = cr
+			} else if (cr > 0) != (sign > 0) {
+				return false
+			}
+		}
+		angle += math.Atan2(cr, e1.Dot(e2))
+	}
+	// a star polygon turns consistently but more than once
+	return sign != 0 && math.Abs(math.Abs(angle)-2*math.Pi) < 1e-6
+}
+
+// IsSimple reports whether p is a simple polygon, that is if it has at least 3
+// vertices and if its edges only meet at their shared vertices.
+func (p Polygon) IsSimple() bool {
+	n := len(p)
+	if n < 3 {
+		return false
+	}
+	for i := 0; i < n; i++ {
+		ei := p.Edge(i)
+		if ei.Degenerate() {
+			return false
+		}
+		for j := i + 1; j < n; j++ {
+			switch k, _ := ei.Intersect(p.Edge(j)); {
+			case k == OverlapIntersection:
+				return false
+			case k == PointIntersection:
+				// adjacent edges only meet at their shared vertex
+				if j != i+1 && !(i == 0 && j == n-1) {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// Edge returns the i-th edge of p, going from p[i] to p[i+1], or to p[0] for
+// the last edge.
+func (p Polygon) Edge(i int) Segment {
+	j := i + 1
+	if j == len(p) {
+		j = 0
+	}
+	return Segment{p[i], p[j]}
+}
+
+// onBoundary reports whether pt lies on one of the edges of p.
+func (p Polygon) onBoundary(pt Vec) bool {
+	for i := range p {
+		if p.Edge(i).onSegment(pt) {
+			return true
+		}
+	}
+	return false
+}
+
+// Rectangle returns the smallest rectangle containing all the vertices of p.
+//
+// Note that a Rectangle Max bound is exclusive, so the rightmost and lowest
+// vertices of p are not In the returned rectangle.
+func (p Polygon) Rectangle() Rectangle {
+	return Path(p).Rectangle()
+}
+
+// Winding returns the winding number of p around pt, that is the number of
+// times p travels counter-clockwise around pt. It's 0 if pt is outside p.
+func (p Polygon) Winding(pt Vec) int {
+	wn := 0
+	for i, j := len(p)-1, 0; j < len(p); i, j = j, j+1 {
+		a, b := p[i], p[j]
+		if a.Y <= pt.Y {
+			if b.Y > pt.Y && b.Sub(a).Cross(pt.Sub(a)) > 0 {
+				// upward crossing, pt left of edge
+				wn++
+			}
+		} else if b.Y <= pt.Y && b.Sub(a).Cross(pt.Sub(a)) < 0 {
+			// downward crossing, pt right of edge
+			wn--
+		}
+	}
+	return wn
+}
+
+// Contains reports whether pt is inside p, according to the given fill rule.
+//
+// Points located exactly on the boundary of p may be considered either inside
+// or outside.
+func (p Polygon) Contains(pt Vec, rule FillRule) bool {
+	if rule == NonZero {
+		return p.Winding(pt) != 0
+	}
+	in := false
+	for i, j := len(p)-1, 0; j < len(p); i, j = j, j+1 {
+		a, b := p[i], p[j]
+		if (a.Y > pt.Y) != (b.Y > pt.Y) &&
+			pt.X < a.X+(pt.Y-a.Y)*(b.X-a.X)/(b.Y-a.Y) {
+			in = !in
+		}
+	}
+	return in
+}
+
+// Hit implements the Hitter interface.
+func (p Polygon) Hit(r Ray) (RayHit, bool) {
+	return r.HitPolygon(Path(p))
+}
@@ -0,0 +1,115 @@
+package d2
+
+import "testing"
+
+var (
+	// counter-clockwise 4x4 square
+	square = Polygon{{0, 0}, {4, 0}, {4, 4}, {0, 4}}
+	// clockwise L-shaped polygon
+	lshape = Polygon{{0, 0}, {0, 4}, {2, 4}, {2, 2}, {4, 2}, {4, 0}}
+	// pentagram, self-intersecting
+	star = Polygon{{0, 10}, {6, -8}, {-9.5, 3}, {9.5, 3}, {-6, -8}}
+)
+
+func TestPolygonArea(t *testing.T) {
+	var tests = []struct {
+		p           Polygon
+		signed      float64
+		perimeter   float64
+		centroid    Vec
+		orientation Orientation
+	}{
+		{square, 16, 16, Vec{2, 2}, CounterClockwise},
+		{lshape, -12, 16, Vec{5.0 / 3, 5.0 / 3}, Clockwise},
+		{Polygon{{0, 0}, {1, 1}, {2, 2}}, 0, 4 * 1.4142135623730951, Vec{1, 1}, Collinear},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.SignedArea(); !approx(got, tt.signed) {
+			t.Errorf("%v.SignedArea() = %v, want %v", tt.p, got, tt.signed)
+		}
+		if got := tt.p.Perimeter(); !approx(got, tt.perimeter) {
+			t.Errorf("%v.Perimeter() = %v, want %v", tt.p, got, tt.perimeter)
+		}
+		if got := tt.p.Centroid(); !got.Approx(tt.centroid) {
+			t.Errorf("%v.Centroid() = %v, want %v", tt.p, got, tt.centroid)
+		}
+		if got := tt.p.Orientation(); got != tt.orientation {
+			t.Errorf("%v.Orientation() = %v, want %v", tt.p, got, tt.orientation)
+		}
+	}
+}
+
+func TestPolygonEnsureCCW(t *testing.T) {
+	p := append(Polygon{}, lshape...)
+	p.EnsureCCW()
+	if p.Orientation() != CounterClockwise {
+		t.Errorf("EnsureCCW: got %v, want ccw", p.Orientation())
+	}
+	if p.Area() != lshape.Area() {
+		t.Errorf("EnsureCCW: area changed from %v to %v", lshape.Area(), p.Area())
+	}
+	p.EnsureCW()
+	for i := range p {
+		if p[i] != lshape[i] {
+			t.Fatalf("EnsureCW: got %v, want %v", p, lshape)
+		}
+	}
+}
+
+func TestPolygonIsConvex(t *testing.T) {
+	var tests = []struct {
+		p    Polygon
+		want bool
+	}{
+		{square, true},
+		{Polygon{{0, 0}, {2, 0}, {4, 0}, {4, 4}, {0, 4}}, true},
+		{lshape, false},
+		{star, false},
+		{Polygon{{0, 0}, {1, 1}}, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.IsConvex(); got != tt.want {
+			t.Errorf("%v.IsConvex() = %v, want %v", tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestPolygonRectangle(t *testing.T) {
+	var r Rectangler = star
+	want := Rect(-9.5, -8, 9.5, 10)
+	if got := r.Rectangle(); got != want {
+		t.Errorf("%v.Rectangle() = %v, want %v", star, got, want)
+	}
+}
+
+func TestPolygonContains(t *testing.T) {
+	var tests = []struct {
+		p       Polygon
+		pt      Vec
+		evenOdd bool
+		nonZero bool
+	}{
+		{square, Vec{2, 2}, true, true},
+		{square, Vec{5, 2}, false, false},
+		{square, Vec{-1, -1}, false, false},
+		{lshape, Vec{1, 3}, true, true},
+		{lshape, Vec{3, 3}, false, false},
+		{lshape, Vec{3, 1}, true, true},
+		// center of the star: winding twice around
+		{star, Vec{0, 0}, false, true},
+		// a branch of the star
+		{star, Vec{0, 7}, true, true},
+		{star, Vec{0, -9}, false, false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.p.Contains(tt.pt, EvenOdd); got != tt.evenOdd {
+			t.Errorf("%v.Contains(%v, EvenOdd) = %v, want %v", tt.p, tt.pt, got, tt.evenOdd)
+		}
+		if got := tt.p.Contains(tt.pt, NonZero); got != tt.nonZero {
+			t.Errorf("%v.Contains(%v, NonZero) = %v, want %v", tt.p, tt.pt, got, tt.nonZero)
+		}
+	}
+}
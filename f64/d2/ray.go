@@ -37,25 +37,199 @@ func (r Ray) Direction() Vec {
 
 // IntersectRect indicates wether the ray intersects with the rectangle b.
 func (r Ray) IntersectRect(b Rectangle) bool {
-	t1 := (b.Min.X - r.o.X) * r.invv.X
-	t2 := (b.Max.X - r.o.X) * r.invv.X
+	_, _, ok := r.ClipRect(b)
+	return ok
+}
+
+// ClipRect computes the parameters at which the ray enters and exits the
+// rectangle b, using the slab method. The boundaries of b are considered as
+// part of it.
+//
+// Parameters are expressed in units of the ray direction vector, so the
+// entry point is r.At(tmin). tmin is negative if the origin of the ray is
+// inside b. ok is false if the ray does not intersect b, in which case tmin
+// and tmax are meaningless.
+func (r Ray) ClipRect(b Rectangle) (tmin, tmax float64, ok bool) {
+	tmin, tmax, _, _, ok = r.clipRect(b)
+	return
+}
+
+// clipRect performs the slab test of r against b and also returns the axis
+// (0 for X, 1 for Y) that determined tmin and tmax.
+func (r Ray) clipRect(b Rectangle) (tmin, tmax float64, amin, amax int, ok bool) {
+	tmin, tmax = math.Inf(-1), math.Inf(1)
+	amin, amax = -1, -1
+
+	slab := func(axis int, o, v, invv, lo, hi float64) bool {
+		if v == 0 {
+			// parallel to the slab, the origin must be in it
+			return lo <= o && o <= hi
+		}
+		t1 := (lo - o) * invv
+		t2 := (hi - o) * invv
+		if t1 > t2 {
+			t1, t2 = t2, t1
+		}
+		if t1 > tmin {
+			tmin, amin = t1, axis
+		}
+		if t2 < tmax {
+			tmax, amax = t2, axis
+		}
+		return true
+	}
 
-	tmin := math.Min(t1, t2)
-	tmax := math.Max(t1, t2)
+	if !slab(0, r.o.X, r.v.X, r.invv.X, b.Min.X, b.Max.X) ||
+		!slab(1, r.o.Y, r.v.Y, r.invv.Y, b.Min.Y, b.Max.Y) {
+		return 0, 0, -1, -1, false
+	}
+	ok = tmax >= math.Max(tmin, 0.0)
+	return
+}
+
+// At returns the point of the ray located at parameter t, that is o + t*v.
+func (r Ray) At(t float64) Vec {
+	return r.o.Add(r.v.Mul(t))
+}
 
-	// x
-	t1 = (b.Min.X - r.o.X) * r.invv.X
-	t2 = (b.Max.X - r.o.X) * r.invv.X
-	tmin = math.Max(tmin, math.Min(t1, t2))
-	tmax = math.Min(tmax, math.Max(t1, t2))
+// A RayHit describes the point where a ray hits a shape.
+type RayHit struct {
+	// T is the parameter of the hit point along the ray, expressed in units
+	// of the ray direction vector. If the direction vector is normalized, T
+	// is the distance from the ray origin to the hit point.
+	T float64
 
-	// y
-	t1 = (b.Min.Y - r.o.Y) * r.invv.Y
-	t2 = (b.Max.Y - r.o.Y) * r.invv.Y
-	tmin = math.Max(tmin, math.Min(t1, t2))
-	tmax = math.Min(tmax, math.Max(t1, t2))
+	// Point is the hit point.
+	Point Vec
+
+	// Normal is the unit normal of the shape surface at the hit point. It
+	// always faces the ray origin, that is Normal.Dot(ray.Direction()) <= 0.
+	Normal Vec
+}
+
+// HitRect computes the first point where the ray hits the boundary of the
+// rectangle b, along with the normal of the hit side.
+//
+// If the ray origin is inside b, the hit point is where the ray exits b and
+// the normal is the one of the exit side, oriented toward the inside of b.
+// ok is false if the ray does not intersect b or if its direction is the null
+// vector.
+func (r Ray) HitRect(b Rectangle) (hit RayHit, ok bool) {
+	tmin, tmax, amin, amax, ok := r.clipRect(b)
+	if !ok || r.v == ZV {
+		return RayHit{}, false
+	}
+	t, axis := tmin, amin
+	if tmin < 0 {
+		t, axis = tmax, amax
+	}
+	hit.T = t
+	hit.Point = r.At(t)
+	if axis == 0 {
+		hit.Normal = Vec{-math.Copysign(1, r.v.X), 0}
+	} else {
+		hit.Normal = Vec{0, -math.Copysign(1, r.v.Y)}
+	}
+	return hit, true
+}
+
+// A Hitter is the interface implemented by shapes a Ray can be cast against.
+type Hitter interface {
+	// Hit returns the first point where r hits the shape boundary. ok is
+	// false if r misses the shape.
+	Hit(r Ray) (hit RayHit, ok bool)
+}
 
-	return tmax >= math.Max(tmin, 0.0)
+// HitCircle computes the first point where the ray hits the circle c.
+//
+// If the ray origin is inside c, the hit point is where the ray exits c and
+// the normal is oriented toward the center of c. ok is false if the ray does
+// not intersect c, or if c has a null radius and thus no defined normal.
+func (r Ray) HitCircle(c Circle) (hit RayHit, ok bool) {
+	if c.Radius <= 0 {
+		return RayHit{}, false
+	}
+	oc := r.o.Sub(c.Center)
+	a := r.v.Dot(r.v)
+	b := oc.Dot(r.v)
+	cc := oc.Dot(oc) - c.Radius*c.Radius
+	disc := b*b - a*cc
+	if a == 0 || disc < 0 {
+		return RayHit{}, false
+	}
+	sq := math.Sqrt(disc)
+	t1, t2 := (-b-sq)/a, (-b+sq)/a
+	if t2 < 0 {
+		return RayHit{}, false
+	}
+
+	hit.T = t1
+	if t1 < 0 {
+		hit.T = t2
+	}
+	hit.Point = r.At(hit.T)
+	hit.Normal = hit.Point.Sub(c.Center).Normalize()
+	if t1 < 0 {
+		hit.Normal = hit.Normal.Mul(-1)
+	}
+	return hit, true
+}
+
+// HitSegment computes the point where the ray hits the segment s.
+//
+// The normal is perpendicular to s, on the side of the ray origin. A ray
+// parallel to s never hits it. ok is false if the ray does not intersect s.
+func (r Ray) HitSegment(s Segment) (hit RayHit, ok bool) {
+	d := s.Vec()
+	denom := r.v.Cross(d)
+	if denom == 0 {
+		return RayHit{}, false
+	}
+	ao := s.A.Sub(r.o)
+	t := ao.Cross(d) / denom
+	u := ao.Cross(r.v) / denom
+	if t < 0 || u < 0 || u > 1 {
+		return RayHit{}, false
+	}
+
+	hit.T = t
+	hit.Point = s.At(u)
+	hit.Normal = Vec{-d.Y, d.X}.Normalize()
+	if hit.Normal.Dot(r.v) > 0 {
+		hit.Normal = hit.Normal.Mul(-1)
+	}
+	return hit, true
+}
+
+// HitPolygon computes the first point where the ray hits one of the edges of
+// the polygon whose vertices are the points of p. p is considered closed, so
+// the last point is joined to the first.
+//
+// ok is false if the ray does not intersect any edge of p.
+func (r Ray) HitPolygon(p Path) (hit RayHit, ok bool) {
+	for i := range p {
+		j := i + 1
+		if j == len(p) {
+			j = 0
+		}
+		if h, hok := r.HitSegment(Segment{p[i], p[j]}); hok && (!ok || h.T < hit.T) {
+			hit, ok = h, true
+		}
+	}
+	return
+}
+
+// Cast casts the ray against every shape and returns the nearest hit, along
+// with the index of the shape that has been hit. ok is false if no shape has
+// been hit.
+func (r Ray) Cast(shapes []Hitter) (hit RayHit, idx int, ok bool) {
+	idx = -1
+	for i, s := range shapes {
+		if h, hok := s.Hit(r); hok && (!ok || h.T < hit.T) {
+			hit, idx, ok = h, i, true
+		}
+	}
+	return
 }
 
 // String returns a string representation of r like with (o:Vec,v:Vec).
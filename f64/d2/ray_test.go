@@ -39,3 +39,164 @@ func TestRay(t *testing.T) {
 		}
 	}
 }
+
+func TestRayHitRect(t *testing.T) {
+	b := Rect(1, 1, 3, 2)
+	var tests = []struct {
+		r          Ray
+		ok         bool
+		tmin, tmax float64
+		hit        RayHit
+	}{
+		{NewRay(Vec{0, 1.5}, Vec{1, 0}), true, 1, 3, RayHit{1, Vec{1, 1.5}, Vec{-1, 0}}},
+		{NewRay(Vec{4, 1.5}, Vec{-2, 0}), true, 0.5, 1.5, RayHit{0.5, Vec{3, 1.5}, Vec{1, 0}}},
+		{NewRay(Vec{2, 0}, Vec{0, 1}), true, 1, 2, RayHit{1, Vec{2, 1}, Vec{0, -1}}},
+		{NewRay(Vec{2, 3}, Vec{0, -1}), true, 1, 2, RayHit{1, Vec{2, 2}, Vec{0, 1}}},
+		{NewRay(Vec{0, 0}, Vec{1, 1}), true, 1, 2, RayHit{1, Vec{1, 1}, Vec{-1, 0}}},
+		// origin inside: exit point, inward normal
+		{NewRay(Vec{2, 1.5}, Vec{1, 0}), true, -1, 1, RayHit{1, Vec{3, 1.5}, Vec{-1, 0}}},
+		// misses
+		{NewRay(Vec{0, 0}, Vec{1, 0}), false, 0, 0, RayHit{}},
+		{NewRay(Vec{4, 1.5}, Vec{1, 0}), false, 0, 0, RayHit{}},
+	}
+
+	for _, tt := range tests {
+		tmin, tmax, ok := tt.r.ClipRect(b)
+		if ok != tt.ok {
+			t.Errorf("%v.ClipRect(%v) ok = %v, want %v", tt.r, b, ok, tt.ok)
+			continue
+		}
+		if ok && (tmin != tt.tmin || tmax != tt.tmax) {
+			t.Errorf("%v.ClipRect(%v) = %v, %v, want %v, %v", tt.r, b, tmin, tmax, tt.tmin, tt.tmax)
+		}
+
+		hit, ok := tt.r.HitRect(b)
+		if ok != tt.ok {
+			t.Errorf("%v.HitRect(%v) ok = %v, want %v", tt.r, b, ok, tt.ok)
+			continue
+		}
+		if hit != tt.hit {
+			t.Errorf("%v.HitRect(%v) = %+v, want %+v", tt.r, b, hit, tt.hit)
+		}
+	}
+}
+
+func TestRayHitCircle(t *testing.T) {
+	c := Circ(5, 0, 2)
+	var tests = []struct {
+		r   Ray
+		ok  bool
+		hit RayHit
+	}{
+		{NewRay(Vec{0, 0}, Vec{1, 0}), true, RayHit{3, Vec{3, 0}, Vec{-1, 0}}},
+		{NewRay(Vec{10, 0}, Vec{-2, 0}), true, RayHit{1.5, Vec{7, 0}, Vec{1, 0}}},
+		{NewRay(Vec{5, -5}, Vec{0, 1}), true, RayHit{3, Vec{5, -2}, Vec{0, -1}}},
+		// tangent
+		{NewRay(Vec{0, 2}, Vec{1, 0}), true, RayHit{5, Vec{5, 2}, Vec{0, 1}}},
+		// origin inside
+		{NewRay(Vec{5, 0}, Vec{0, 1}), true, RayHit{2, Vec{5, 2}, Vec{0, -1}}},
+		// misses
+		{NewRay(Vec{0, 3}, Vec{1, 0}), false, RayHit{}},
+		{NewRay(Vec{0, 0}, Vec{-1, 0}), false, RayHit{}},
+	}
+
+	for _, tt := range tests {
+		hit, ok := tt.r.HitCircle(c)
+		if ok != tt.ok {
+			t.Errorf("%v.HitCircle(%v) ok = %v, want %v", tt.r, c, ok, tt.ok)
+			continue
+		}
+		if ok && (!approx(hit.T, tt.hit.T) || !hit.Point.Approx(tt.hit.Point) || !hit.Normal.Approx(tt.hit.Normal)) {
+			t.Errorf("%v.HitCircle(%v) = %+v, want %+v", tt.r, c, hit, tt.hit)
+		}
+	}
+
+	// null radius
+	if hit, ok := NewRay(Vec{0, 0}, Vec{1, 0}).HitCircle(Circ(5, 0, 0)); ok {
+		t.Errorf("HitCircle() of a null radius circle = %+v, want no hit", hit)
+	}
+}
+
+func TestRayHitSegment(t *testing.T) {
+	s := Seg(2, -1, 2, 1)
+	var tests = []struct {
+		r   Ray
+		ok  bool
+		hit RayHit
+	}{
+		{NewRay(Vec{0, 0}, Vec{1, 0}), true, RayHit{2, Vec{2, 0}, Vec{-1, 0}}},
+		{NewRay(Vec{4, 0}, Vec{-1, 0}), true, RayHit{2, Vec{2, 0}, Vec{1, 0}}},
+		{NewRay(Vec{0, 0}, Vec{1, 0.5}), true, RayHit{2, Vec{2, 1}, Vec{-1, 0}}},
+		{NewRay(Vec{0, 0}, Vec{1, 0.6}), false, RayHit{}},
+		{NewRay(Vec{0, 0}, Vec{-1, 0}), false, RayHit{}},
+		{NewRay(Vec{2, -3}, Vec{0, 1}), false, RayHit{}},
+	}
+
+	for _, tt := range tests {
+		hit, ok := tt.r.HitSegment(s)
+		if ok != tt.ok {
+			t.Errorf("%v.HitSegment(%v) ok = %v, want %v", tt.r, s, ok, tt.ok)
+			continue
+		}
+		if ok && (!approx(hit.T, tt.hit.T) || !hit.Point.Approx(tt.hit.Point) || !hit.Normal.Approx(tt.hit.Normal)) {
+			t.Errorf("%v.HitSegment(%v) = %+v, want %+v", tt.r, s, hit, tt.hit)
+		}
+	}
+}
+
+func TestRayHitPolygon(t *testing.T) {
+	// a diamond centered on (0,0)
+	p := Path{{0, -2}, {2, 0}, {0, 2}, {-2, 0}}
+
+	r := NewRay(Vec{-5, 0}, Vec{1, 0})
+	hit, ok := r.HitPolygon(p)
+	if !ok || !approx(hit.T, 3) || !hit.Point.Approx(Vec{-2, 0}) {
+		t.Errorf("%v.HitPolygon(%v) = %+v, %v, want hit at (-2,0)", r, p, hit, ok)
+	}
+	if hit.Normal.Dot(r.Direction()) > 0 {
+		t.Errorf("%v.HitPolygon(%v) normal = %v, want facing the ray", r, p, hit.Normal)
+	}
+
+	r = NewRay(Vec{0, 0}, Vec{1, 1})
+	hit, ok = r.HitPolygon(p)
+	if !ok || !approx(hit.T, 1) || !hit.Point.Approx(Vec{1, 1}) || !hit.Normal.Approx(Vec{-1, -1}.Normalize()) {
+		t.Errorf("%v.HitPolygon(%v) = %+v, %v, want exit hit at (1,1)", r, p, hit, ok)
+	}
+
+	r = NewRay(Vec{-5, 3}, Vec{1, 0})
+	if _, ok = r.HitPolygon(p); ok {
+		t.Errorf("%v.HitPolygon(%v) ok = true, want false", r, p)
+	}
+}
+
+func TestRayCast(t *testing.T) {
+	shapes := []Hitter{
+		Circ(10, 0, 1),
+		Rect(4, -1, 5, 1),
+		Seg(7, -1, 7, 1),
+		Circ(0, 10, 1),
+	}
+
+	var tests = []struct {
+		r   Ray
+		idx int
+		t   float64
+	}{
+		{NewRay(Vec{0, 0}, Vec{1, 0}), 1, 4},
+		{NewRay(Vec{6, 0}, Vec{1, 0}), 2, 1},
+		{NewRay(Vec{8, 0}, Vec{1, 0}), 0, 1},
+		{NewRay(Vec{0, 0}, Vec{0, 1}), 3, 9},
+		{NewRay(Vec{0, 0}, Vec{-1, 0}), -1, 0},
+	}
+
+	for _, tt := range tests {
+		hit, idx, ok := tt.r.Cast(shapes)
+		if idx != tt.idx || ok != (tt.idx != -1) {
+			t.Errorf("%v.Cast() idx = %v, %v, want %v", tt.r, idx, ok, tt.idx)
+			continue
+		}
+		if ok && !approx(hit.T, tt.t) {
+			t.Errorf("%v.Cast() T = %v, want %v", tt.r, hit.T, tt.t)
+		}
+	}
+}
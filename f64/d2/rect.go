@@ -165,6 +165,11 @@ func (r Rectangle) Canon() Rectangle {
 	return r
 }
 
+// Hit implements the Hitter interface.
+func (r Rectangle) Hit(ray Ray) (RayHit, bool) {
+	return ray.HitRect(r)
+}
+
 // ZR is the zero Rectangle.
 var ZR Rectangle
 
@@ -0,0 +1,111 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import "math"
+
+// solveQuadratic returns the real roots of a*t² + b*t + c, in no particular
+// order. A double root is returned once. It returns nil if there are no
+// roots, or if all the coefficients are null.
+func solveQuadratic(a, b, c float64) []float64 {
+	scale := math.Max(math.Abs(b), math.Abs(c))
+	if math.Abs(a) <= 1e-12*scale || a == 0 {
+		if b == 0 {
+			return nil
+		}
+		return []float64{-c / b}
+	}
+	disc := b*b - 4*a*c
+	switch {
+	case disc < -1e-12*b*b:
+		return nil
+	case disc <= 0:
+		return []float64{-b / (2 * a)}
+	}
+	// avoid the cancellation of b and the square root of disc
+	q := -(b + math.Copysign(math.Sqrt(disc), b)) / 2
+	if q == 0 {
+		return []float64{0}
+	}
+	return []float64{q / a, c / q}
+}
+
+// solveCubic returns the real roots of a*t³ + b*t² + c*t + d, in no
+// particular order. It returns nil if there are no roots, or if all the
+// coefficients are null.
+func solveCubic(a, b, c, d float64) []float64 {
+	scale := math.Max(math.Abs(b), math.Max(math.Abs(c), math.Abs(d)))
+	if math.Abs(a) <= 1e-12*scale || a == 0 {
+		return solveQuadratic(b, c, d)
+	}
+
+	// depressed cubic x³ + p*x + q, with t = x - b/3
+	b, c, d = b/a, c/a, d/a
+	shift := -b / 3
+	p := c - b*b/3
+	q := 2*b*b*b/27 - b*c/3 + d
+
+	var roots []float64
+	switch disc := q*q/4 + p*p*p/27; {
+	case p == 0:
+		roots = []float64{math.Cbrt(-q) + shift}
+	case disc > 0:
+		sq := math.Sqrt(disc)
+		roots = []float64{math.Cbrt(-q/2+sq) + math.Cbrt(-q/2-sq) + shift}
+	default:
+		r := 2 * math.Sqrt(-p/3)
+		phi := math.Acos(math.Max(-1, math.Min(1, 3*q/(2*p)*math.Sqrt(-3/p)))) / 3
+		for k := 0.0; k < 3; k++ {
+			roots = append(roots, r*math.Cos(phi-2*math.Pi*k/3)+shift)
+		}
+	}
+
+	// polish the roots, the closed form formulas being inaccurate
+	for i, t := range roots {
+		for n := 0; n < 2; n++ {
+			f := ((t+b)*t+c)*t + d
+			df := (3*t+2*b)*t + c
+			if df == 0 {
+				break
+			}
+			t -= f / df
+		}
+		roots[i] = t
+	}
+	return roots
+}
+
+// Nodes and weights of the 5 points Gauss-Legendre quadrature on [-1, 1].
+var (
+	glNodes   = [5]float64{0, -0.5384693101056831, 0.5384693101056831, -0.9061798459386640, 0.9061798459386640}
+	glWeights = [5]float64{0.5688888888888889, 0.4786286704993665, 0.4786286704993665, 0.2369268850561891, 0.2369268850561891}
+)
+
+// gaussLegendre returns the integral of f over [a, b], computed with the 5
+// points Gauss-Legendre quadrature.
+func gaussLegendre(f func(float64) float64, a, b float64) float64 {
+	h, m := (b-a)/2, (a+b)/2
+	var sum float64
+	for i, x := range glNodes {
+		sum += glWeights[i] * f(m+h*x)
+	}
+	return sum * h
+}
+
+// integrate returns the integral of f over [a, b], computed by adaptive
+// Gauss-Legendre quadrature, with an absolute error that should be less than
+// tol.
+func integrate(f func(float64) float64, a, b, tol float64) float64 {
+	return integrateRec(f, a, b, gaussLegendre(f, a, b), tol, 20)
+}
+
+func integrateRec(f func(float64) float64, a, b, whole, tol float64, depth int) float64 {
+	m := (a + b) / 2
+	left, right := gaussLegendre(f, a, m), gaussLegendre(f, m, b)
+	if depth == 0 || math.Abs(left+right-whole) <= tol {
+		return left + right
+	}
+	return integrateRec(f, a, m, left, tol/2, depth-1) + integrateRec(f, m, b, right, tol/2, depth-1)
+}
@@ -0,0 +1,54 @@
+package d2
+
+import (
+	"math"
+	"sort"
+	"testing"
+)
+
+func TestSolvePolynomials(t *testing.T) {
+	var tests = []struct {
+		a, b, c, d float64
+		want       []float64
+	}{
+		{1, -6, 11, -6, []float64{1, 2, 3}},
+		{2, -12, 22, -12, []float64{1, 2, 3}},
+		{1, 0, 0, -8, []float64{2}},
+		{1, 0, 1, 0, []float64{0}},
+		{1, -3, 3, -1, []float64{1}},
+		{0, 1, -3, 2, []float64{1, 2}},
+		{0, 1, -2, 1, []float64{1}},
+		{0, 1, 0, 1, nil},
+		{0, 0, 2, -1, []float64{0.5}},
+		{0, 0, 0, 1, nil},
+	}
+	for _, tt := range tests {
+		got := solveCubic(tt.a, tt.b, tt.c, tt.d)
+		sort.Float64s(got)
+		ok := len(got) == len(tt.want)
+		for i := 0; ok && i < len(got); i++ {
+			ok = math.Abs(got[i]-tt.want[i]) < 1e-6
+		}
+		if !ok {
+			t.Errorf("solveCubic(%v, %v, %v, %v) = %v, want %v", tt.a, tt.b, tt.c, tt.d, got, tt.want)
+		}
+	}
+}
+
+func TestIntegrate(t *testing.T) {
+	var tests = []struct {
+		f    func(float64) float64
+		a, b float64
+		want float64
+	}{
+		{func(x float64) float64 { return x * x }, 0, 3, 9},
+		{math.Sin, 0, math.Pi, 2},
+		{math.Sqrt, 0, 1, 2.0 / 3},
+		{func(x float64) float64 { return math.Abs(x - 0.3) }, 0, 1, 0.29},
+	}
+	for i, tt := range tests {
+		if got := integrate(tt.f, tt.a, tt.b, 1e-10); math.Abs(got-tt.want) > 1e-8 {
+			t.Errorf("test %d: integrate = %v, want %v", i, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,591 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+// Package rtree implements an R-tree, a spatial index of 2D objects
+// represented by their bounding rectangles, using the R*-tree heuristics.
+package rtree
+
+import (
+	"container/heap"
+	"math"
+	"sort"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+// DefaultMaxEntries is the maximum number of entries per node used when none
+// is specified.
+const DefaultMaxEntries = 16
+
+// An RTree indexes d2.Rectangler items by their rectangles, allowing to
+// efficiently find the items overlapping a rectangle, or the nearest items
+// from a point.
+//
+// Rectangles are considered as closed: flat rectangles, as the ones of points
+// or horizontal segments, are correctly indexed, and rectangles sharing only
+// a side or a corner overlap.
+//
+// The rectangle of an item must not change while it's in the tree.
+type RTree struct {
+	root       *node
+	size       int
+	minEntries int
+	maxEntries int
+}
+
+// A node holds entries that are items if the node is a leaf, at level 0, or
+// child nodes otherwise.
+type node struct {
+	level   int
+	entries []entry
+}
+
+type entry struct {
+	rect  d2.Rectangle
+	child *node         // internal nodes only
+	item  d2.Rectangler // leaves only
+}
+
+func (n *node) leaf() bool {
+	return n.level == 0
+}
+
+func (n *node) bounds() d2.Rectangle {
+	r := n.entries[0].rect
+	for _, e := range n.entries[1:] {
+		r = union(r, e.rect)
+	}
+	return r
+}
+
+// New returns an empty R-tree having at most maxEntries entries per node.
+// Values of maxEntries lower than 4, like 0, select DefaultMaxEntries.
+func New(maxEntries int) *RTree {
+	if maxEntries < 4 {
+		maxEntries = DefaultMaxEntries
+	}
+	// 40% is the minimum fill factor advised for R*-trees
+	minEntries := maxEntries * 2 / 5
+	if minEntries < 2 {
+		minEntries = 2
+	}
+	return &RTree{
+		root:       &node{},
+		minEntries: minEntries,
+		maxEntries: maxEntries,
+	}
+}
+
+// Len returns the number of items in t.
+func (t *RTree) Len() int {
+	return t.size
+}
+
+// Bounds returns the smallest rectangle containing all the items of t, or
+// d2.ZR if t is empty.
+func (t *RTree) Bounds() d2.Rectangle {
+	if len(t.root.entries) == 0 {
+		return d2.ZR
+	}
+	return t.root.bounds()
+}
+
+// Search returns the items of t whose rectangles overlap r.
+func (t *RTree) Search(r d2.Rectangle) []d2.Rectangler {
+	var items []d2.Rectangler
+	t.SearchFunc(r, func(item d2.Rectangler) bool {
+		items = append(items, item)
+		return true
+	})
+	return items
+}
+
+// SearchFunc calls fn for every item of t whose rectangle overlaps r, until fn
+// returns false.
+func (t *RTree) SearchFunc(r d2.Rectangle, fn func(item d2.Rectangler) bool) {
+	search(t.root, r, fn)
+}
+
+func search(n *node, r d2.Rectangle, fn func(item d2.Rectangler) bool) bool {
+	for i := range n.entries {
+		e := &n.entries[i]
+		if !intersects(e.rect, r) {
+			continue
+		}
+		if n.leaf() {
+			if !fn(e.item) {
+				return false
+			}
+		} else if !search(e.child, r, fn) {
+			return false
+		}
+	}
+	return true
+}
+
+// Insert adds item to t.
+func (t *RTree) Insert(item d2.Rectangler) {
+	ins := inserter{t: t, reinserted: make(map[int]bool)}
+	ins.insert(entry{rect: item.Rectangle(), item: item}, 0)
+	t.size++
+}
+
+// inserter inserts entries into a tree. Entries removed from overflowing
+// nodes, to be reinserted, are kept pending until the insertion of the entry
+// causing the overflow is complete.
+type inserter struct {
+	t          *RTree
+	reinserted map[int]bool // levels where a reinsertion occurred
+	pending    []pendingEntry
+}
+
+type pendingEntry struct {
+	e     entry
+	level int
+}
+
+// insert inserts e in a node at the given level.
+func (ins *inserter) insert(e entry, level int) {
+	ins.pending = append(ins.pending, pendingEntry{e, level})
+	for len(ins.pending) > 0 {
+		p := ins.pending[len(ins.pending)-1]
+		ins.pending = ins.pending[:len(ins.pending)-1]
+
+		t := ins.t
+		if sibling := ins.insertNode(t.root, p.e, p.level); sibling != nil {
+			// grow the tree
+			root := &node{level: t.root.level + 1}
+			root.entries = []entry{
+				{rect: t.root.bounds(), child: t.root},
+				{rect: sibling.bounds(), child: sibling},
+			}
+			t.root = root
+		}
+	}
+}
+
+// insertNode inserts e in the subtree rooted at n, and returns the new sibling
+// of n if n has been split.
+func (ins *inserter) insertNode(n *node, e entry, level int) *node {
+	if n.level == level {
+		n.entries = append(n.entries, e)
+	} else {
+		i := chooseSubtree(n, e.rect)
+		child := n.entries[i].child
+		sibling := ins.insertNode(child, e, level)
+		n.entries[i].rect = child.bounds()
+		if sibling != nil {
+			n.entries = append(n.entries, entry{rect: sibling.bounds(), child: sibling})
+		}
+	}
+	if len(n.entries) <= ins.t.maxEntries {
+		return nil
+	}
+
+	// overflow, reinsert some entries the first time it happens at this
+	// level, split otherwise
+	if n != ins.t.root && !ins.reinserted[n.level] {
+		ins.reinserted[n.level] = true
+		ins.reinsert(n)
+		return nil
+	}
+	return ins.t.split(n)
+}
+
+// reinsert removes the 30% of the entries of n that are the farthest from
+// its center, and schedules their reinsertion, closest first.
+func (ins *inserter) reinsert(n *node) {
+	c := n.bounds().Center()
+	dist := func(e entry) float64 {
+		d := e.rect.Center().Sub(c)
+		return d.Dot(d)
+	}
+	sort.SliceStable(n.entries, func(i, j int) bool {
+		return dist(n.entries[i]) < dist(n.entries[j])
+	})
+	p := len(n.entries) * 3 / 10
+	if p < 1 {
+		p = 1
+	}
+	keep := len(n.entries) - p
+	// pending entries are popped from the end
+	for i := len(n.entries) - 1; i >= keep; i-- {
+		ins.pending = append(ins.pending, pendingEntry{n.entries[i], n.level})
+	}
+	n.entries = n.entries[:keep:keep]
+}
+
+// chooseSubtree returns the index of the entry of n, an internal node, where
+// to insert a rectangle r.
+func chooseSubtree(n *node, r d2.Rectangle) int {
+	best := 0
+	if n.level == 1 {
+		// children are leaves, minimize the overlap enlargement, then the
+		// area enlargement, then the area
+		bestOverlap, bestEnl, bestArea := math.Inf(1), math.Inf(1), math.Inf(1)
+		for i, e := range n.entries {
+			u := union(e.rect, r)
+			a := area(e.rect)
+			enl := area(u) - a
+			var overlap float64
+			if u != e.rect {
+				for j, o := range n.entries {
+					if j != i {
+						overlap += overlapArea(u, o.rect) - overlapArea(e.rect, o.rect)
+					}
+				}
+			}
+			if overlap < bestOverlap ||
+				overlap == bestOverlap && (enl < bestEnl || enl == bestEnl && a < bestArea) {
+				best, bestOverlap, bestEnl, bestArea = i, overlap, enl, a
+			}
+		}
+		return best
+	}
+
+	// minimize the area enlargement, then the area
+	bestEnl, bestArea := math.Inf(1), math.Inf(1)
+	for i, e := range n.entries {
+		a := area(e.rect)
+		enl := area(union(e.rect, r)) - a
+		if enl < bestEnl || enl == bestEnl && a < bestArea {
+			best, bestEnl, bestArea = i, enl, a
+		}
+	}
+	return best
+}
+
+// split splits the overflowing node n in 2, keeping the first group of entries
+// in n, and returns the new node holding the second group.
+func (t *RTree) split(n *node) *node {
+	m := t.minEntries
+	byMin := func(axis int) func(i, j int) bool {
+		return func(i, j int) bool {
+			a, b := n.entries[i].rect, n.entries[j].rect
+			if axis == 0 {
+				return a.Min.X < b.Min.X || a.Min.X == b.Min.X && a.Max.X < b.Max.X
+			}
+			return a.Min.Y < b.Min.Y || a.Min.Y == b.Min.Y && a.Max.Y < b.Max.Y
+		}
+	}
+	byMax := func(axis int) func(i, j int) bool {
+		return func(i, j int) bool {
+			a, b := n.entries[i].rect, n.entries[j].rect
+			if axis == 0 {
+				return a.Max.X < b.Max.X || a.Max.X == b.Max.X && a.Min.X < b.Min.X
+			}
+			return a.Max.Y < b.Max.Y || a.Max.Y == b.Max.Y && a.Min.Y < b.Min.Y
+		}
+	}
+
+	// choose the split axis, minimizing the sum of the margins of all the
+	// distributions
+	bestAxis, bestMargin := 0, math.Inf(1)
+	for axis := 0; axis < 2; axis++ {
+		var margin float64
+		for _, less := range []func(int) func(i, j int) bool{byMin, byMax} {
+			sort.SliceStable(n.entries, less(axis))
+			lo, hi := distributions(n.entries)
+			for k := m; k <= len(n.entries)-m; k++ {
+				margin += perimeter(lo[k-1]) + perimeter(hi[k])
+			}
+		}
+		if margin < bestMargin {
+			bestAxis, bestMargin = axis, margin
+		}
+	}
+
+	// choose the distribution minimizing the overlap, then the area
+	bestSort, bestK := 0, m
+	bestOverlap, bestArea := math.Inf(1), math.Inf(1)
+	for s, less := range []func(int) func(i, j int) bool{byMin, byMax} {
+		sort.SliceStable(n.entries, less(bestAxis))
+		lo, hi := distributions(n.entries)
+		for k := m; k <= len(n.entries)-m; k++ {
+			overlap := overlapArea(lo[k-1], hi[k])
+			a := area(lo[k-1]) + area(hi[k])
+			if overlap < bestOverlap || overlap == bestOverlap && a < bestArea {
+				bestSort, bestK, bestOverlap, bestArea = s, k, overlap, a
+			}
+		}
+	}
+	if bestSort == 0 {
+		sort.SliceStable(n.entries, byMin(bestAxis))
+	}
+
+	sibling := &node{level: n.level}
+	sibling.entries = append(make([]entry, 0, t.maxEntries+1), n.entries[bestK:]...)
+	n.entries = n.entries[:bestK]
+	return sibling
+}
+
+// distributions returns, for each k, the bounds of entries[:k+1] in lo[k] and
+// the bounds of entries[k:] in hi[k].
+func distributions(entries []entry) (lo, hi []d2.Rectangle) {
+	lo = make([]d2.Rectangle, len(entries))
+	hi = make([]d2.Rectangle, len(entries))
+	lo[0] = entries[0].rect
+	for i := 1; i < len(entries); i++ {
+		lo[i] = union(lo[i-1], entries[i].rect)
+	}
+	hi[len(entries)-1] = entries[len(entries)-1].rect
+	for i := len(entries) - 2; i >= 0; i-- {
+		hi[i] = union(hi[i+1], entries[i].rect)
+	}
+	return lo, hi
+}
+
+// Delete removes item from t, and reports whether it was found. Items are
+// compared with ==, so they must be of comparable types, like pointers.
+func (t *RTree) Delete(item d2.Rectangler) bool {
+	var orphans []pendingEntry
+	if !t.delete(t.root, item, item.Rectangle(), &orphans) {
+		return false
+	}
+	t.size--
+
+	// reinsert the entries of the removed nodes, at their level
+	ins := inserter{t: t, reinserted: make(map[int]bool)}
+	for _, o := range orphans {
+		ins.insert(o.e, o.level)
+	}
+	// shrink the tree
+	for !t.root.leaf() && len(t.root.entries) == 1 {
+		t.root = t.root.entries[0].child
+	}
+	return true
+}
+
+// delete removes item, having r as rectangle, from the subtree rooted at n.
+// Nodes having too few entries are removed and their entries are appended to
+// orphans.
+func (t *RTree) delete(n *node, item d2.Rectangler, r d2.Rectangle, orphans *[]pendingEntry) bool {
+	for i := range n.entries {
+		e := &n.entries[i]
+		if n.leaf() {
+			if e.item == item {
+				n.entries = append(n.entries[:i], n.entries[i+1:]...)
+				return true
+			}
+			continue
+		}
+		if !contains(e.rect, r) || !t.delete(e.child, item, r, orphans) {
+			continue
+		}
+		if child := e.child; len(child.entries) < t.minEntries {
+			for _, ce := range child.entries {
+				*orphans = append(*orphans, pendingEntry{ce, child.level})
+			}
+			n.entries = append(n.entries[:i], n.entries[i+1:]...)
+		} else {
+			e.rect = child.bounds()
+		}
+		return true
+	}
+	return false
+}
+
+// Nearest returns the k items of t that are the nearest from p, sorted by
+// increasing distance. The distance from p to an item is the distance to its
+// rectangle, null if p is inside it.
+//
+// Less than k items are returned if t contains less than k items.
+func (t *RTree) Nearest(p d2.Vec, k int) []d2.Rectangler {
+	if k <= 0 {
+		return nil
+	}
+	var items []d2.Rectangler
+	t.NearestFunc(p, func(item d2.Rectangler, dist float64) bool {
+		items = append(items, item)
+		return len(items) < k
+	})
+	return items
+}
+
+// NearestFunc calls fn for the items of t by increasing distance from p, with
+// the item and its distance, until fn returns false. The distance from p to an
+// item is the distance to its rectangle, null if p is inside it.
+func (t *RTree) NearestFunc(p d2.Vec, fn func(item d2.Rectangler, dist float64) bool) {
+	q := &queue{}
+	for _, e := range t.root.entries {
+		heap.Push(q, queued{entry: e, leaf: t.root.leaf(), dist: distSqr(e.rect, p)})
+	}
+	for q.Len() > 0 {
+		it := heap.Pop(q).(queued)
+		if it.leaf {
+			if !fn(it.entry.item, math.Sqrt(it.dist)) {
+				return
+			}
+			continue
+		}
+		n := it.entry.child
+		for _, e := range n.entries {
+			heap.Push(q, queued{entry: e, leaf: n.leaf(), dist: distSqr(e.rect, p)})
+		}
+	}
+}
+
+// queued is an entry in the priority queue of the nearest neighbors search.
+type queued struct {
+	entry entry
+	leaf  bool    // whether entry is an item
+	dist  float64 // squared distance to the query point
+}
+
+type queue []queued
+
+func (q queue) Len() int { return len(q) }
+
+func (q queue) Less(i, j int) bool {
+	// at equal distance, items come first
+	return q[i].dist < q[j].dist || q[i].dist == q[j].dist && q[i].leaf && !q[j].leaf
+}
+
+func (q queue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *queue) Push(x interface{}) { *q = append(*q, x.(queued)) }
+
+func (q *queue) Pop() interface{} {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
+
+// BulkLoad returns an R-tree having at most maxEntries entries per node,
+// containing items, built with the Sort-Tile-Recursive algorithm.
+//
+// It's much faster than inserting the items one by one, and produces a tree
+// with less overlap, which is well suited for static datasets. Values of
+// maxEntries lower than 4, like 0, select DefaultMaxEntries.
+func BulkLoad(maxEntries int, items []d2.Rectangler) *RTree {
+	t := New(maxEntries)
+	if len(items) == 0 {
+		return t
+	}
+	entries := make([]entry, len(items))
+	for i, item := range items {
+		entries[i] = entry{rect: item.Rectangle(), item: item}
+	}
+	level := 0
+	for {
+		nodes := t.pack(entries, level)
+		if len(nodes) == 1 {
+			t.root = nodes[0]
+			break
+		}
+		entries = make([]entry, len(nodes))
+		for i, n := range nodes {
+			entries[i] = entry{rect: n.bounds(), child: n}
+		}
+		level++
+	}
+	t.size = len(items)
+	return t
+}
+
+// pack groups entries into nodes at the given level, by tiling them into
+// vertical slices sorted along X, each one being sorted along Y.
+func (t *RTree) pack(entries []entry, level int) []*node {
+	M := t.maxEntries
+	nnodes := (len(entries) + M - 1) / M
+	nslices := int(math.Ceil(math.Sqrt(float64(nnodes))))
+	perSlice := nslices * M
+
+	center := func(e entry, axis int) float64 {
+		if axis == 0 {
+			return e.rect.Min.X + e.rect.Max.X
+		}
+		return e.rect.Min.Y + e.rect.Max.Y
+	}
+	sortAlong := func(es []entry, axis int) {
+		sort.Slice(es, func(i, j int) bool {
+			return center(es[i], axis) < center(es[j], axis)
+		})
+	}
+
+	sortAlong(entries, 0)
+	var nodes []*node
+	for s := 0; s < len(entries); s += perSlice {
+		slice := entries[s:minInt(s+perSlice, len(entries))]
+		sortAlong(slice, 1)
+		// distribute the entries evenly among the nodes of the slice
+		n := (len(slice) + M - 1) / M
+		for i := 0; i < n; i++ {
+			group := slice[i*len(slice)/n : (i+1)*len(slice)/n]
+			nd := &node{level: level, entries: make([]entry, len(group), M+1)}
+			copy(nd.entries, group)
+			nodes = append(nodes, nd)
+		}
+	}
+	return nodes
+}
+
+// minf and maxf are faster than math.Min and math.Max, that handle special
+// values which can't appear in rectangles.
+func minf(a, b float64) float64 {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxf(a, b float64) float64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Rectangle helpers, where rectangles are closed, contrary to d2.Rectangle
+// methods that consider flat rectangles as empty.
+
+func union(a, b d2.Rectangle) d2.Rectangle {
+	return d2.Rectangle{
+		Min: d2.Vec{X: minf(a.Min.X, b.Min.X), Y: minf(a.Min.Y, b.Min.Y)},
+		Max: d2.Vec{X: maxf(a.Max.X, b.Max.X), Y: maxf(a.Max.Y, b.Max.Y)},
+	}
+}
+
+func area(r d2.Rectangle) float64 {
+	return r.Dx() * r.Dy()
+}
+
+func perimeter(r d2.Rectangle) float64 {
+	return r.Dx() + r.Dy()
+}
+
+func intersects(a, b d2.Rectangle) bool {
+	return a.Min.X <= b.Max.X && b.Min.X <= a.Max.X &&
+		a.Min.Y <= b.Max.Y && b.Min.Y <= a.Max.Y
+}
+
+// contains reports whether b is inside a.
+func contains(a, b d2.Rectangle) bool {
+	return a.Min.X <= b.Min.X && b.Max.X <= a.Max.X &&
+		a.Min.Y <= b.Min.Y && b.Max.Y <= a.Max.Y
+}
+
+func overlapArea(a, b d2.Rectangle) float64 {
+	dx := minf(a.Max.X, b.Max.X) - maxf(a.Min.X, b.Min.X)
+	dy := minf(a.Max.Y, b.Max.Y) - maxf(a.Min.Y, b.Min.Y)
+	if dx <= 0 || dy <= 0 {
+		return 0
+	}
+	return dx * dy
+}
+
+// distSqr returns the squared distance from p to r.
+func distSqr(r d2.Rectangle, p d2.Vec) float64 {
+	dx := maxf(0, maxf(r.Min.X-p.X, p.X-r.Max.X))
+	dy := maxf(0, maxf(r.Min.Y-p.Y, p.Y-r.Max.Y))
+	return dx*dx + dy*dy
+}
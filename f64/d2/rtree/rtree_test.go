@@ -0,0 +1,264 @@
+package rtree
+
+import (
+	"math"
+	"math/rand"
+	"sort"
+	"testing"
+
+	"github.com/arl/gogeo/f64/d2"
+)
+
+type box struct {
+	id int
+	r  d2.Rectangle
+}
+
+func (b *box) Rectangle() d2.Rectangle {
+	return b.r
+}
+
+// randomBoxes returns n boxes in [0,1000]², a quarter of them being points
+// and a quarter flat.
+func randomBoxes(rng *rand.Rand, n int) []d2.Rectangler {
+	items := make([]d2.Rectangler, n)
+	for i := range items {
+		x, y := rng.Float64()*1000, rng.Float64()*1000
+		w, h := rng.Float64()*20, rng.Float64()*20
+		switch i % 4 {
+		case 0:
+			w, h = 0, 0
+		case 1:
+			h = 0
+		}
+		items[i] = &box{id: i, r: d2.Rect(x, y, x+w, y+h)}
+	}
+	return items
+}
+
+// checkTree verifies the invariants of t: the rectangles of the entries are
+// the bounds of their children, all the leaves are at level 0, and nodes are
+// filled between the minimum and the maximum number of entries.
+func checkTree(t *testing.T, tr *RTree, checkMin bool) {
+	t.Helper()
+	count := 0
+	var walk func(n *node)
+	walk = func(n *node) {
+		if len(n.entries) > tr.maxEntries {
+			t.Fatalf("node at level %d has %d entries, max is %d", n.level, len(n.entries), tr.maxEntries)
+		}
+		if checkMin && n != tr.root && len(n.entries) < tr.minEntries {
+			t.Fatalf("node at level %d has %d entries, min is %d", n.level, len(n.entries), tr.minEntries)
+		}
+		for _, e := range n.entries {
+			if n.leaf() {
+				if e.rect != e.item.Rectangle() {
+					t.Fatalf("leaf entry rect %v, want %v", e.rect, e.item.Rectangle())
+				}
+				count++
+				continue
+			}
+			if e.child.level != n.level-1 {
+				t.Fatalf("child at level %d of a node at level %d", e.child.level, n.level)
+			}
+			if len(e.child.entries) == 0 || e.rect != e.child.bounds() {
+				t.Fatalf("entry rect %v, want child bounds", e.rect)
+			}
+			walk(e.child)
+		}
+	}
+	walk(tr.root)
+	if count != tr.Len() {
+		t.Fatalf("tree has %d items, Len() = %d", count, tr.Len())
+	}
+}
+
+// checkSearch compares the results of random searches with a brute force
+// search among items.
+func checkSearch(t *testing.T, rng *rand.Rand, tr *RTree, items []d2.Rectangler) {
+	t.Helper()
+	for i := 0; i < 50; i++ {
+		x, y := rng.Float64()*1000, rng.Float64()*1000
+		q := d2.Rect(x, y, x+rng.Float64()*100, y+rng.Float64()*100)
+		if i%10 == 0 {
+			q = d2.Rect(x, y, x, y)
+		}
+		want := make(map[d2.Rectangler]bool)
+		for _, it := range items {
+			if intersects(it.Rectangle(), q) {
+				want[it] = true
+			}
+		}
+		got := tr.Search(q)
+		if len(got) != len(want) {
+			t.Fatalf("Search(%v) returned %d items, want %d", q, len(got), len(want))
+		}
+		for _, it := range got {
+			if !want[it] {
+				t.Fatalf("Search(%v) returned %v, that doesn't overlap", q, it.Rectangle())
+			}
+		}
+	}
+}
+
+func TestInsertSearch(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	items := randomBoxes(rng, 2000)
+	tr := New(8)
+	for i, it := range items {
+		tr.Insert(it)
+		if i%500 == 0 {
+			checkTree(t, tr, true)
+		}
+	}
+	checkTree(t, tr, true)
+	checkSearch(t, rng, tr, items)
+
+	// bounds
+	want := items[0].Rectangle()
+	for _, it := range items {
+		want = union(want, it.Rectangle())
+	}
+	if got := tr.Bounds(); got != want {
+		t.Errorf("Bounds() = %v, want %v", got, want)
+	}
+
+	// touching rectangles overlap
+	tr = New(0)
+	tr.Insert(&box{r: d2.Rect(0, 0, 1, 1)})
+	if got := tr.Search(d2.Rect(1, 1, 2, 2)); len(got) != 1 {
+		t.Errorf("Search() of a touching rectangle returned %d items, want 1", len(got))
+	}
+
+	// early stop
+	n := 0
+	New(0).SearchFunc(d2.Rect(0, 0, 1, 1), func(d2.Rectangler) bool { n++; return true })
+	tr = BulkLoad(0, items)
+	tr.SearchFunc(d2.Rect(0, 0, 1000, 1000), func(d2.Rectangler) bool { n++; return n < 10 })
+	if n != 10 {
+		t.Errorf("SearchFunc() called fn %d times, want 10", n)
+	}
+}
+
+func TestDelete(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	items := randomBoxes(rng, 2000)
+	tr := New(6)
+	for _, it := range items {
+		tr.Insert(it)
+	}
+
+	rng.Shuffle(len(items), func(i, j int) { items[i], items[j] = items[j], items[i] })
+	for i, it := range items[:1500] {
+		if !tr.Delete(it) {
+			t.Fatalf("Delete(%v) failed", it.Rectangle())
+		}
+		if tr.Delete(it) {
+			t.Fatalf("Delete(%v) of a deleted item succeeded", it.Rectangle())
+		}
+		if i%300 == 0 {
+			checkTree(t, tr, true)
+			checkSearch(t, rng, tr, items[i+1:])
+		}
+	}
+	items = items[1500:]
+	if tr.Len() != len(items) {
+		t.Fatalf("Len() = %d, want %d", tr.Len(), len(items))
+	}
+	checkTree(t, tr, true)
+	checkSearch(t, rng, tr, items)
+
+	// same rectangle, different item
+	if tr.Delete(&box{r: items[0].Rectangle()}) {
+		t.Errorf("Delete() of an item not in the tree succeeded")
+	}
+
+	for _, it := range items {
+		if !tr.Delete(it) {
+			t.Fatalf("Delete(%v) failed", it.Rectangle())
+		}
+	}
+	if tr.Len() != 0 || !tr.root.leaf() || tr.Bounds() != d2.ZR {
+		t.Errorf("after deleting all the items, Len() = %d, Bounds() = %v", tr.Len(), tr.Bounds())
+	}
+	if got := tr.Search(d2.Rect(0, 0, 1000, 1000)); len(got) != 0 {
+		t.Errorf("Search() in an empty tree returned %d items", len(got))
+	}
+}
+
+func TestNearest(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	items := randomBoxes(rng, 1000)
+	tr := New(0)
+	for _, it := range items {
+		tr.Insert(it)
+	}
+
+	for i := 0; i < 50; i++ {
+		p := d2.Vec{X: rng.Float64()*1200 - 100, Y: rng.Float64()*1200 - 100}
+		dists := make([]float64, len(items))
+		for j, it := range items {
+			dists[j] = math.Sqrt(distSqr(it.Rectangle(), p))
+		}
+		sort.Float64s(dists)
+
+		got := tr.Nearest(p, 10)
+		if len(got) != 10 {
+			t.Fatalf("Nearest(%v, 10) returned %d items", p, len(got))
+		}
+		for j, it := range got {
+			if d := math.Sqrt(distSqr(it.Rectangle(), p)); d != dists[j] {
+				t.Fatalf("Nearest(%v, 10)[%d] is at distance %v, want %v", p, j, d, dists[j])
+			}
+		}
+	}
+
+	if got := tr.Nearest(d2.Vec{}, len(items)+10); len(got) != len(items) {
+		t.Errorf("Nearest() returned %d items, want %d", len(got), len(items))
+	}
+	if got := tr.Nearest(d2.Vec{}, 0); len(got) != 0 {
+		t.Errorf("Nearest(p, 0) returned %d items, want 0", len(got))
+	}
+	if got := New(0).Nearest(d2.Vec{}, 3); len(got) != 0 {
+		t.Errorf("Nearest() in an empty tree returned %d items", len(got))
+	}
+
+	// the point is inside the rectangle
+	tr = New(0)
+	b := &box{r: d2.Rect(-1, -1, 1, 1)}
+	tr.Insert(b)
+	tr.Insert(&box{r: d2.Rect(2, 2, 3, 3)})
+	tr.NearestFunc(d2.Vec{}, func(item d2.Rectangler, dist float64) bool {
+		if item != b || dist != 0 {
+			t.Errorf("NearestFunc() called with %v at %v, want %v at 0", item.Rectangle(), dist, b.r)
+		}
+		return false
+	})
+}
+
+func TestBulkLoad(t *testing.T) {
+	rng := rand.New(rand.NewSource(4))
+	for _, n := range []int{0, 1, 5, 16, 17, 300, 5000} {
+		items := randomBoxes(rng, n)
+		tr := BulkLoad(0, items)
+		if tr.Len() != n {
+			t.Fatalf("BulkLoad() of %d items, Len() = %d", n, tr.Len())
+		}
+		checkTree(t, tr, false)
+		checkSearch(t, rng, tr, items)
+	}
+
+	// the tree can be modified afterwards
+	items := randomBoxes(rng, 3000)
+	tr := BulkLoad(10, items[:2000])
+	for _, it := range items[2000:] {
+		tr.Insert(it)
+	}
+	for _, it := range items[:1000] {
+		if !tr.Delete(it) {
+			t.Fatalf("Delete(%v) failed", it.Rectangle())
+		}
+	}
+	checkTree(t, tr, false)
+	checkSearch(t, rng, tr, items[1000:])
+}
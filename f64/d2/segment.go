@@ -0,0 +1,268 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"fmt"
+	"math"
+)
+
+// A Segment is the finite part of a line comprised between 2 end points, A
+// and B.
+type Segment struct {
+	A, B Vec
+}
+
+// Seg is shorthand for Segment{Vec{x0, y0}, Vec{x1, y1}}.
+func Seg(x0, y0, x1, y1 float64) Segment {
+	return Segment{Vec{x0, y0}, Vec{x1, y1}}
+}
+
+// Vec returns the vector going from s.A to s.B.
+func (s Segment) Vec() Vec {
+	return s.B.Sub(s.A)
+}
+
+// Len returns the length of s.
+func (s Segment) Len() float64 {
+	return s.A.Dist(s.B)
+}
+
+// Mid returns the point located at the middle of s.
+func (s Segment) Mid() Vec {
+	return Vec{(s.A.X + s.B.X) / 2, (s.A.Y + s.B.Y) / 2}
+}
+
+// At returns the point located at parameter t along s, that is A + t*(B-A).
+// t = 0 returns A and t = 1 returns B.
+func (s Segment) At(t float64) Vec {
+	return s.A.Mul(1 - t).Add(s.B.Mul(t))
+}
+
+// Reverse returns the segment going from s.B to s.A.
+func (s Segment) Reverse() Segment {
+	return Segment{s.B, s.A}
+}
+
+// Degenerate reports whether both end points of s are the same, in which case
+// s is just a point.
+func (s Segment) Degenerate() bool {
+	return s.A == s.B
+}
+
+// Project returns the parameter t of the point of s that is the closest to p,
+// so that s.At(t) == s.ClosestPoint(p). t is always comprised in [0, 1].
+func (s Segment) Project(p Vec) float64 {
+	d := s.Vec()
+	l2 := d.Dot(d)
+	if l2 == 0 {
+		return 0
+	}
+	return math.Max(0, math.Min(1, p.Sub(s.A).Dot(d)/l2))
+}
+
+// ClosestPoint returns the point of s that is the closest to p.
+func (s Segment) ClosestPoint(p Vec) Vec {
+	return s.At(s.Project(p))
+}
+
+// Dist returns the shortest distance between p and any point of s.
+func (s Segment) Dist(p Vec) float64 {
+	return p.Dist(s.ClosestPoint(p))
+}
+
+// Rectangle returns the smallest rectangle containing s.
+//
+// Note that a Rectangle Max bound is exclusive, so s.B might not be In the
+// returned rectangle.
+func (s Segment) Rectangle() Rectangle {
+	return Rect(s.A.X, s.A.Y, s.B.X, s.B.Y)
+}
+
+// Ray returns the ray having s.A as origin and pointing toward s.B. The ray
+// direction is not normalized, so that the points of s are those of the ray
+// with a parameter comprised in [0, 1].
+func (s Segment) Ray() Ray {
+	return NewRay(s.A, s.Vec())
+}
+
+// Hit implements the Hitter interface.
+func (s Segment) Hit(r Ray) (RayHit, bool) {
+	return r.HitSegment(s)
+}
+
+// IntersectionKind describes the kind of intersection found between 2
+// segments.
+type IntersectionKind int
+
+const (
+	// NoIntersection indicates that the segments have no point in common.
+	NoIntersection IntersectionKind = iota
+
+	// PointIntersection indicates that the segments intersect at a single
+	// point.
+	PointIntersection
+
+	// OverlapIntersection indicates that the segments are collinear and
+	// share a sub-segment of non null length.
+	OverlapIntersection
+)
+
+func (k IntersectionKind) String() string {
+	switch k {
+	case NoIntersection:
+		return "none"
+	case PointIntersection:
+		return "point"
+	case OverlapIntersection:
+		return "overlap"
+	}
+	return fmt.Sprintf("IntersectionKind(%d)", int(k))
+}
+
+// parallelEps is the relative tolerance under which the cross product of 2
+// direction vectors is considered null.
+const parallelEps = 1e-12
+
+// Intersect computes the intersection between s and s2.
+//
+// The returned kind indicates whether the segments do not intersect, intersect
+// at a single point or overlap. In case of a single point intersection, the
+// returned segment is degenerate (both ends are the intersection point); in
+// case of an overlap, it's the shared sub-segment, oriented like s.
+func (s Segment) Intersect(s2 Segment) (IntersectionKind, Segment) {
+	r, q := s.Vec(), s2.Vec()
+	rr, qq := r.Dot(r), q.Dot(q)
+
+	// handle degenerate segments first
+	switch {
+	case rr == 0 && qq == 0:
+		if s.A == s2.A {
+			return PointIntersection, Segment{s.A, s.A}
+		}
+		return NoIntersection, Segment{}
+	case rr == 0:
+		if s2.onSegment(s.A) {
+			return PointIntersection, Segment{s.A, s.A}
+		}
+		return NoIntersection, Segment{}
+	case qq == 0:
+		if s.onSegment(s2.A) {
+			return PointIntersection, Segment{s2.A, s2.A}
+		}
+		return NoIntersection, Segment{}
+	}
+
+	pq := s2.A.Sub(s.A)
+	denom := r.Cross(q)
+	if math.Abs(denom) <= parallelEps*math.Sqrt(rr*qq) {
+		// parallel segments
+		if math.Abs(pq.Cross(r)) > parallelEps*math.Sqrt(rr*pq.Dot(pq)) {
+			return NoIntersection, Segment{}
+		}
+
+		// collinear, express s2 end points in s parametric space
+		t0 := pq.Dot(r) / rr
+		t1 := t0 + q.Dot(r)/rr
+		if t0 > t1 {
+			t0, t1 = t1, t0
+		}
+		t0, t1 = math.Max(t0, 0), math.Min(t1, 1)
+		switch {
+		case t0 > t1:
+			return NoIntersection, Segment{}
+		case t1-t0 <= parallelEps:
+			// touching end points, up to rounding errors
+			p := s.At(t0)
+			return PointIntersection, Segment{p, p}
+		}
+		return OverlapIntersection, Segment{s.At(t0), s.At(t1)}
+	}
+
+	t := pq.Cross(q) / denom
+	u := pq.Cross(r) / denom
+	if t < 0 || t > 1 || u < 0 || u > 1 {
+		return NoIntersection, Segment{}
+	}
+	p := s.At(t)
+	return PointIntersection, Segment{p, p}
+}
+
+// Intersects reports whether s and s2 have at least one point in common.
+func (s Segment) Intersects(s2 Segment) bool {
+	k, _ := s.Intersect(s2)
+	return k != NoIntersection
+}
+
+// onSegment reports whether p lies on s, with a tolerance proportional to the
+// length of s.
+func (s Segment) onSegment(p Vec) bool {
+	d := s.Vec()
+	l2 := d.Dot(d)
+	if l2 == 0 {
+		return p == s.A
+	}
+	ap := p.Sub(s.A)
+	if math.Abs(d.Cross(ap)) > parallelEps*math.Sqrt(l2*ap.Dot(ap)) {
+		return false
+	}
+	t := ap.Dot(d) / l2
+	return t >= 0 && t <= 1
+}
+
+// ClipRect clips s against the rectangle b, using the Liang-Barsky algorithm.
+//
+// It returns the part of s that is inside b, the boundaries of b included, and
+// a boolean indicating if such part exists. The clipped segment has the same
+// orientation as s.
+func (s Segment) ClipRect(b Rectangle) (Segment, bool) {
+	d := s.Vec()
+	t0, t1 := 0.0, 1.0
+
+	// each (p, q) pair represents one of the 4 boundaries of b
+	clip := func(p, q float64) bool {
+		if p == 0 {
+			// segment is parallel to this boundary
+			return q >= 0
+		}
+		t := q / p
+		if p < 0 {
+			if t > t1 {
+				return false
+			}
+			if t > t0 {
+				t0 = t
+			}
+		} else {
+			if t < t0 {
+				return false
+			}
+			if t < t1 {
+				t1 = t
+			}
+		}
+		return true
+	}
+
+	if !clip(-d.X, s.A.X-b.Min.X) ||
+		!clip(d.X, b.Max.X-s.A.X) ||
+		!clip(-d.Y, s.A.Y-b.Min.Y) ||
+		!clip(d.Y, b.Max.Y-s.A.Y) {
+		return Segment{}, false
+	}
+	return Segment{s.At(t0), s.At(t1)}, true
+}
+
+// IntersectRect reports whether s has at least one point inside b or on its
+// boundaries.
+func (s Segment) IntersectRect(b Rectangle) bool {
+	_, ok := s.ClipRect(b)
+	return ok
+}
+
+// String returns a string representation of s like "[(1,2),(3,4)]".
+func (s Segment) String() string {
+	return fmt.Sprintf("[%v,%v]", s.A, s.B)
+}
@@ -0,0 +1,124 @@
+package d2
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSegmentClosestPoint(t *testing.T) {
+	var tests = []struct {
+		s    Segment
+		p    Vec
+		want Vec
+		dist float64
+	}{
+		{Seg(0, 0, 10, 0), Vec{5, 5}, Vec{5, 0}, 5},
+		{Seg(0, 0, 10, 0), Vec{-3, 4}, Vec{0, 0}, 5},
+		{Seg(0, 0, 10, 0), Vec{13, -4}, Vec{10, 0}, 5},
+		{Seg(0, 0, 10, 10), Vec{10, 0}, Vec{5, 5}, 5 * math.Sqrt2},
+		{Seg(1, 1, 1, 1), Vec{1, 3}, Vec{1, 1}, 2},
+	}
+
+	for _, tt := range tests {
+		got := tt.s.ClosestPoint(tt.p)
+		if !got.Approx(tt.want) {
+			t.Errorf("%v.ClosestPoint(%v) = %v, want %v", tt.s, tt.p, got, tt.want)
+		}
+		if d := tt.s.Dist(tt.p); !approx(d, tt.dist) {
+			t.Errorf("%v.Dist(%v) = %v, want %v", tt.s, tt.p, d, tt.dist)
+		}
+	}
+}
+
+func TestSegmentLenMid(t *testing.T) {
+	s := Seg(1, 1, 4, 5)
+	if got := s.Len(); got != 5 {
+		t.Errorf("%v.Len() = %v, want 5", s, got)
+	}
+	if got := s.Mid(); got != (Vec{2.5, 3}) {
+		t.Errorf("%v.Mid() = %v, want (2.5,3)", s, got)
+	}
+}
+
+func TestSegmentIntersect(t *testing.T) {
+	var tests = []struct {
+		s1, s2 Segment
+		kind   IntersectionKind
+		want   Segment
+	}{
+		// crossing
+		{Seg(0, 0, 10, 10), Seg(0, 10, 10, 0), PointIntersection, Seg(5, 5, 5, 5)},
+		// touching at an end point
+		{Seg(0, 0, 10, 0), Seg(10, 0, 10, 10), PointIntersection, Seg(10, 0, 10, 0)},
+		// T junction
+		{Seg(0, 0, 10, 0), Seg(5, 0, 5, 10), PointIntersection, Seg(5, 0, 5, 0)},
+		// not reaching
+		{Seg(0, 0, 10, 0), Seg(5, 1, 5, 10), NoIntersection, Segment{}},
+		// parallel
+		{Seg(0, 0, 10, 0), Seg(0, 1, 10, 1), NoIntersection, Segment{}},
+		// collinear disjoint
+		{Seg(0, 0, 10, 0), Seg(11, 0, 20, 0), NoIntersection, Segment{}},
+		// collinear touching
+		{Seg(0, 0, 10, 0), Seg(10, 0, 20, 0), PointIntersection, Seg(10, 0, 10, 0)},
+		// collinear overlapping
+		{Seg(0, 0, 10, 0), Seg(5, 0, 20, 0), OverlapIntersection, Seg(5, 0, 10, 0)},
+		// collinear overlapping, opposite directions
+		{Seg(0, 0, 10, 10), Seg(8, 8, -2, -2), OverlapIntersection, Seg(0, 0, 8, 8)},
+		// collinear, s2 contained in s1
+		{Seg(0, 0, 0, 10), Seg(0, 2, 0, 3), OverlapIntersection, Seg(0, 2, 0, 3)},
+		// degenerate segments
+		{Seg(0, 0, 10, 0), Seg(3, 0, 3, 0), PointIntersection, Seg(3, 0, 3, 0)},
+		{Seg(3, 1, 3, 1), Seg(0, 0, 10, 0), NoIntersection, Segment{}},
+		{Seg(3, 1, 3, 1), Seg(3, 1, 3, 1), PointIntersection, Seg(3, 1, 3, 1)},
+	}
+
+	for _, tt := range tests {
+		kind, got := tt.s1.Intersect(tt.s2)
+		if kind != tt.kind {
+			t.Errorf("%v.Intersect(%v) kind = %v, want %v", tt.s1, tt.s2, kind, tt.kind)
+			continue
+		}
+		if !got.A.Approx(tt.want.A) || !got.B.Approx(tt.want.B) {
+			t.Errorf("%v.Intersect(%v) = %v, want %v", tt.s1, tt.s2, got, tt.want)
+		}
+		if tt.s2.Intersects(tt.s1) != (tt.kind != NoIntersection) {
+			t.Errorf("%v.Intersects(%v) should be symmetric", tt.s2, tt.s1)
+		}
+	}
+}
+
+func TestSegmentClipRect(t *testing.T) {
+	b := Rect(0, 0, 10, 10)
+	var tests = []struct {
+		s    Segment
+		want Segment
+		ok   bool
+	}{
+		{Seg(-5, 5, 15, 5), Seg(0, 5, 10, 5), true},
+		{Seg(15, 5, -5, 5), Seg(10, 5, 0, 5), true},
+		{Seg(2, 2, 8, 8), Seg(2, 2, 8, 8), true},
+		{Seg(-5, -5, 15, 15), Seg(0, 0, 10, 10), true},
+		{Seg(5, -5, 5, 5), Seg(5, 0, 5, 5), true},
+		{Seg(-5, 11, 15, 11), Segment{}, false},
+		{Seg(-5, 5, 5, -5), Seg(0, 0, 0, 0), true},
+		{Seg(-5, 1, 1, -5), Segment{}, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := tt.s.ClipRect(b)
+		if ok != tt.ok {
+			t.Errorf("%v.ClipRect(%v) ok = %v, want %v", tt.s, b, ok, tt.ok)
+			continue
+		}
+		if ok && (!got.A.Approx(tt.want.A) || !got.B.Approx(tt.want.B)) {
+			t.Errorf("%v.ClipRect(%v) = %v, want %v", tt.s, b, got, tt.want)
+		}
+		if tt.s.IntersectRect(b) != tt.ok {
+			t.Errorf("%v.IntersectRect(%v) = %v, want %v", tt.s, b, !tt.ok, tt.ok)
+		}
+	}
+}
+
+func approx(a, b float64) bool {
+	return math.Abs(a-b) < 1e-9*(1+math.Max(math.Abs(a), math.Abs(b)))
+}
@@ -0,0 +1,355 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"container/heap"
+	"math"
+)
+
+// SimplifyDP returns a simplified copy of p, computed with the
+// Douglas-Peucker algorithm: the removed points are at most at a distance of
+// tolerance from the simplified path. The first and last points are always
+// kept.
+func (p Path) SimplifyDP(tolerance float64) Path {
+	if len(p) <= 2 {
+		return p.clone()
+	}
+	keep := make([]bool, len(p))
+	keep[0], keep[len(p)-1] = true, true
+
+	stack := [][2]int{{0, len(p) - 1}}
+	for len(stack) > 0 {
+		r := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		k, d := p.farthest(r[0], r[1])
+		if k == -1 || d <= tolerance {
+			continue
+		}
+		keep[k] = true
+		stack = append(stack, [2]int{r[0], k}, [2]int{k, r[1]})
+	}
+	return p.filter(keep)
+}
+
+// SimplifyDPCount returns a simplified copy of p having n points, or less if
+// p has less points, computed with the Douglas-Peucker algorithm: the point
+// that is the farthest from the simplified path is repeatedly added to it,
+// starting from the first and last points of p.
+func (p Path) SimplifyDPCount(n int) Path {
+	if n >= len(p) || len(p) <= 2 {
+		return p.clone()
+	}
+	keep := make([]bool, len(p))
+	keep[0], keep[len(p)-1] = true, true
+
+	var q dpQueue
+	q.push(p, 0, len(p)-1)
+	for count := 2; count < n && len(q) > 0; count++ {
+		r := heap.Pop(&q).(*dpRange)
+		keep[r.k] = true
+		q.push(p, r.i, r.k)
+		q.push(p, r.k, r.j)
+	}
+	return p.filter(keep)
+}
+
+// farthest returns the index of the point of p, between i and j excluded,
+// that is the farthest from the segment joining p[i] and p[j], and its
+// distance. It returns -1 if there are no points between i and j.
+func (p Path) farthest(i, j int) (int, float64) {
+	s := Segment{p[i], p[j]}
+	k, dmax := -1, -1.0
+	for m := i + 1; m < j; m++ {
+		if d := s.Dist(p[m]); d > dmax {
+			k, dmax = m, d
+		}
+	}
+	return k, dmax
+}
+
+// filter returns the points of p for which keep is true.
+func (p Path) filter(keep []bool) Path {
+	var res Path
+	for i, v := range p {
+		if keep[i] {
+			res = append(res, v)
+		}
+	}
+	return res
+}
+
+// clone returns a copy of p.
+func (p Path) clone() Path {
+	if p == nil {
+		return nil
+	}
+	return append(Path{}, p...)
+}
+
+// A dpRange is a range of points of a path, between i and j, to which the
+// point k, at distance d, is the next to add.
+type dpRange struct {
+	i, j, k int
+	d       float64
+}
+
+// dpQueue is a max-priority queue of ranges, by distance, implementing
+// heap.Interface.
+type dpQueue []*dpRange
+
+func (q dpQueue) Len() int            { return len(q) }
+func (q dpQueue) Less(i, j int) bool  { return q[i].d > q[j].d }
+func (q dpQueue) Swap(i, j int)       { q[i], q[j] = q[j], q[i] }
+func (q *dpQueue) Push(x interface{}) { *q = append(*q, x.(*dpRange)) }
+
+func (q *dpQueue) Pop() interface{} {
+	old := *q
+	r := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return r
+}
+
+// push pushes the range of p between i and j, if it contains points.
+func (q *dpQueue) push(p Path, i, j int) {
+	if k, d := p.farthest(i, j); k != -1 {
+		heap.Push(q, &dpRange{i: i, j: j, k: k, d: d})
+	}
+}
+
+// SimplifyVW returns a simplified copy of p, computed with the
+// Visvalingam-Whyatt algorithm: the point forming the triangle of smallest
+// area with its neighbours is repeatedly removed, as long as this area is
+// less than area. The first and last points are always kept.
+func (p Path) SimplifyVW(area float64) Path {
+	return p.simplifyVW(area, 2, false)
+}
+
+// SimplifyVWCount returns a simplified copy of p having n points, or less if
+// p has less points, computed with the Visvalingam-Whyatt algorithm.
+func (p Path) SimplifyVWCount(n int) Path {
+	return p.simplifyVW(math.Inf(1), n, false)
+}
+
+// SimplifyVWTopology is like SimplifyVW but preserves the topology of p: a
+// point is not removed if that makes the simplified path self-intersect. So
+// if p does not self-intersect, neither does the returned path.
+func (p Path) SimplifyVWTopology(area float64) Path {
+	return p.simplifyVW(area, 2, true)
+}
+
+// SimplifyVWTopologyCount is like SimplifyVWCount but preserves the topology
+// of p, as SimplifyVWTopology does. The returned path may then have more than
+// n points.
+func (p Path) SimplifyVWTopologyCount(n int) Path {
+	return p.simplifyVW(math.Inf(1), n, true)
+}
+
+// simplifyVW removes the points of p by increasing effective area, while
+// it's less than area and there are more than n points. If topo is true, the
+// points whose removal would make the path self-intersect are kept.
+func (p Path) simplifyVW(area float64, n int, topo bool) Path {
+	if n >= len(p) || len(p) <= 2 {
+		return p.clone()
+	}
+
+	nodes := make([]vwNode, len(p))
+	var q vwQueue
+	for i := range nodes {
+		nodes[i] = vwNode{i: i, prev: i - 1, next: i + 1, index: -1}
+		if i > 0 && i < len(p)-1 {
+			nodes[i].area = triangleArea(p[i-1], p[i], p[i+1])
+			heap.Push(&q, &nodes[i])
+		}
+	}
+	var g *segGrid
+	if topo {
+		g = newSegGrid(p)
+	}
+
+	keep := make([]bool, len(p))
+	for i := range keep {
+		keep[i] = true
+	}
+	for count := len(p); count > n && len(q) > 0; {
+		nd := heap.Pop(&q).(*vwNode)
+		if nd.area >= area {
+			break
+		}
+		if g != nil && g.crosses(p, nodes, nd.prev, nd.next) {
+			// kept until one of its neighbours gets removed
+			continue
+		}
+
+		keep[nd.i] = false
+		count--
+		prev, next := &nodes[nd.prev], &nodes[nd.next]
+		prev.next, next.prev = nd.next, nd.prev
+		if g != nil {
+			g.remove(p, nd.prev, nd.i)
+			g.remove(p, nd.i, nd.next)
+			g.insert(p, nd.prev, nd.next)
+		}
+
+		// the effective area of the neighbours can't be less than the one of
+		// the removed point, so that points are removed in order.
+		for _, nb := range [2]*vwNode{prev, next} {
+			if nb.prev == -1 || nb.next == len(p) {
+				continue
+			}
+			nb.area = math.Max(nd.area, triangleArea(p[nb.prev], p[nb.i], p[nb.next]))
+			if nb.index == -1 {
+				heap.Push(&q, nb)
+			} else {
+				heap.Fix(&q, nb.index)
+			}
+		}
+	}
+	return p.filter(keep)
+}
+
+// triangleArea returns the area of the triangle a, b, c.
+func triangleArea(a, b, c Vec) float64 {
+	return math.Abs(b.Sub(a).Cross(c.Sub(a))) / 2
+}
+
+// A vwNode is a point of a path being simplified with the
+// Visvalingam-Whyatt algorithm.
+type vwNode struct {
+	i          int     // index of the point in the path
+	prev, next int     // neighbours in the simplified path
+	area       float64 // effective area
+	index      int     // index in the queue, -1 if not queued
+}
+
+// vwQueue is a min-priority queue of nodes, by effective area, implementing
+// heap.Interface.
+type vwQueue []*vwNode
+
+func (q vwQueue) Len() int           { return len(q) }
+func (q vwQueue) Less(i, j int) bool { return q[i].area < q[j].area }
+
+func (q vwQueue) Swap(i, j int) {
+	q[i], q[j] = q[j], q[i]
+	q[i].index, q[j].index = i, j
+}
+
+func (q *vwQueue) Push(x interface{}) {
+	nd := x.(*vwNode)
+	nd.index = len(*q)
+	*q = append(*q, nd)
+}
+
+func (q *vwQueue) Pop() interface{} {
+	old := *q
+	nd := old[len(old)-1]
+	nd.index = -1
+	*q = old[:len(old)-1]
+	return nd
+}
+
+// segGrid is a uniform grid indexing the segments of a path being
+// simplified, so as to quickly find the segments close to another one. A
+// segment is identified by the index of its first point.
+type segGrid struct {
+	origin Vec
+	size   float64
+	cells  map[[2]int][]int
+	stamps []int // last query having visited each segment
+	stamp  int
+}
+
+// newSegGrid returns a grid indexing the segments of p.
+func newSegGrid(p Path) *segGrid {
+	b := p.Rectangle()
+	size := math.Max(b.Dx(), b.Dy()) / math.Sqrt(float64(len(p)))
+	if size == 0 {
+		size = 1
+	}
+	g := &segGrid{
+		origin: b.Min,
+		size:   size,
+		cells:  make(map[[2]int][]int),
+		stamps: make([]int, len(p)),
+	}
+	for i := 0; i < len(p)-1; i++ {
+		g.insert(p, i, i+1)
+	}
+	return g
+}
+
+// span returns the range of cells overlapped by the bounding box of the
+// segment a, b.
+func (g *segGrid) span(a, b Vec) (x0, y0, x1, y1 int) {
+	r := Rect(a.X, a.Y, b.X, b.Y)
+	x0 = int(math.Floor((r.Min.X - g.origin.X) / g.size))
+	y0 = int(math.Floor((r.Min.Y - g.origin.Y) / g.size))
+	x1 = int(math.Floor((r.Max.X - g.origin.X) / g.size))
+	y1 = int(math.Floor((r.Max.Y - g.origin.Y) / g.size))
+	return
+}
+
+// insert adds the segment going from p[i] to p[j].
+func (g *segGrid) insert(p Path, i, j int) {
+	x0, y0, x1, y1 := g.span(p[i], p[j])
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			k := [2]int{x, y}
+			g.cells[k] = append(g.cells[k], i)
+		}
+	}
+}
+
+// remove removes the segment going from p[i] to p[j].
+func (g *segGrid) remove(p Path, i, j int) {
+	x0, y0, x1, y1 := g.span(p[i], p[j])
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			k := [2]int{x, y}
+			segs := g.cells[k]
+			for m, s := range segs {
+				if s == i {
+					segs[m] = segs[len(segs)-1]
+					segs = segs[:len(segs)-1]
+					break
+				}
+			}
+			if len(segs) == 0 {
+				delete(g.cells, k)
+			} else {
+				g.cells[k] = segs
+			}
+		}
+	}
+}
+
+// crosses reports whether the segment going from p[i] to p[j], replacing the
+// segments between them, would intersect any other segment of the path,
+// other than by sharing an end point.
+func (g *segGrid) crosses(p Path, nodes []vwNode, i, j int) bool {
+	s := Segment{p[i], p[j]}
+	g.stamp++
+	x0, y0, x1, y1 := g.span(p[i], p[j])
+	for x := x0; x <= x1; x++ {
+		for y := y0; y <= y1; y++ {
+			for _, k := range g.cells[[2]int{x, y}] {
+				if g.stamps[k] == g.stamp || k >= i && k < j {
+					continue
+				}
+				g.stamps[k] = g.stamp
+				s2 := Segment{p[k], p[nodes[k].next]}
+				switch kind, _ := s.Intersect(s2); kind {
+				case OverlapIntersection:
+					return true
+				case PointIntersection:
+					if s.A != s2.A && s.A != s2.B && s.B != s2.A && s.B != s2.B {
+						return true
+					}
+				}
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package d2
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+// selfIntersects reports whether 2 non-consecutive segments of p intersect.
+func selfIntersects(p Path) bool {
+	for i := 0; i < len(p)-1; i++ {
+		for j := i + 2; j < len(p)-1; j++ {
+			if Seg(p[i].X, p[i].Y, p[i+1].X, p[i+1].Y).Intersects(Seg(p[j].X, p[j].Y, p[j+1].X, p[j+1].Y)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// distToPath returns the distance from v to the closest segment of p.
+func distToPath(p Path, v Vec) float64 {
+	d := math.Inf(1)
+	for i := 0; i < len(p)-1; i++ {
+		d = math.Min(d, Segment{p[i], p[i+1]}.Dist(v))
+	}
+	return d
+}
+
+// spiral returns a noisy spiral having n points, that does not self-intersect.
+func spiral(rng *rand.Rand, n int) Path {
+	p := make(Path, n)
+	for i := range p {
+		a := float64(i) * 0.05
+		r := 1 + a + rng.Float64()*0.5
+		p[i] = Vec{r * math.Cos(a), r * math.Sin(a)}
+	}
+	return p
+}
+
+func TestSimplifyDP(t *testing.T) {
+	p := Path{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 0}, {3, 1}, {3.2, 2}, {3, 3}}
+	var tests = []struct {
+		tol  float64
+		want Path
+	}{
+		{0, p},
+		{0.15, Path{{0, 0}, {3, 0}, {3.2, 2}, {3, 3}}},
+		{0.5, Path{{0, 0}, {3, 0}, {3, 3}}},
+		{10, Path{{0, 0}, {3, 3}}},
+	}
+	for _, tt := range tests {
+		if got := p.SimplifyDP(tt.tol); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SimplifyDP(%v) = %v, want %v", tt.tol, got, tt.want)
+		}
+	}
+
+	var counts = []struct {
+		n    int
+		want Path
+	}{
+		{0, Path{{0, 0}, {3, 3}}},
+		{3, Path{{0, 0}, {3, 0}, {3, 3}}},
+		{4, Path{{0, 0}, {3, 0}, {3.2, 2}, {3, 3}}},
+		{10, p},
+	}
+	for _, tt := range counts {
+		if got := p.SimplifyDPCount(tt.n); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SimplifyDPCount(%v) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+
+	for _, p := range []Path{nil, {{1, 1}}, {{1, 1}, {2, 2}}} {
+		if got := p.SimplifyDP(1); !reflect.DeepEqual(got, p) {
+			t.Errorf("SimplifyDP(1) of %v = %v", p, got)
+		}
+	}
+}
+
+func TestSimplifyDPRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	p := spiral(rng, 1000)
+	for _, tol := range []float64{0.1, 0.5, 2} {
+		s := p.SimplifyDP(tol)
+		if s[0] != p[0] || s[len(s)-1] != p[len(p)-1] {
+			t.Errorf("SimplifyDP(%v) doesn't keep the end points", tol)
+		}
+		for _, v := range p {
+			if d := distToPath(s, v); d > tol+1e-9 {
+				t.Fatalf("SimplifyDP(%v): %v is at %v from the simplified path", tol, v, d)
+			}
+		}
+	}
+	for _, n := range []int{2, 10, 100} {
+		if s := p.SimplifyDPCount(n); len(s) != n {
+			t.Errorf("SimplifyDPCount(%v) has %d points", n, len(s))
+		}
+	}
+}
+
+func TestSimplifyVW(t *testing.T) {
+	p := Path{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 0}, {3, 1}, {3.2, 2}, {3, 3}}
+	var tests = []struct {
+		area float64
+		want Path
+	}{
+		{0, p},
+		{0.12, Path{{0, 0}, {1, 0.1}, {2, -0.1}, {3, 0}, {3.2, 2}, {3, 3}}},
+		{1, Path{{0, 0}, {3, 0}, {3, 3}}},
+		{10, Path{{0, 0}, {3, 3}}},
+	}
+	for _, tt := range tests {
+		if got := p.SimplifyVW(tt.area); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("SimplifyVW(%v) = %v, want %v", tt.area, got, tt.want)
+		}
+	}
+	for n := 0; n <= len(p)+1; n++ {
+		want := n
+		if n < 2 {
+			want = 2
+		} else if n > len(p) {
+			want = len(p)
+		}
+		if got := p.SimplifyVWCount(n); len(got) != want {
+			t.Errorf("SimplifyVWCount(%v) = %v, want %d points", n, got, want)
+		}
+	}
+}
+
+func TestSimplifyVWTopology(t *testing.T) {
+	// removing the point at (5, 2), which has the smallest area, makes the
+	// first segment cross the last one.
+	p := Path{{0, 0}, {5, 2}, {10, 0}, {10, -5}, {5, -5}, {5, 1}}
+	if got := p.SimplifyVW(11); !selfIntersects(got) {
+		t.Fatalf("SimplifyVW(11) = %v, want a self-intersecting path", got)
+	}
+	if got := p.SimplifyVWTopology(11); !reflect.DeepEqual(got, p) {
+		t.Errorf("SimplifyVWTopology(11) = %v, want %v", got, p)
+	}
+	if got := p.SimplifyVWTopologyCount(2); selfIntersects(got) {
+		t.Errorf("SimplifyVWTopologyCount(2) = %v self-intersects", got)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	p = spiral(rng, 2000)
+	if selfIntersects(p) {
+		t.Fatal("input path self-intersects")
+	}
+	for _, n := range []int{2, 10, 50, 200} {
+		got := p.SimplifyVWTopologyCount(n)
+		if selfIntersects(got) {
+			t.Errorf("SimplifyVWTopologyCount(%v) self-intersects", n)
+		}
+		if len(got) < n {
+			t.Errorf("SimplifyVWTopologyCount(%v) has %d points", n, len(got))
+		}
+	}
+	for _, area := range []float64{0.1, 1, 10} {
+		if got := p.SimplifyVWTopology(area); selfIntersects(got) {
+			t.Errorf("SimplifyVWTopology(%v) self-intersects", area)
+		}
+	}
+}
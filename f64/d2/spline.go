@@ -0,0 +1,232 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"math"
+	"sort"
+
+	"github.com/arl/gogeo/f64"
+)
+
+// Parametrizations of Catmull-Rom splines, to be used as the alpha argument
+// of NewCatmullRom.
+const (
+	// CatmullRomUniform is the uniform parametrization, that may produce
+	// cusps and self-intersections where waypoints are unevenly spaced.
+	CatmullRomUniform = 0
+
+	// CatmullRomCentripetal is the centripetal parametrization, that never
+	// produces cusps nor self-intersections inside a curve segment.
+	CatmullRomCentripetal = 0.5
+
+	// CatmullRomChordal is the chordal parametrization.
+	CatmullRomChordal = 1
+)
+
+// A Spline is a smooth curve made of consecutive cubic Bézier curves, passing
+// through, or near, a sequence of waypoints.
+//
+// The points of a spline built from n waypoints are parametrized by t in
+// [0, n-1], the i-th curve, going from the waypoint i to the waypoint i+1 in
+// the case of a Catmull-Rom spline, being parametrized by t in [i, i+1]. A
+// spline built from a single waypoint is made of a single curve reduced to
+// that point.
+type Spline struct {
+	curves []CubicBezier
+	dist   []float64 // dist[i] is the arc length of the curves before the i-th one
+}
+
+// NewCatmullRom returns the Catmull-Rom spline interpolating the points of p:
+// it passes through all of them. Consecutive duplicate points are ignored.
+//
+// alpha defines the parametrization of the spline, usually
+// CatmullRomUniform, CatmullRomCentripetal or CatmullRomChordal. The tangents
+// at the end points are computed as if p was extended by the reflections of
+// its second and penultimate points.
+func NewCatmullRom(p Path, alpha float64) *Spline {
+	p = extend(dedup(p))
+	var curves []CubicBezier
+	for i := 1; i < len(p)-2; i++ {
+		p0, p1, p2, p3 := p[i-1], p[i], p[i+1], p[i+2]
+		d0 := math.Pow(p1.Dist(p0), alpha)
+		d1 := math.Pow(p2.Dist(p1), alpha)
+		d2 := math.Pow(p3.Dist(p2), alpha)
+
+		// tangents at p1 and p2 of the non-uniform Catmull-Rom curve, scaled
+		// to a parametrization over [0, 1].
+		m1 := p1.Sub(p0).Div(d0).Sub(p2.Sub(p0).Div(d0 + d1)).Add(p2.Sub(p1).Div(d1)).Mul(d1)
+		m2 := p2.Sub(p1).Div(d1).Sub(p3.Sub(p1).Div(d1 + d2)).Add(p3.Sub(p2).Div(d2)).Mul(d1)
+		curves = append(curves, CubicBezier{p1, p1.Add(m1.Div(3)), p2.Sub(m2.Div(3)), p2})
+	}
+	return newSpline(curves, p)
+}
+
+// NewBSpline returns the uniform cubic B-spline having the points of p as
+// control points. It approximates p: it starts at the first point of p and
+// ends at the last one, but doesn't pass through the other ones. Consecutive
+// duplicate points are ignored.
+//
+// The end points are reached as if p was extended by the reflections of its
+// second and penultimate points.
+func NewBSpline(p Path) *Spline {
+	p = extend(dedup(p))
+	var curves []CubicBezier
+	for i := 1; i < len(p)-2; i++ {
+		p0, p1, p2, p3 := p[i-1], p[i], p[i+1], p[i+2]
+		curves = append(curves, CubicBezier{
+			p0.Add(p1.Mul(4)).Add(p2).Div(6),
+			p1.Mul(2).Add(p2).Div(3),
+			p1.Add(p2.Mul(2)).Div(3),
+			p1.Add(p2.Mul(4)).Add(p3).Div(6),
+		})
+	}
+	return newSpline(curves, p)
+}
+
+// extend returns p with the reflection of its second point, with respect to
+// the first one, prepended, and the reflection of its penultimate point,
+// with respect to the last one, appended.
+func extend(p Path) Path {
+	n := len(p)
+	if n < 2 {
+		return p
+	}
+	ext := make(Path, 0, n+2)
+	ext = append(ext, p[0].Mul(2).Sub(p[1]))
+	ext = append(ext, p...)
+	return append(ext, p[n-1].Mul(2).Sub(p[n-2]))
+}
+
+// newSpline returns the spline made of curves, that were built from the
+// extended path p. If p has a single point, the spline is that point.
+func newSpline(curves []CubicBezier, p Path) *Spline {
+	if len(p) == 1 {
+		curves = []CubicBezier{{p[0], p[0], p[0], p[0]}}
+	}
+	s := &Spline{curves: curves, dist: make([]float64, len(curves)+1)}
+	for i, c := range curves {
+		s.dist[i+1] = s.dist[i] + c.Length()
+	}
+	return s
+}
+
+// Curves returns the cubic Bézier curves making s.
+func (s *Spline) Curves() []CubicBezier {
+	return s.curves
+}
+
+// Max returns the maximum parameter of the points of s, which is the number
+// of curves making it.
+func (s *Spline) Max() float64 {
+	return float64(len(s.curves))
+}
+
+// locate returns the curve containing the point at parameter t, and the
+// parameter of that point along it. s must have at least one curve.
+func (s *Spline) locate(t float64) (CubicBezier, float64) {
+	t = f64.Clamp(t, 0, s.Max())
+	i := int(math.Min(math.Floor(t), s.Max()-1))
+	return s.curves[i], t - float64(i)
+}
+
+// At returns the point of s at parameter t, which is clamped to
+// [0, s.Max()]. It returns the zero Vec if s is empty.
+func (s *Spline) At(t float64) Vec {
+	if len(s.curves) == 0 {
+		return ZV
+	}
+	c, u := s.locate(t)
+	return c.At(u)
+}
+
+// Tangent returns the derivative of s at parameter t, which is clamped to
+// [0, s.Max()]. It returns the zero Vec if s is empty.
+func (s *Spline) Tangent(t float64) Vec {
+	if len(s.curves) == 0 {
+		return ZV
+	}
+	c, u := s.locate(t)
+	return c.Derivative(u)
+}
+
+// Length returns the arc length of s.
+func (s *Spline) Length() float64 {
+	return s.dist[len(s.dist)-1]
+}
+
+// LengthAt returns the arc length of s between its start and the point at
+// parameter t, which is clamped to [0, s.Max()].
+func (s *Spline) LengthAt(t float64) float64 {
+	if len(s.curves) == 0 {
+		return 0
+	}
+	t = f64.Clamp(t, 0, s.Max())
+	i := int(math.Min(math.Floor(t), s.Max()-1))
+	c, _ := s.curves[i].Split(t - float64(i))
+	return s.dist[i] + c.Length()
+}
+
+// ParamAt returns the parameter of the point located at arc length d from the
+// start of s, d being clamped to [0, s.Length()]. This is the inverse of
+// LengthAt, used to move along s at constant speed.
+func (s *Spline) ParamAt(d float64) float64 {
+	if len(s.curves) == 0 {
+		return 0
+	}
+	d = f64.Clamp(d, 0, s.Length())
+	// last curve starting before d
+	i := sort.Search(len(s.curves), func(i int) bool { return s.dist[i+1] >= d })
+	if i == len(s.curves) {
+		i--
+	}
+	c, target := s.curves[i], d-s.dist[i]
+	l := s.dist[i+1] - s.dist[i]
+	if l == 0 {
+		return float64(i)
+	}
+
+	// solve length(u) = target by Newton iterations, falling back to
+	// bisection if they leave the bracket.
+	lo, hi := 0.0, 1.0
+	u := target / l
+	for it := 0; it < 50; it++ {
+		c1, _ := c.Split(u)
+		f := c1.Length() - target
+		if math.Abs(f) <= bezierEps*l {
+			break
+		}
+		if f < 0 {
+			lo = u
+		} else {
+			hi = u
+		}
+		u -= f / c.Derivative(u).Len()
+		if !(u > lo && u < hi) {
+			u = (lo + hi) / 2
+		}
+	}
+	return float64(i) + u
+}
+
+// AtLength returns the point located at arc length d from the start of s, d
+// being clamped to [0, s.Length()].
+func (s *Spline) AtLength(d float64) Vec {
+	return s.At(s.ParamAt(d))
+}
+
+// Flatten returns the polyline approximating s, so that no point of s is
+// further than tolerance from it. See CubicBezier.Flatten.
+func (s *Spline) Flatten(tolerance float64) Path {
+	var p Path
+	for i, c := range s.curves {
+		f := c.Flatten(tolerance)
+		if i > 0 {
+			f = f[1:]
+		}
+		p = append(p, f...)
+	}
+	return p
+}
@@ -0,0 +1,183 @@
+package d2
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// waypoints returns n random points in [0,10]x[0,10].
+func waypoints(rng *rand.Rand, n int) Path {
+	p := make(Path, n)
+	for i := range p {
+		p[i] = Vec{rng.Float64() * 10, rng.Float64() * 10}
+	}
+	return p
+}
+
+func TestCatmullRom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	p := waypoints(rng, 8)
+	for _, alpha := range []float64{CatmullRomUniform, CatmullRomCentripetal, CatmullRomChordal} {
+		s := NewCatmullRom(p, alpha)
+		if s.Max() != float64(len(p)-1) {
+			t.Fatalf("alpha=%v: Max() = %v, want %v", alpha, s.Max(), len(p)-1)
+		}
+		// passes through the waypoints, with continuous tangent directions
+		for i, v := range p {
+			if got := s.At(float64(i)); !got.ApproxEpsilon(v, 1e-12) {
+				t.Errorf("alpha=%v: At(%d) = %v, want %v", alpha, i, got, v)
+			}
+			if i == 0 || i == len(p)-1 {
+				continue
+			}
+			in := s.curves[i-1].Derivative(1).Normalize()
+			out := s.Tangent(float64(i)).Normalize()
+			if !in.ApproxEpsilon(out, 1e-9) {
+				t.Errorf("alpha=%v: tangent at waypoint %d changes from %v to %v", alpha, i, in, out)
+			}
+		}
+	}
+
+	// uniform tangents at the waypoints
+	s := NewCatmullRom(p, CatmullRomUniform)
+	for i := 1; i < len(p)-1; i++ {
+		want := p[i+1].Sub(p[i-1]).Div(2)
+		if got := s.Tangent(float64(i)); !got.ApproxEpsilon(want, 1e-12) {
+			t.Errorf("Tangent(%d) = %v, want %v", i, got, want)
+		}
+	}
+
+	// evenly spaced collinear points give a straight line, whatever alpha
+	line := Path{{0, 0}, {1, 0}, {1, 0}, {2, 0}, {3, 0}}
+	for _, alpha := range []float64{CatmullRomUniform, CatmullRomCentripetal} {
+		s := NewCatmullRom(line, alpha)
+		for _, tt := range []float64{-1, 0, 0.25, 1.5, 2.75, 3, 4} {
+			want := Vec{math.Max(0, math.Min(3, tt)), 0}
+			if got := s.At(tt); !got.ApproxEpsilon(want, 1e-12) {
+				t.Errorf("alpha=%v: At(%v) of a line = %v, want %v", alpha, tt, got, want)
+			}
+		}
+	}
+}
+
+func TestCatmullRomCentripetal(t *testing.T) {
+	// a sharp turn with unevenly spaced waypoints: the uniform spline makes a
+	// loop where the centripetal one doesn't.
+	p := Path{{0, 0}, {10, 0}, {11, 0}, {11, 10}}
+	if !selfIntersects(NewCatmullRom(p, CatmullRomUniform).Flatten(1e-3)) {
+		t.Errorf("uniform Catmull-Rom spline should self-intersect")
+	}
+	if selfIntersects(NewCatmullRom(p, CatmullRomCentripetal).Flatten(1e-3)) {
+		t.Errorf("centripetal Catmull-Rom spline self-intersects")
+	}
+}
+
+func TestBSpline(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	p := waypoints(rng, 8)
+	s := NewBSpline(p)
+	if got := s.At(0); !got.ApproxEpsilon(p[0], 1e-12) {
+		t.Errorf("At(0) = %v, want %v", got, p[0])
+	}
+	if got := s.At(s.Max()); !got.ApproxEpsilon(p[len(p)-1], 1e-12) {
+		t.Errorf("At(%v) = %v, want %v", s.Max(), got, p[len(p)-1])
+	}
+	// C2 continuity, and the interior points are at (p[i-1]+4p[i]+p[i+1])/6
+	for i := 1; i < len(p)-1; i++ {
+		prev, next := s.curves[i-1], s.curves[i]
+		if a, b := prev.Derivative(1), next.Derivative(0); !a.ApproxEpsilon(b, 1e-9) {
+			t.Errorf("derivative at %d changes from %v to %v", i, a, b)
+		}
+		if a, b := prev.SecondDerivative(1), next.SecondDerivative(0); !a.ApproxEpsilon(b, 1e-9) {
+			t.Errorf("second derivative at %d changes from %v to %v", i, a, b)
+		}
+		want := p[i-1].Add(p[i].Mul(4)).Add(p[i+1]).Div(6)
+		if got := s.At(float64(i)); !got.ApproxEpsilon(want, 1e-12) {
+			t.Errorf("At(%d) = %v, want %v", i, got, want)
+		}
+	}
+
+	line := NewBSpline(Path{{0, 0}, {1, 0}, {2, 0}, {3, 0}})
+	for _, tt := range []float64{0, 0.5, 1.25, 3} {
+		if got := line.At(tt); !got.ApproxEpsilon(Vec{tt, 0}, 1e-12) {
+			t.Errorf("At(%v) of a line = %v, want (%v,0)", tt, got, tt)
+		}
+	}
+}
+
+func TestSplineDegenerate(t *testing.T) {
+	for _, s := range []*Spline{NewCatmullRom(nil, CatmullRomCentripetal), NewBSpline(nil)} {
+		if s.At(0) != ZV || s.Length() != 0 || s.Flatten(1) != nil {
+			t.Errorf("empty spline: At(0) = %v, Length() = %v", s.At(0), s.Length())
+		}
+	}
+	pt := Path{{1, 2}, {1, 2}}
+	for _, s := range []*Spline{NewCatmullRom(pt, CatmullRomCentripetal), NewBSpline(pt)} {
+		if got := s.At(0.5); got != (Vec{1, 2}) {
+			t.Errorf("point spline: At(0.5) = %v, want (1,2)", got)
+		}
+		if got := s.AtLength(1); got != (Vec{1, 2}) {
+			t.Errorf("point spline: AtLength(1) = %v, want (1,2)", got)
+		}
+		if got := s.Flatten(1); len(got) != 2 || got[0] != pt[0] || got[1] != pt[0] {
+			t.Errorf("point spline: Flatten(1) = %v", got)
+		}
+	}
+}
+
+func TestSplineArcLength(t *testing.T) {
+	line := NewCatmullRom(Path{{0, 0}, {1, 0}, {2, 0}, {3, 0}}, CatmullRomUniform)
+	if got := line.Length(); math.Abs(got-3) > 1e-9 {
+		t.Errorf("Length() of a line = %v, want 3", got)
+	}
+	if got := line.LengthAt(1.5); math.Abs(got-1.5) > 1e-9 {
+		t.Errorf("LengthAt(1.5) of a line = %v, want 1.5", got)
+	}
+
+	rng := rand.New(rand.NewSource(1))
+	p := waypoints(rng, 10)
+	for _, s := range []*Spline{NewCatmullRom(p, CatmullRomCentripetal), NewBSpline(p)} {
+		if got, want := s.Length(), s.Flatten(1e-6).Length(); math.Abs(got-want) > 1e-4 {
+			t.Errorf("Length() = %v, want %v", got, want)
+		}
+		if got := s.LengthAt(s.Max()); math.Abs(got-s.Length()) > 1e-9 {
+			t.Errorf("LengthAt(%v) = %v, want %v", s.Max(), got, s.Length())
+		}
+		for i := 0; i < 20; i++ {
+			d := rng.Float64() * s.Length()
+			tt := s.ParamAt(d)
+			if got := s.LengthAt(tt); math.Abs(got-d) > 1e-6 {
+				t.Errorf("LengthAt(ParamAt(%v)) = %v", d, got)
+			}
+			if got, want := s.AtLength(d), s.At(tt); got != want {
+				t.Errorf("AtLength(%v) = %v, want %v", d, got, want)
+			}
+		}
+		if got := s.ParamAt(-1); got != 0 {
+			t.Errorf("ParamAt(-1) = %v, want 0", got)
+		}
+		if got := s.ParamAt(s.Length() + 1); math.Abs(got-s.Max()) > 1e-9 {
+			t.Errorf("ParamAt(%v) = %v, want %v", s.Length()+1, got, s.Max())
+		}
+	}
+}
+
+func TestSplineFlatten(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	p := waypoints(rng, 10)
+	for _, s := range []*Spline{NewCatmullRom(p, CatmullRomCentripetal), NewBSpline(p)} {
+		for _, tol := range []float64{0.5, 0.01} {
+			f := s.Flatten(tol)
+			if f[0] != s.At(0) || f[len(f)-1] != s.At(s.Max()) {
+				t.Errorf("Flatten(%v) doesn't start and end at the spline end points", tol)
+			}
+			for k := 0; k <= 1000; k++ {
+				v := s.At(s.Max() * float64(k) / 1000)
+				if d := distToPath(f, v); d > tol {
+					t.Fatalf("Flatten(%v): %v is at %v from the polyline", tol, v, d)
+				}
+			}
+		}
+	}
+}
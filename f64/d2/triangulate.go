@@ -0,0 +1,345 @@
+// Copyright 2016 Aurélien Rainone. All rights reserved.
+// Use of this source code is governed by MIT license.
+// license that can be found in the LICENSE file.
+
+package d2
+
+import (
+	"math"
+	"sort"
+)
+
+// Triangulate triangulates the simple polygon outer, that may have holes, by
+// ear clipping. Holes are first bridged into the outer ring.
+//
+// It returns the triangles as triples of indices into the vertices of outer
+// followed by those of holes, in order: the first vertex of holes[0] has index
+// len(outer). Triangles are counter-clockwise (in a Y-up frame), whatever the
+// orientation of the input rings.
+//
+// Duplicate vertices and collinear vertices are tolerated, the latter may not
+// appear in the returned triangles. Triangulate returns nil if outer has a
+// null area. If the rings are not simple or if the holes are not inside outer,
+// the triangulation is not correct but Triangulate still terminates.
+func Triangulate(outer Polygon, holes ...Polygon) [][3]int {
+	start := linkRing(outer, 0, CounterClockwise)
+	if start == nil {
+		return nil
+	}
+
+	var hs []*triNode
+	off := len(outer)
+	for _, h := range holes {
+		if n := linkRing(h, off, Clockwise); n != nil {
+			hs = append(hs, n.leftmost())
+		}
+		off += len(h)
+	}
+	sort.Slice(hs, func(i, j int) bool {
+		if hs[i].p.X != hs[j].p.X {
+			return hs[i].p.X < hs[j].p.X
+		}
+		return hs[i].p.Y < hs[j].p.Y
+	})
+	for _, h := range hs {
+		m, hb := findTouchingVertex(h, start)
+		if m == nil {
+			hb = h
+			m = findHoleBridge(h, start)
+		}
+		if m != nil {
+			start = filterRing(bridgeRings(m, hb))
+		}
+	}
+	if start == nil {
+		return nil
+	}
+	return clipEars(start)
+}
+
+// A triNode is a vertex in the doubly linked ring used for triangulation.
+type triNode struct {
+	i          int
+	p          Vec
+	prev, next *triNode
+}
+
+// linkRing creates a circular doubly linked list from the vertices of ring,
+// whose indices start at off, such that its orientation is o. Duplicate and
+// collinear vertices are removed. It returns nil if the ring is degenerate.
+func linkRing(ring Polygon, off int, o Orientation) *triNode {
+	switch ring.Orientation() {
+	case Collinear:
+		return nil
+	case o:
+		var last *triNode
+		for i, v := range ring {
+			last = last.insert(off+i, v)
+		}
+		return filterRing(last)
+	}
+	var last *triNode
+	for i := len(ring) - 1; i >= 0; i-- {
+		last = last.insert(off+i, ring[i])
+	}
+	return filterRing(last)
+}
+
+// insert inserts a new node after n, which may be nil, and returns it.
+func (n *triNode) insert(i int, p Vec) *triNode {
+	nn := &triNode{i: i, p: p}
+	if n == nil {
+		nn.prev, nn.next = nn, nn
+	} else {
+		nn.next, nn.prev = n.next, n
+		n.next.prev, n.next = nn, nn
+	}
+	return nn
+}
+
+// remove unlinks n from its ring.
+func (n *triNode) remove() {
+	n.next.prev, n.prev.next = n.prev, n.next
+}
+
+// leftmost returns the node of the ring of n having the lowest X, then the
+// lowest Y.
+func (n *triNode) leftmost() *triNode {
+	l := n
+	for p := n.next; p != n; p = p.next {
+		if p.p.X < l.p.X || p.p.X == l.p.X && p.p.Y < l.p.Y {
+			l = p
+		}
+	}
+	return l
+}
+
+// orient returns the orientation of the vertex n, given its neighbours.
+func (n *triNode) orient() Orientation {
+	return Orient(n.prev.p, n.p, n.next.p)
+}
+
+// filterRing removes the duplicate and collinear vertices of the ring of n.
+// It returns a remaining node or nil if less than 3 vertices remain.
+func filterRing(n *triNode) *triNode {
+	if n == nil {
+		return nil
+	}
+	end := n
+	for {
+		if n.next == n.prev {
+			return nil
+		}
+		if n.p == n.next.p || n.orient() == Collinear {
+			n.remove()
+			n, end = n.prev, n.prev
+			continue
+		}
+		if n = n.next; n == end {
+			return n
+		}
+	}
+}
+
+// locallyInside reports whether the diagonal from a to b starts inside the
+// counter-clockwise ring of a.
+func locallyInside(a, b *triNode) bool {
+	if a.orient() == Clockwise {
+		// reflex vertex, b must not be strictly in the exterior cone
+		return !(Orient(a.p, a.prev.p, b.p) == CounterClockwise &&
+			Orient(a.p, b.p, a.next.p) == CounterClockwise)
+	}
+	return Orient(a.p, a.next.p, b.p) != Clockwise &&
+		Orient(a.p, b.p, a.prev.p) != Clockwise
+}
+
+// findHoleBridge finds a vertex of the outer ring that can be connected to h,
+// the leftmost vertex of a hole, without crossing any edge.
+//
+// This is the method of David Eberly, with a ray cast to the left of h.
+func findHoleBridge(h, outer *triNode) *triNode {
+	hx, hy := h.p.X, h.p.Y
+	qx := math.Inf(-1)
+	var m *triNode
+
+	// find the nearest edge crossed by a ray cast from h to the left, its
+	// leftmost end point is a candidate.
+	p := outer
+	for {
+		a, b := p.p, p.next.p
+		if a.Y != b.Y && (a.Y <= hy && hy <= b.Y || b.Y <= hy && hy <= a.Y) {
+			x := a.X + (hy-a.Y)*(b.X-a.X)/(b.Y-a.Y)
+			if x <= hx && x > qx {
+				qx = x
+				m = p
+				if b.X < a.X {
+					m = p.next
+				}
+				if x == hx {
+					// h is on the edge
+					return m
+				}
+			}
+		}
+		if p = p.next; p == outer {
+			break
+		}
+	}
+	if m == nil {
+		return nil
+	}
+
+	// the candidate is visible from h, unless other vertices lie in the
+	// triangle made of h, the ray hit point and m: pick the one making the
+	// smallest angle with the ray.
+	tri := [3]Vec{h.p, {qx, hy}, m.p}
+	if hy < m.p.Y {
+		tri[0], tri[1] = tri[1], tri[0]
+	}
+	tanMin := math.Inf(1)
+	stop := m
+	for p := m; ; {
+		if hx > p.p.X && p.p.X >= m.p.X && inTriangle(tri[0], tri[1], tri[2], p.p) {
+			tan := math.Abs(hy-p.p.Y) / (hx - p.p.X)
+			if locallyInside(p, h) && (tan < tanMin || tan == tanMin && p.p.X > m.p.X) {
+				m, tanMin = p, tan
+			}
+		}
+		if p = p.next; p == stop {
+			break
+		}
+	}
+	return m
+}
+
+// findTouchingVertex looks for a vertex of the hole of h located at the same
+// position as a vertex of the outer ring, where the hole enters the ring. It
+// returns both vertices, or nil if the hole doesn't touch the ring at a
+// vertex.
+//
+// Bridging the rings at such a vertex, with an edge of null length, prevents
+// the bridge found by findHoleBridge from leaving a pinched ring that ear
+// clipping can't resolve.
+func findTouchingVertex(h, outer *triNode) (m, hv *triNode) {
+	for p := h; ; {
+		for q := outer; ; {
+			if q.p == p.p && locallyInside(q, p.prev) && locallyInside(q, p.next) {
+				return q, p
+			}
+			if q = q.next; q == outer {
+				break
+			}
+		}
+		if p = p.next; p == h {
+			return nil, nil
+		}
+	}
+}
+
+// bridgeRings merges the rings of a and b by linking a and b with a pair of
+// edges going in opposite directions. It returns the node of the
+// bridge that follows b.
+func bridgeRings(a, b *triNode) *triNode {
+	a2 := &triNode{i: a.i, p: a.p}
+	b2 := &triNode{i: b.i, p: b.p}
+	an, bp := a.next, b.prev
+
+	a.next, b.prev = b, a
+	a2.next, an.prev = an, a2
+	b2.next, a2.prev = a2, b2
+	bp.next, b2.prev = b2, bp
+	return b2
+}
+
+// inTriangle reports whether p is inside the counter-clockwise triangle a, b,
+// c, or on its boundary.
+func inTriangle(a, b, c, p Vec) bool {
+	return Orient(a, b, p) != Clockwise &&
+		Orient(b, c, p) != Clockwise &&
+		Orient(c, a, p) != Clockwise
+}
+
+// isEar reports whether the triangle made of ear and its neighbours is inside
+// the ring and contains no other vertex. If strict is false, vertices located
+// at the same position as one of the triangle corners are ignored.
+func isEar(ear *triNode, strict bool) bool {
+	a, b, c := ear.prev, ear, ear.next
+	if b.orient() != CounterClockwise {
+		return false
+	}
+	for p := c.next; p != a; p = p.next {
+		switch p.p {
+		case a.p:
+			if strict && entersCorner(p, a.p, b.p, c.p) {
+				return false
+			}
+		case b.p:
+			if strict && entersCorner(p, b.p, c.p, a.p) {
+				return false
+			}
+		case c.p:
+			if strict && entersCorner(p, c.p, a.p, b.p) {
+				return false
+			}
+		default:
+			if inTriangle(a.p, b.p, c.p, p.p) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// entersCorner reports whether one of the edges of p, which is located at the
+// corner v of the counter-clockwise triangle v, v1, v2, goes inside it.
+func entersCorner(p *triNode, v, v1, v2 Vec) bool {
+	for _, q := range [2]Vec{p.prev.p, p.next.p} {
+		if Orient(v, v1, q) == CounterClockwise && Orient(v, q, v2) == CounterClockwise {
+			return true
+		}
+	}
+	return false
+}
+
+// clipEars triangulates the counter-clockwise ring of n.
+func clipEars(n *triNode) [][3]int {
+	var tris [][3]int
+	ear, stop := n, n
+	pass := 0
+	for ear.prev != ear.next {
+		if pass == 2 || isEar(ear, pass == 0) {
+			tris = append(tris, [3]int{ear.prev.i, ear.i, ear.next.i})
+			ear.remove()
+			// where the ring touches itself, clipping an ear can leave a
+			// spike of null width, that must not be clipped as an ear.
+			if ear = filterRing(ear.next); ear == nil {
+				return tris
+			}
+			// skipping the next vertex leads to less sliver triangles
+			ear = ear.next
+			stop, pass = ear, 0
+			continue
+		}
+		if ear = ear.next; ear != stop {
+			continue
+		}
+
+		// no ear found in a whole turn
+		switch pass {
+		case 0:
+			// relax the test for vertices touching the triangles
+			if ear = filterRing(ear); ear == nil {
+				return tris
+			}
+			pass = 1
+		case 1:
+			// invalid input, clip any convex vertex to terminate
+			for ear.orient() != CounterClockwise && ear.next != stop {
+				ear = ear.next
+			}
+			pass = 2
+		}
+		stop = ear
+	}
+	return tris
+}
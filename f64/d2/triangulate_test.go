@@ -0,0 +1,108 @@
+package d2
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+// checkTriangulation checks that tris are non-degenerate counter-clockwise
+// triangles whose total area is the area of outer minus those of holes.
+func checkTriangulation(t *testing.T, tris [][3]int, outer Polygon, holes ...Polygon) {
+	t.Helper()
+	vs := append(Path{}, outer...)
+	want := outer.Area()
+	for _, h := range holes {
+		vs = append(vs, h...)
+		want -= h.Area()
+	}
+	var got float64
+	for _, tri := range tris {
+		a := Polygon{vs[tri[0]], vs[tri[1]], vs[tri[2]]}.SignedArea()
+		if a <= 0 {
+			t.Errorf("triangle %v of %v, %v is clockwise or degenerate", tri, outer, holes)
+		}
+		got += a
+	}
+	if math.Abs(got-want) > 1e-9*want {
+		t.Errorf("Triangulate(%v, %v) area = %v, want %v", outer, holes, got, want)
+	}
+}
+
+func TestTriangulate(t *testing.T) {
+	var tests = []struct {
+		outer Polygon
+		holes []Polygon
+		ntris int
+	}{
+		{Polygon{{0, 0}, {1, 0}, {0, 1}}, nil, 1},
+		{square, nil, 2},
+		{Polygon{{0, 0}, {0, 2}, {2, 2}, {2, 0}}, nil, 2},
+		{lshape, nil, 4},
+		// collinear and duplicate vertices
+		{Polygon{{0, 0}, {1, 0}, {1, 0}, {2, 0}, {2, 2}, {0, 2}, {0, 2}}, nil, 2},
+		{Polygon{{0, 0}, {1, 0}, {2, 0}}, nil, 0},
+		{frame().Outer, frame().Holes, 14},
+		// hole touching the outer ring
+		{square, []Polygon{{{0, 1}, {1, 2}, {1, 1}}}, 0},
+		{Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, []Polygon{{{10, 0}, {5, 5}, {8, 7}}}, 5},
+		{Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, []Polygon{{{0, 10}, {5, 5}, {2, 4}}}, 5},
+		// holes touching each other
+		{Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, []Polygon{{{2, 2}, {5, 5}, {2, 5}}, {{5, 5}, {8, 8}, {8, 5}}}, 10},
+		{Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, []Polygon{{{10, 0}, {5, 5}, {8, 7}}, {{5, 5}, {2, 8}, {3, 3}}}, 0},
+		// hole orientation does not matter
+		{Polygon{{0, 0}, {10, 0}, {10, 10}, {0, 10}}, []Polygon{{{4, 4}, {6, 4}, {6, 6}, {4, 6}}}, 8},
+	}
+	for _, tt := range tests {
+		tris := Triangulate(tt.outer, tt.holes...)
+		if tt.ntris != 0 && len(tris) != tt.ntris {
+			t.Errorf("Triangulate(%v, %v) returned %d triangles, want %d", tt.outer, tt.holes, len(tris), tt.ntris)
+		}
+		if tt.outer.Area() == 0 {
+			if tris != nil {
+				t.Errorf("Triangulate(%v) = %v, want nil", tt.outer, tris)
+			}
+			continue
+		}
+		checkTriangulation(t, tris, tt.outer, tt.holes...)
+	}
+}
+
+func TestTriangulateRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 200; i++ {
+		// star shaped polygon with a hole around its center
+		n := 3 + rng.Intn(30)
+		outer := make(Polygon, n)
+		hole := make(Polygon, 0, n)
+		for j := range outer {
+			a := 2 * math.Pi * float64(j) / float64(n)
+			r := 2 + 8*rng.Float64()
+			outer[j] = Vec{10 + r*math.Cos(a), 10 + r*math.Sin(a)}
+			if j%2 == 0 {
+				hole = append(hole, Vec{10 + math.Cos(a), 10 + math.Sin(a)})
+			}
+		}
+		checkTriangulation(t, Triangulate(outer), outer)
+		if len(hole) >= 3 {
+			checkTriangulation(t, Triangulate(outer, hole), outer, hole)
+		}
+
+		// holes touching the outer ring and each other at vertices. Angles
+		// are jittered so that no vertex is aligned with c and another one.
+		if n >= 4 {
+			c := Vec{10, 10}
+			for j := range outer {
+				a := 2 * math.Pi * (float64(j) + 0.1 + 0.8*rng.Float64()) / float64(n)
+				r := 2 + 8*rng.Float64()
+				outer[j] = Vec{10 + r*math.Cos(a), 10 + r*math.Sin(a)}
+			}
+			// a point strictly inside the triangle c, outer[i], outer[i+1]
+			inside := func(i int) Vec { return c.Mul(2).Add(outer[i]).Add(outer[i+1]).Mul(0.25) }
+			h1 := Polygon{outer[0], c, inside(0)}
+			h2 := Polygon{c, outer[n/2], inside(n / 2)}
+			checkTriangulation(t, Triangulate(outer, h1), outer, h1)
+			checkTriangulation(t, Triangulate(outer, h1, h2), outer, h1, h2)
+		}
+	}
+}
@@ -78,6 +78,21 @@ func (v Vec) Dot(v2 Vec) float64 {
 	return v.X*v2.X + v.Y*v2.Y
 }
 
+// Cross returns the z component of the 3D cross product of v and v2, also
+// called the perp dot product:
+//  v.x * v2.y - v.y * v2.x
+//
+// It is positive if v2 is counter-clockwise from v (in a Y-up frame), negative
+// if it's clockwise and 0 if both vectors are collinear.
+func (v Vec) Cross(v2 Vec) float64 {
+	return v.X*v2.Y - v.Y*v2.X
+}
+
+// Dist returns the euclidean distance between v and v2.
+func (v Vec) Dist(v2 Vec) float64 {
+	return math.Hypot(v2.X-v.X, v2.Y-v.Y)
+}
+
 // Len returns the vector's length.
 func (v Vec) Len() float64 {
 	return float64(math.Hypot(float64(v.X), float64(v.Y)))